@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log"
 	"os"
 
@@ -12,8 +13,10 @@ import (
 )
 
 var (
-	helpFlag   = flag.Bool("help", false, "Show help message")
-	configPath = flag.String("config-path", "config.yaml", "Path to the config yaml file")
+	helpFlag       = flag.Bool("help", false, "Show help message")
+	configPath     = flag.String("config-path", "config.yaml", "Path to the config yaml file")
+	printConfig    = flag.Bool("print-config", false, "Print config with sources of values and exit")
+	validateConfig = flag.Bool("validate-config", false, "Validate config and exit")
 )
 
 func Run() {
@@ -27,13 +30,29 @@ func Run() {
 
 	// Init config
 	cfg, err := config.New(*configPath)
-	if err != nil {
-		log.Fatal("failed to configure application", err)
-		config.PrintHelp()
+	switch {
+	case *printConfig:
+		if cfg != nil {
+			config.PrintConfig(cfg)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	case *validateConfig:
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Println("configuration is valid")
+		return
+	case err != nil:
+		log.Fatal("failed to configure application: ", err)
 	}
 
 	// Init logger
-	logger := logger.InitLogger(string(cfg.Mode), cfg.LogLevel)
+	logger := logger.InitLogger(string(cfg.Mode), cfg.LoggerOptions())
 
 	config.PrintConfig(cfg)
 
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+
+	"wheres-my-pizza/internal/adapter/http/handler/dto"
+	"wheres-my-pizza/internal/domain/types"
+	"wheres-my-pizza/pkg/logger"
+	"wheres-my-pizza/pkg/validator"
+)
+
+// Admin controls the running process. The logger is shared by all services of the process,
+// so its levels are changed for every one of them.
+type Admin struct {
+	log logger.Logger
+}
+
+func NewAdmin(log logger.Logger) *Admin {
+	return &Admin{
+		log: log,
+	}
+}
+
+// GetLogLevel returns levels of the service and components which override it.
+func (h *Admin) GetLogLevel(w http.ResponseWriter, r *http.Request) {
+	if err := writeJSON(w, http.StatusOK, envelope{"log_level": h.log.Levels()}, nil); err != nil {
+		internalErrorResponse(w, r, h.log, err)
+	}
+}
+
+// SetLogLevel changes levels until restart or config reload.
+func (h *Admin) SetLogLevel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req dto.SetLogLevelRequest
+	if err := readJSON(w, r, &req); err != nil {
+		h.log.Error(ctx, types.ActionValidationFailed, "failed to decode request", err)
+		errorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	dto.ValidateSetLogLevelRequest(v, req)
+	if !v.Valid() {
+		failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	if req.Level != nil {
+		if err := h.log.SetLevel(*req.Level); err != nil {
+			internalErrorResponse(w, r, h.log, err)
+			return
+		}
+	}
+	for component, lvl := range req.Components {
+		if err := h.log.SetComponentLevel(component, lvl); err != nil {
+			internalErrorResponse(w, r, h.log, err)
+			return
+		}
+	}
+
+	levels := h.log.Levels()
+	h.log.Info(ctx, types.ActionLogLevelChanged, "log level changed", "level", levels.Level, "components", levels.Components, "changed-by", callerName(ctx))
+
+	if err := writeJSON(w, http.StatusOK, envelope{"log_level": levels}, nil); err != nil {
+		internalErrorResponse(w, r, h.log, err)
+	}
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"wheres-my-pizza/internal/adapter/http/handler/dto"
+	"wheres-my-pizza/internal/domain/models"
+	"wheres-my-pizza/internal/domain/types"
+	"wheres-my-pizza/pkg/logger"
+	"wheres-my-pizza/pkg/validator"
+)
+
+type AuthService interface {
+	Authenticate(ctx context.Context, key string) (models.APIKey, error)
+	IssueKey(ctx context.Context, name, role string, customerID *int, createdBy string) (models.IssuedAPIKey, error)
+	RevokeKey(ctx context.Context, id int, revokedBy string) (models.APIKey, error)
+	ListKeys(ctx context.Context) ([]models.APIKey, error)
+	Audit(ctx context.Context, entry models.AuditEntry)
+	ListAudit(ctx context.Context, keyID *int, limit int) ([]models.AuditEntry, error)
+}
+
+type Auth struct {
+	service AuthService
+	log     logger.Logger
+}
+
+func NewAuth(service AuthService, log logger.Logger) *Auth {
+	return &Auth{
+		service: service,
+		log:     log,
+	}
+}
+
+// IssueAPIKey issues new API key, its plain value is shown only in this response.
+func (h *Auth) IssueAPIKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req dto.IssueAPIKeyRequest
+	if err := readJSON(w, r, &req); err != nil {
+		h.log.Error(ctx, types.ActionValidationFailed, "failed to decode request", err)
+		errorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	dto.ValidateIssueAPIKeyRequest(v, req)
+	if !v.Valid() {
+		failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	key, err := h.service.IssueKey(ctx, req.Name, req.Role, req.CustomerID, callerName(ctx))
+	if err != nil {
+		if errors.Is(err, models.ErrCustomerNotFound) {
+			failedValidationResponse(w, r, map[string]string{"customer_id": models.Message(err)})
+			return
+		}
+		serviceErrorResponse(w, r, h.log, err)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusCreated, envelope{"api_key": key}, nil); err != nil {
+		internalErrorResponse(w, r, h.log, err)
+	}
+}
+
+func (h *Auth) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.service.ListKeys(r.Context())
+	if err != nil {
+		serviceErrorResponse(w, r, h.log, err)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"api_keys": keys}, nil); err != nil {
+		internalErrorResponse(w, r, h.log, err)
+	}
+}
+
+// RevokeAPIKey revokes API key, requests with it are rejected right away.
+func (h *Auth) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := readIDParam(r, "id")
+	if err != nil {
+		errorResponse(w, r, http.StatusNotFound, models.ErrAPIKeyNotFound.Error())
+		return
+	}
+
+	key, err := h.service.RevokeKey(ctx, id, callerName(ctx))
+	if err != nil {
+		serviceErrorResponse(w, r, h.log, err)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"api_key": key}, nil); err != nil {
+		internalErrorResponse(w, r, h.log, err)
+	}
+}
+
+// ListAuditLog returns latest authenticated requests.
+// Filtered by 'key_id' and limited with 'limit' (1-1000, default 100) query parameters.
+func (h *Auth) ListAuditLog(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	v := validator.New()
+	qs := r.URL.Query()
+	limit := readInt(qs, "limit", 100, v)
+	v.Check(limit >= 1 && limit <= 1000, "limit", "must be between 1 and 1000")
+
+	var keyID *int
+	if qs.Has("key_id") {
+		id := readInt(qs, "key_id", 0, v)
+		keyID = &id
+	}
+	if !v.Valid() {
+		failedParametersResponse(w, r, v.Errors)
+		return
+	}
+
+	entries, err := h.service.ListAudit(ctx, keyID, limit)
+	if err != nil {
+		serviceErrorResponse(w, r, h.log, err)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"audit_log": entries}, nil); err != nil {
+		internalErrorResponse(w, r, h.log, err)
+	}
+}
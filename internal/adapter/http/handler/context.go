@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"context"
+
+	"wheres-my-pizza/internal/domain/models"
+	"wheres-my-pizza/internal/domain/types"
+)
+
+type contextKey string
+
+const apiKeyContextKey = contextKey("api_key")
+
+// WithAPIKey returns ctx with authenticated API key of the request.
+func WithAPIKey(ctx context.Context, key models.APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyContextKey, key)
+}
+
+// APIKeyFromContext returns authenticated API key of the request, false if authentication is disabled.
+func APIKeyFromContext(ctx context.Context) (models.APIKey, bool) {
+	key, ok := ctx.Value(apiKeyContextKey).(models.APIKey)
+	return key, ok
+}
+
+// authorizeCustomer returns models.ErrNotOwnCustomer if the request is made with a customer key
+// of another customer. Other roles may access every customer.
+func authorizeCustomer(ctx context.Context, customerID int) error {
+	key, ok := APIKeyFromContext(ctx)
+	if !ok || key.Role != types.RoleCustomer {
+		return nil
+	}
+	if key.CustomerID == nil || *key.CustomerID != customerID {
+		return models.ErrNotOwnCustomer
+	}
+	return nil
+}
+
+// authorizeOrder returns models.ErrNotOwnCustomer if the request is made with a customer key and
+// the order is placed for another customer.
+func authorizeOrder(ctx context.Context, order *models.CreateOrder) error {
+	if order.CustomerID == nil {
+		return nil
+	}
+	return authorizeCustomer(ctx, *order.CustomerID)
+}
+
+// callerName returns name of the API key of the request for audit fields.
+func callerName(ctx context.Context) string {
+	if key, ok := APIKeyFromContext(ctx); ok {
+		return key.Name
+	}
+	return "anonymous"
+}
+
+// requestID returns request_id of the request, see logger.WithRequestID.
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(models.GetRequestIDKey()).(string)
+	return id
+}
@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"net/http"
+
+	"wheres-my-pizza/internal/adapter/http/handler/dto"
+	"wheres-my-pizza/internal/domain/models"
+	"wheres-my-pizza/internal/domain/types"
+	"wheres-my-pizza/pkg/validator"
+)
+
+// Customer accounts are managed by the order service, tracking service only reads them.
+
+// CreateCustomer creates customer account
+func (h *Order) CreateCustomer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req dto.CreateCustomerRequest
+	if err := readJSON(w, r, &req); err != nil {
+		h.log.Error(ctx, types.ActionValidationFailed, "failed to decode request", err)
+		errorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	dto.ValidateCreateCustomerRequest(v, req)
+	if !v.Valid() {
+		failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	customer, err := h.service.CreateCustomer(ctx, dto.FromRequestToCustomer(req))
+	if err != nil {
+		serviceErrorResponse(w, r, h.log, err)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusCreated, envelope{"customer": customer}, nil); err != nil {
+		internalErrorResponse(w, r, h.log, err)
+	}
+}
+
+// AddCustomerAddress saves delivery address of the customer
+func (h *Order) AddCustomerAddress(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	customerID, err := readIDParam(r, "id")
+	if err != nil {
+		errorResponse(w, r, http.StatusNotFound, models.ErrCustomerNotFound.Error())
+		return
+	}
+	if err := authorizeCustomer(r.Context(), customerID); err != nil {
+		serviceErrorResponse(w, r, h.log, err)
+		return
+	}
+
+	var req dto.CustomerAddressRequest
+	if err := readJSON(w, r, &req); err != nil {
+		h.log.Error(ctx, types.ActionValidationFailed, "failed to decode request", err)
+		errorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	dto.ValidateCustomerAddressRequest(v, req)
+	if !v.Valid() {
+		failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	address, err := h.service.AddCustomerAddress(ctx, models.CustomerAddress{
+		CustomerID: customerID,
+		Label:      req.Label,
+		Address:    req.Address,
+	})
+	if err != nil {
+		serviceErrorResponse(w, r, h.log, err)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusCreated, envelope{"address": address}, nil); err != nil {
+		internalErrorResponse(w, r, h.log, err)
+	}
+}
+
+// DeleteCustomerAddress deletes saved address of the customer
+func (h *Order) DeleteCustomerAddress(w http.ResponseWriter, r *http.Request) {
+	customerID, err := readIDParam(r, "id")
+	if err != nil {
+		errorResponse(w, r, http.StatusNotFound, models.ErrCustomerNotFound.Error())
+		return
+	}
+	if err := authorizeCustomer(r.Context(), customerID); err != nil {
+		serviceErrorResponse(w, r, h.log, err)
+		return
+	}
+	addressID, err := readIDParam(r, "address_id")
+	if err != nil {
+		errorResponse(w, r, http.StatusNotFound, models.ErrAddressNotFound.Error())
+		return
+	}
+
+	if err := h.service.DeleteCustomerAddress(r.Context(), customerID, addressID); err != nil {
+		serviceErrorResponse(w, r, h.log, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddCustomerFavorite adds menu item to favorites of the customer
+func (h *Order) AddCustomerFavorite(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	customerID, err := readIDParam(r, "id")
+	if err != nil {
+		errorResponse(w, r, http.StatusNotFound, models.ErrCustomerNotFound.Error())
+		return
+	}
+	if err := authorizeCustomer(r.Context(), customerID); err != nil {
+		serviceErrorResponse(w, r, h.log, err)
+		return
+	}
+
+	var req dto.FavoriteItemRequest
+	if err := readJSON(w, r, &req); err != nil {
+		h.log.Error(ctx, types.ActionValidationFailed, "failed to decode request", err)
+		errorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	dto.ValidateFavoriteItemRequest(v, req)
+	if !v.Valid() {
+		failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	favorite, err := h.service.AddCustomerFavorite(ctx, models.FavoriteItem{
+		CustomerID: customerID,
+		Name:       req.Name,
+		Price:      req.Price,
+	})
+	if err != nil {
+		serviceErrorResponse(w, r, h.log, err)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusCreated, envelope{"favorite": favorite}, nil); err != nil {
+		internalErrorResponse(w, r, h.log, err)
+	}
+}
+
+// DeleteCustomerFavorite removes menu item from favorites of the customer
+func (h *Order) DeleteCustomerFavorite(w http.ResponseWriter, r *http.Request) {
+	customerID, err := readIDParam(r, "id")
+	if err != nil {
+		errorResponse(w, r, http.StatusNotFound, models.ErrCustomerNotFound.Error())
+		return
+	}
+	if err := authorizeCustomer(r.Context(), customerID); err != nil {
+		serviceErrorResponse(w, r, h.log, err)
+		return
+	}
+	favoriteID, err := readIDParam(r, "favorite_id")
+	if err != nil {
+		errorResponse(w, r, http.StatusNotFound, models.ErrFavoriteNotFound.Error())
+		return
+	}
+
+	if err := h.service.DeleteCustomerFavorite(r.Context(), customerID, favoriteID); err != nil {
+		serviceErrorResponse(w, r, h.log, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetCustomer returns customer with saved addresses and favorites.
+func (h *Tracking) GetCustomer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := readIDParam(r, "id")
+	if err != nil {
+		errorResponse(w, r, http.StatusNotFound, models.ErrCustomerNotFound.Error())
+		return
+	}
+	if err := authorizeCustomer(r.Context(), id); err != nil {
+		serviceErrorResponse(w, r, h.log, err)
+		return
+	}
+
+	customer, err := h.service.GetCustomer(ctx, id)
+	if err != nil {
+		serviceErrorResponse(w, r, h.log, err)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"customer": customer}, nil); err != nil {
+		internalErrorResponse(w, r, h.log, err)
+	}
+}
+
+// ListCustomerOrders returns order history of the customer, newest first.
+// Paginated with 'limit' (1-100, default 20) and 'offset' query parameters.
+func (h *Tracking) ListCustomerOrders(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := readIDParam(r, "id")
+	if err != nil {
+		errorResponse(w, r, http.StatusNotFound, models.ErrCustomerNotFound.Error())
+		return
+	}
+	if err := authorizeCustomer(r.Context(), id); err != nil {
+		serviceErrorResponse(w, r, h.log, err)
+		return
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+	limit := readInt(qs, "limit", 20, v)
+	offset := readInt(qs, "offset", 0, v)
+	v.Check(limit >= 1 && limit <= 100, "limit", "must be between 1 and 100")
+	v.Check(offset >= 0, "offset", "must not be negative")
+	if !v.Valid() {
+		failedParametersResponse(w, r, v.Errors)
+		return
+	}
+
+	orders, err := h.service.ListCustomerOrders(ctx, id, limit, offset)
+	if err != nil {
+		serviceErrorResponse(w, r, h.log, err)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, envelope{"customer_id": id, "orders": orders}, nil); err != nil {
+		internalErrorResponse(w, r, h.log, err)
+	}
+}
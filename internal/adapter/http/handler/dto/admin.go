@@ -0,0 +1,24 @@
+package dto
+
+import (
+	"wheres-my-pizza/pkg/logger"
+	"wheres-my-pizza/pkg/validator"
+)
+
+var logLevels = []string{logger.LevelDebug, logger.LevelInfo, logger.LevelWarn, logger.LevelError}
+
+type SetLogLevelRequest struct {
+	Level      *string           `json:"level"`      // level of the service, unchanged if omitted
+	Components map[string]string `json:"components"` // component=level, empty level removes the override
+}
+
+func ValidateSetLogLevelRequest(v *validator.Validator, req SetLogLevelRequest) {
+	v.Check(req.Level != nil || len(req.Components) > 0, "level", "level or components must be provided")
+	if req.Level != nil {
+		v.Check(validator.PermittedValue(*req.Level, logLevels...), "level", "must be one of: 'DEBUG', 'INFO', 'WARN' or 'ERROR'")
+	}
+	for component, lvl := range req.Components {
+		v.Check(component != "", "components", "component name must not be empty")
+		v.Check(lvl == "" || validator.PermittedValue(lvl, logLevels...), validator.Path("components", component), "must be one of: 'DEBUG', 'INFO', 'WARN', 'ERROR' or empty")
+	}
+}
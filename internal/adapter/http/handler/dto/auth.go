@@ -0,0 +1,28 @@
+package dto
+
+import (
+	"unicode/utf8"
+
+	"wheres-my-pizza/internal/domain/types"
+	"wheres-my-pizza/pkg/validator"
+)
+
+type IssueAPIKeyRequest struct {
+	Name string `json:"name"` // who the key is for, e.g. 'ios-app' or 'partner-glovo'
+	Role string `json:"role"`
+	// Customer of a customer key, required for customer keys only
+	CustomerID *int `json:"customer_id,omitempty"`
+}
+
+func ValidateIssueAPIKeyRequest(v *validator.Validator, req IssueAPIKeyRequest) {
+	v.Check(utf8.RuneCountInString(req.Name) >= 1 && utf8.RuneCountInString(req.Name) <= 100, "name", "must be between 1-100 characters")
+	v.Check(validator.PermittedValue(req.Role, types.AllRoles...), "role", "must be one of: 'customer', 'kitchen', 'manager' or 'partner'")
+	if req.Role == types.RoleCustomer {
+		v.Check(req.CustomerID != nil, "customer_id", "must be provided for a customer key")
+	} else {
+		v.Check(req.CustomerID == nil, "customer_id", "must be provided only for a customer key")
+	}
+	if req.CustomerID != nil {
+		v.Check(*req.CustomerID > 0, "customer_id", "must be a positive integer")
+	}
+}
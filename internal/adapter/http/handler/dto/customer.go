@@ -0,0 +1,66 @@
+package dto
+
+import (
+	"regexp"
+
+	"wheres-my-pizza/internal/domain/models"
+	"wheres-my-pizza/pkg/validator"
+)
+
+// phoneRX matches phone numbers in international format, e.g. +77011234567.
+var phoneRX = regexp.MustCompile(`^\+?[0-9]{7,15}$`)
+
+type CreateCustomerRequest struct {
+	Name  string  `json:"name"`
+	Phone *string `json:"phone,omitempty"`
+	Email *string `json:"email,omitempty"`
+}
+
+type CustomerAddressRequest struct {
+	Label   *string `json:"label,omitempty"`
+	Address string  `json:"address"`
+}
+
+type FavoriteItemRequest struct {
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+}
+
+func FromRequestToCustomer(req CreateCustomerRequest) models.Customer {
+	return models.Customer{
+		Name:  req.Name,
+		Phone: req.Phone,
+		Email: req.Email,
+	}
+}
+
+func ValidateCreateCustomerRequest(v *validator.Validator, req CreateCustomerRequest) {
+	v.Check(
+		isValidCustomerName(req.Name),
+		"name",
+		"1-100 characters. Must not contain special characters other than spaces, hyphens, and apostrophes.",
+	)
+
+	v.Check(req.Phone != nil || req.Email != nil, "phone", "phone or email is required")
+
+	if req.Phone != nil {
+		v.Check(validator.Matches(*req.Phone, phoneRX), "phone", "must be 7-15 digits with optional leading '+'")
+	}
+
+	if req.Email != nil {
+		v.Check(validator.Matches(*req.Email, validator.EmailRX), "email", "must be a valid email address")
+	}
+}
+
+func ValidateCustomerAddressRequest(v *validator.Validator, req CustomerAddressRequest) {
+	v.Check(len(req.Address) >= 10, "address", "must be at least 10 characters")
+
+	if req.Label != nil {
+		v.Check(len(*req.Label) >= 1 && len(*req.Label) <= 50, "label", "must be between 1-50 characters")
+	}
+}
+
+func ValidateFavoriteItemRequest(v *validator.Validator, req FavoriteItemRequest) {
+	v.Check(isValidItemName(req.Name), "name", "must be between 1-50 characters")
+	v.Check(req.Price >= 0.01 && req.Price <= 999.99, "price", "must be between `0.01` and `999.99`")
+}
@@ -5,10 +5,12 @@ import "wheres-my-pizza/internal/domain/models"
 
 type CreateOrderRequest struct {
 	CustomerName    string      `json:"customer_name"`
+	CustomerID      *int        `json:"customer_id,omitempty"` // Registered customer, customer_name is optional then
 	OrderType       string      `json:"order_type"`
 	Items           []OrderItem `json:"items"`
 	TableNumber     *int        `json:"table_number,omitempty"`     // Only for dine_in
 	DeliveryAddress *string     `json:"delivery_address,omitempty"` // Only for delivery
+	AddressID       *int        `json:"address_id,omitempty"`       // Only for delivery, saved address of the customer
 }
 
 type OrderItem struct {
@@ -30,10 +32,12 @@ func FromRequestToInternalCreateOrder(req CreateOrderRequest) *models.CreateOrde
 
 	return &models.CreateOrder{
 		CustomerName:    req.CustomerName,
+		CustomerID:      req.CustomerID,
 		Type:            req.OrderType,
 		Items:           items,
 		TableNumber:     req.TableNumber,
 		DeliveryAddress: req.DeliveryAddress,
+		AddressID:       req.AddressID,
 		// These fields will be set later in the business logic
 		Number:      "",
 		TotalAmount: 0,
@@ -47,3 +51,18 @@ type CreateOrderResponse struct {
 	Status      string  `json:"status"`
 	TotalAmount float64 `json:"total_amount"`
 }
+
+type CreateOrderBatchRequest struct {
+	Orders  []CreateOrderRequest `json:"orders"`
+	Partial bool                 `json:"partial"` // create valid orders even if others fail
+}
+
+// BatchOrderResult is an outcome of an order of the batch by its index in the request.
+type BatchOrderResult struct {
+	Index       int          `json:"index"`
+	OrderNumber string       `json:"order_number,omitempty"`
+	Status      string       `json:"status,omitempty"`
+	TotalAmount float64      `json:"total_amount,omitempty"`
+	Error       string       `json:"error,omitempty"`
+	Errors      []FieldError `json:"errors,omitempty"` // validation errors
+}
@@ -0,0 +1,67 @@
+package dto
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+
+	"wheres-my-pizza/pkg/validator"
+)
+
+// ProblemContentType is the media type of error responses.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an error response as RFC 9457 defines it.
+type Problem struct {
+	Type     string       `json:"type"`  // "about:blank": the status explains the problem
+	Title    string       `json:"title"` // text of the status
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"` // request_id of the request
+	Errors   []FieldError `json:"errors,omitempty"`   // invalid fields and parameters of the request
+}
+
+// FieldError is an invalid field of the request body or an invalid query parameter.
+type FieldError struct {
+	Pointer   string `json:"pointer,omitempty"`   // JSON pointer to the field, e.g. "/items/3/price"
+	Parameter string `json:"parameter,omitempty"` // name of the query parameter
+	Detail    string `json:"detail"`
+}
+
+// NewProblem creates problem of the status.
+func NewProblem(status int, detail string) Problem {
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// FieldErrors converts validator errors of the request body to field errors sorted by pointer.
+// Keys are validator paths, see validator.Path, of the field at the given path, e.g. of an order
+// of the batch: FieldErrors(errs, "orders", 2).
+func FieldErrors(errs map[string]string, at ...any) []FieldError {
+	prefix := "/"
+	if len(at) > 0 {
+		prefix += validator.Path(at...) + "/"
+	}
+	return fieldErrors(errs, func(key, msg string) FieldError {
+		return FieldError{Pointer: prefix + key, Detail: msg}
+	})
+}
+
+// ParameterErrors converts validator errors of query parameters to field errors sorted by name.
+func ParameterErrors(errs map[string]string) []FieldError {
+	return fieldErrors(errs, func(key, msg string) FieldError {
+		return FieldError{Parameter: key, Detail: msg}
+	})
+}
+
+func fieldErrors(errs map[string]string, newError func(key, msg string) FieldError) []FieldError {
+	out := make([]FieldError, 0, len(errs))
+	for _, key := range slices.Sorted(maps.Keys(errs)) {
+		out = append(out, newError(key, errs[key]))
+	}
+	return out
+}
@@ -14,6 +14,7 @@ import (
 // | `tag`           | `required type` | `description`                                                                                      |
 // | --------------- | --------------- | -------------------------------------------------------------------------------------------------- |
 // | `customer_name` | string           | 1-100 characters. Must not contain special characters other than spaces, hyphens, and apostrophes. |
+// |                 |                  | Optional when `customer_id` is given, name of the customer account is used.                        |
 // | `order_type`    | string           | Must be one of: `'dine_in'`, `'takeout'`, or `'delivery'`.                                         |
 // | `items`         | array            | Must contain between 1 and 20 items.                                                               |
 // | `item.name`     | string           | 1-50 characters.                                                                                   |
@@ -26,6 +27,7 @@ import (
 // | ------------ | ---------------------------------------------- | --------------------------------------------- | --------------------- |
 // | `'dine_in'`  | `table_number` (integer, 1-100)                | Table number at which the customer is served. | `delivery_address`    |
 // | `'delivery'` | `delivery_address` (string, min 10 characters) | Address for delivery of the order by courier. | `table_number`        |
+// |              | or `address_id` (saved address of customer)    |                                               |                       |
 
 var ValidOrderTypes = []string{
 	types.OrderTypeDineIn,
@@ -38,11 +40,22 @@ func ValidateCreateOrderRequest(v *validator.Validator, req *models.CreateOrder)
 		return
 	}
 
-	v.Check(
-		isValidCustomerName(req.CustomerName),
-		"customer_name",
-		"1-100 characters. Must not contain special characters other than spaces, hyphens, and apostrophes.",
-	)
+	// Registered customer may omit customer_name, name of the account is used then
+	if req.CustomerID == nil || req.CustomerName != "" {
+		v.Check(
+			isValidCustomerName(req.CustomerName),
+			"customer_name",
+			"1-100 characters. Must not contain special characters other than spaces, hyphens, and apostrophes.",
+		)
+	}
+
+	if req.CustomerID != nil {
+		v.Check(*req.CustomerID > 0, "customer_id", "must be a positive integer")
+	}
+
+	if req.AddressID != nil {
+		v.Check(req.CustomerID != nil, "address_id", "requires customer_id")
+	}
 
 	// Check if order_type in request contains in ValidOrderTypes
 	v.Check(
@@ -60,22 +73,22 @@ func ValidateCreateOrderRequest(v *validator.Validator, req *models.CreateOrder)
 		"must contain between 1 and 20 items.",
 	)
 
-	for _, item := range req.Items {
+	for i, item := range req.Items {
 		v.Check(
 			isValidItemName(item.Name),
-			"item.name",
+			validator.Path("items", i, "name"),
 			"must be between 1-50 characters",
 		)
 
 		v.Check(
 			item.Quantity >= 1 && item.Quantity <= 10,
-			"item.quantity",
+			validator.Path("items", i, "quantity"),
 			"must be between 1 and 10",
 		)
 
 		v.Check(
 			item.Price >= 0.01 && item.Price <= 999.99,
-			"item.Price",
+			validator.Path("items", i, "price"),
 			"must be between `0.01` and `999.99`",
 		)
 	}
@@ -106,11 +119,23 @@ func vaildateOrdertype(v *validator.Validator, req *models.CreateOrder) {
 			"must not be present for dine_in orders",
 		)
 
+		v.Check(
+			req.AddressID == nil,
+			"address_id",
+			"must not be present for dine_in orders",
+		)
+
 	case types.OrderTypeDelivery:
 		v.Check(
-			req.DeliveryAddress != nil,
+			req.DeliveryAddress != nil || req.AddressID != nil,
 			"delivery_address",
-			"required for delivery orders",
+			"required for delivery orders unless address_id is given",
+		)
+
+		v.Check(
+			req.DeliveryAddress == nil || req.AddressID == nil,
+			"address_id",
+			"must not be present together with delivery_address",
 		)
 
 		if req.DeliveryAddress != nil {
@@ -139,6 +164,12 @@ func vaildateOrdertype(v *validator.Validator, req *models.CreateOrder) {
 			"delivery_address",
 			"must not be present for takeout orders",
 		)
+
+		v.Check(
+			req.AddressID == nil,
+			"address_id",
+			"must not be present for takeout orders",
+		)
 	}
 }
 
@@ -0,0 +1,18 @@
+package dto
+
+import (
+	"wheres-my-pizza/internal/domain/types"
+	"wheres-my-pizza/pkg/validator"
+)
+
+type WorkerOrderTypesRequest struct {
+	OrderTypes []string `json:"order_types"`
+}
+
+func ValidateWorkerOrderTypesRequest(v *validator.Validator, req WorkerOrderTypesRequest) {
+	v.Check(len(req.OrderTypes) > 0, "order_types", "must contain at least one order type")
+	v.Check(validator.Unique(req.OrderTypes), "order_types", "must not contain duplicate order types")
+	for i, orderType := range req.OrderTypes {
+		v.Check(types.IsValidOrderType(orderType), validator.Path("order_types", i), "must be one of 'dine_in', 'takeout' or 'delivery'")
+	}
+}
@@ -1,22 +1,77 @@
 package handler
 
-import "net/http"
+import (
+	"encoding/json"
+	"net/http"
 
-func errorResponse(w http.ResponseWriter, status int, message any) {
-	env := envelope{"error": message}
+	"wheres-my-pizza/internal/adapter/http/handler/dto"
+	"wheres-my-pizza/internal/domain/models"
+	"wheres-my-pizza/internal/domain/types"
+	"wheres-my-pizza/pkg/logger"
+)
 
-	// Write the response using the writeJSON() helper. If this happens to return an
-	// error then log it, and fall back to sending the client an empty response with a
-	// 500 Internal Server Error status code.
-	if err := writeJSON(w, status, env, nil); err != nil {
-		w.WriteHeader(500)
+// internalErrorDetail is shown to clients instead of details of internal errors, they go to logs only.
+const internalErrorDetail = "the server encountered a problem and could not process the request"
+
+// writeProblem writes problem+json response, request_id of the request is its instance.
+func writeProblem(w http.ResponseWriter, r *http.Request, problem dto.Problem) {
+	problem.Instance = requestID(r.Context())
+
+	js, err := json.Marshal(problem)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
+
+	w.Header().Set("Content-Type", dto.ProblemContentType)
+	w.WriteHeader(problem.Status)
+	w.Write(append(js, '\n'))
+}
+
+// errorResponse writes problem of the status, detail is shown to clients as is.
+func errorResponse(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	writeProblem(w, r, dto.NewProblem(status, detail))
+}
+
+// failedValidationResponse writes problem with invalid fields of the request body, keys of errs
+// are validator paths.
+func failedValidationResponse(w http.ResponseWriter, r *http.Request, errs map[string]string) {
+	problem := dto.NewProblem(http.StatusUnprocessableEntity, "request body is invalid")
+	problem.Errors = dto.FieldErrors(errs)
+	writeProblem(w, r, problem)
 }
 
-func failedValidationResponse(w http.ResponseWriter, errors map[string]string) {
-	errorResponse(w, http.StatusUnprocessableEntity, errors)
+// failedParametersResponse writes problem with invalid query parameters.
+func failedParametersResponse(w http.ResponseWriter, r *http.Request, errs map[string]string) {
+	problem := dto.NewProblem(http.StatusBadRequest, "query parameters are invalid")
+	problem.Errors = dto.ParameterErrors(errs)
+	writeProblem(w, r, problem)
 }
 
-func internalErrorResponse(w http.ResponseWriter, message any) {
-	errorResponse(w, http.StatusInternalServerError, message)
+// internalErrorResponse logs err and writes 500 problem without its details.
+func internalErrorResponse(w http.ResponseWriter, r *http.Request, log logger.Logger, err error) {
+	log.Error(r.Context(), types.ActionRequestFailed, "failed to process request", err, "method", r.Method, "URL", r.URL.Path)
+	errorResponse(w, r, http.StatusInternalServerError, internalErrorDetail)
+}
+
+// serviceErrorResponse writes problem of the service error with status of its kind, see getCode.
+// Internal and transient errors are logged and their details are not shown.
+func serviceErrorResponse(w http.ResponseWriter, r *http.Request, log logger.Logger, err error) {
+	status := getCode(err)
+	if status >= http.StatusInternalServerError {
+		log.Error(r.Context(), types.ActionRequestFailed, "failed to process request", err, "method", r.Method, "URL", r.URL.Path)
+	}
+	errorResponse(w, r, status, errorDetail(err))
+}
+
+// errorDetail returns message of the error which may be shown to clients.
+func errorDetail(err error) string {
+	switch models.KindOf(err) {
+	case models.KindInternal:
+		return internalErrorDetail
+	case models.KindTransient:
+		return "the service is temporarily unavailable, try again later"
+	default:
+		return models.Message(err)
+	}
 }
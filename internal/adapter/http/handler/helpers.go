@@ -7,15 +7,18 @@ import (
 	"io"
 	"maps"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 
 	"wheres-my-pizza/internal/domain/models"
+	"wheres-my-pizza/pkg/validator"
 )
 
 type envelope map[string]any
 
 func writeJSON(w http.ResponseWriter, status int, data envelope, headers http.Header) error {
-	js, err := json.MarshalIndent(data, "", "\t")
+	js, err := json.Marshal(data)
 	if err != nil {
 		return errors.New("failed to encode json")
 	}
@@ -95,10 +98,47 @@ func readJSON(w http.ResponseWriter, r *http.Request, dst any) error {
 	return nil
 }
 
+// readIDParam reads positive integer id from the path value.
+func readIDParam(r *http.Request, name string) (int, error) {
+	id, err := strconv.Atoi(r.PathValue(name))
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid %s parameter", name)
+	}
+
+	return id, nil
+}
+
+// readInt reads integer from the query string, returns defaultValue if the key is missing.
+func readInt(qs url.Values, key string, defaultValue int, v *validator.Validator) int {
+	s := qs.Get(key)
+	if s == "" {
+		return defaultValue
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		v.AddError(key, "must be an integer value")
+		return defaultValue
+	}
+
+	return i
+}
+
+// getCode returns HTTP status of the error by its kind.
 func getCode(err error) int {
-	switch err {
-	case models.ErrOrderNotFound, models.ErrWorkerNotFound:
+	switch models.KindOf(err) {
+	case models.KindNotFound:
 		return http.StatusNotFound
+	case models.KindConflict:
+		return http.StatusConflict
+	case models.KindValidation:
+		return http.StatusUnprocessableEntity
+	case models.KindUnauthorized:
+		return http.StatusUnauthorized
+	case models.KindForbidden:
+		return http.StatusForbidden
+	case models.KindTransient:
+		return http.StatusServiceUnavailable
 	default:
 		return http.StatusInternalServerError
 	}
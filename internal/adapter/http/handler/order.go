@@ -15,6 +15,12 @@ import (
 
 type OrderService interface {
 	CreateOrder(ctx context.Context, req *models.CreateOrder) (*models.OrderCreatedInfo, error)
+	CreateOrders(ctx context.Context, reqs []*models.CreateOrder, partial bool) ([]models.BatchOrderResult, error)
+	CreateCustomer(ctx context.Context, customer models.Customer) (models.Customer, error)
+	AddCustomerAddress(ctx context.Context, address models.CustomerAddress) (models.CustomerAddress, error)
+	DeleteCustomerAddress(ctx context.Context, customerID, addressID int) error
+	AddCustomerFavorite(ctx context.Context, favorite models.FavoriteItem) (models.FavoriteItem, error)
+	DeleteCustomerFavorite(ctx context.Context, customerID, favoriteID int) error
 }
 
 type Order struct {
@@ -36,7 +42,7 @@ func (h *Order) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	var req dto.CreateOrderRequest
 	if err := readJSON(w, r, &req); err != nil {
 		h.log.Error(ctx, types.ActionValidationFailed, "failed to decode request", err)
-		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		errorResponse(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -46,22 +52,35 @@ func (h *Order) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	dto.ValidateCreateOrderRequest(v, createOrder)
 	if !v.Valid() {
 		h.log.Error(ctx, types.ActionValidationFailed, "failed to validate request", v)
-		failedValidationResponse(w, v.Errors)
+		failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	if err := authorizeOrder(ctx, createOrder); err != nil {
+		serviceErrorResponse(w, r, h.log, err)
 		return
 	}
 
 	info, err := h.service.CreateOrder(ctx, createOrder)
 	if err != nil {
 		if errors.Is(err, order.ErrTooManyRequest) {
-			errorResponse(w, http.StatusTooManyRequests, err.Error())
+			errorResponse(w, r, http.StatusTooManyRequests, err.Error())
+			return
+		}
+		if errors.Is(err, models.ErrCustomerNotFound) {
+			failedValidationResponse(w, r, map[string]string{"customer_id": models.Message(err)})
+			return
+		}
+		if errors.Is(err, models.ErrAddressNotFound) {
+			failedValidationResponse(w, r, map[string]string{"address_id": models.Message(err)})
 			return
 		}
-		internalErrorResponse(w, err.Error())
+		serviceErrorResponse(w, r, h.log, err)
 		return
 	}
 
 	response := envelope{
-		"customer_name": req.CustomerName,
+		"customer_name": createOrder.CustomerName,
 		"order_info": dto.CreateOrderResponse{
 			OrderNumber: info.Number,
 			Status:      info.Status,
@@ -70,8 +89,130 @@ func (h *Order) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := writeJSON(w, http.StatusCreated, response, nil); err != nil {
-		h.log.Error(ctx, types.ActionValidationFailed, "failed to write response", err)
-		internalErrorResponse(w, err.Error())
+		internalErrorResponse(w, r, h.log, err)
+	}
+}
+
+// CreateOrderBatch creates orders of a batch. Every order is validated and authorized as in CreateOrder.
+// A failed order fails the whole batch unless it is partial, then the other orders are created
+// and the response is 207 Multi-Status with result of every order.
+func (h *Order) CreateOrderBatch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req dto.CreateOrderBatchRequest
+	if err := readJSON(w, r, &req); err != nil {
+		h.log.Error(ctx, types.ActionValidationFailed, "failed to decode request", err)
+		errorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	results := make([]dto.BatchOrderResult, len(req.Orders))
+	orders := make([]*models.CreateOrder, 0, len(req.Orders))
+	indexes := make([]int, 0, len(req.Orders)) // batch index -> request index
+	for i, orderReq := range req.Orders {
+		results[i].Index = i
+
+		createOrder := dto.FromRequestToInternalCreateOrder(orderReq)
+
+		v := validator.New()
+		dto.ValidateCreateOrderRequest(v, createOrder)
+		if !v.Valid() {
+			results[i].Error = "validation failed"
+			results[i].Errors = dto.FieldErrors(v.Errors, "orders", i)
+			continue
+		}
+		if err := authorizeOrder(ctx, createOrder); err != nil {
+			results[i].Error = errorDetail(err)
+			continue
+		}
+
+		orders = append(orders, createOrder)
+		indexes = append(indexes, i)
+	}
+
+	if len(orders) < len(req.Orders) && !req.Partial {
+		h.log.Error(ctx, types.ActionValidationFailed, "failed to validate batch of orders", models.ErrBatchAborted)
+		h.writeBatch(w, r, http.StatusUnprocessableEntity, abortBatch(results))
+		return
+	}
+
+	var batch []models.BatchOrderResult
+	if len(orders) > 0 || len(req.Orders) == 0 {
+		var err error
+		batch, err = h.service.CreateOrders(ctx, orders, req.Partial)
+		switch {
+		case errors.Is(err, order.ErrTooManyRequest):
+			errorResponse(w, r, http.StatusTooManyRequests, err.Error())
+			return
+		case errors.Is(err, order.ErrEmptyBatch), errors.Is(err, order.ErrBatchTooLarge):
+			failedValidationResponse(w, r, map[string]string{"orders": err.Error()})
+			return
+		case errors.Is(err, models.ErrBatchAborted):
+			status := http.StatusUnprocessableEntity
+			for j, res := range batch {
+				if res.Err != nil {
+					results[indexes[j]].Error = errorDetail(res.Err)
+					if !errors.Is(res.Err, models.ErrCustomerNotFound) && !errors.Is(res.Err, models.ErrAddressNotFound) {
+						status = http.StatusInternalServerError
+					}
+				}
+			}
+			h.writeBatch(w, r, status, abortBatch(results))
+			return
+		case err != nil:
+			serviceErrorResponse(w, r, h.log, err)
+			return
+		}
+	}
+
+	for j, res := range batch {
+		i := indexes[j]
+		if res.Err != nil {
+			results[i].Error = errorDetail(res.Err)
+			continue
+		}
+		results[i].OrderNumber = res.Info.Number
+		results[i].Status = res.Info.Status
+		results[i].TotalAmount = res.Info.TotalAmount
+	}
+
+	status := http.StatusCreated
+	for _, res := range results {
+		if res.Error != "" {
+			status = http.StatusMultiStatus
+			break
+		}
+	}
+
+	h.writeBatch(w, r, status, results)
+}
+
+// abortBatch marks orders which did not fail themselves as rolled back with the batch.
+func abortBatch(results []dto.BatchOrderResult) []dto.BatchOrderResult {
+	for i := range results {
+		if results[i].Error == "" {
+			results[i].Error = models.ErrBatchAborted.Error()
+		}
+	}
+	return results
+}
+
+func (h *Order) writeBatch(w http.ResponseWriter, r *http.Request, status int, results []dto.BatchOrderResult) {
+	var succeeded int
+	for _, res := range results {
+		if res.Error == "" {
+			succeeded++
+		}
+	}
+
+	response := envelope{
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+		"results":   results,
+	}
+
+	if err := writeJSON(w, status, response, nil); err != nil {
+		internalErrorResponse(w, r, h.log, err)
 	}
 }
 
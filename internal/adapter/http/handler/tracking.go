@@ -5,14 +5,24 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"wheres-my-pizza/internal/adapter/http/handler/dto"
 	"wheres-my-pizza/internal/domain/models"
+	"wheres-my-pizza/internal/domain/types"
 	"wheres-my-pizza/pkg/logger"
+	"wheres-my-pizza/pkg/validator"
 )
 
 type TrackingService interface {
 	GetOrderStatus(ctx context.Context, orderNumber string) (models.OrderStatus, error)
 	GetTrackingHistory(ctx context.Context, orderNumber string) ([]models.OrderHistory, error)
 	ListWorkers(ctx context.Context) ([]models.Worker, error)
+	GetKitchenCapacity(ctx context.Context) (models.KitchenCapacity, error)
+	SendWorkerCommand(ctx context.Context, cmd models.WorkerCommand) (models.WorkerCommand, error)
+	GetWorker(ctx context.Context, name string) (models.WorkerDetails, error)
+	GetWorkerStats(ctx context.Context, name string) (models.WorkerStats, error)
+	MarkOrderReady(ctx context.Context, orderNumber string) error
+	GetCustomer(ctx context.Context, id int) (models.CustomerDetails, error)
+	ListCustomerOrders(ctx context.Context, id, limit, offset int) ([]models.CustomerOrder, error)
 }
 
 type Tracking struct {
@@ -33,13 +43,13 @@ func (h *Tracking) GetOrderStatus(w http.ResponseWriter, r *http.Request) {
 
 	orderStatus, err := h.service.GetOrderStatus(ctx, orderNumber)
 	if err != nil {
-		errorResponse(w, getCode(err), err.Error())
+		serviceErrorResponse(w, r, h.log, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(orderStatus); err != nil {
-		internalErrorResponse(w, err.Error())
+		internalErrorResponse(w, r, h.log, err)
 	}
 }
 
@@ -49,13 +59,28 @@ func (h *Tracking) GetTrackingHistory(w http.ResponseWriter, r *http.Request) {
 
 	historyList, err := h.service.GetTrackingHistory(ctx, orderNumber)
 	if err != nil {
-		errorResponse(w, getCode(err), err.Error())
+		serviceErrorResponse(w, r, h.log, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(historyList); err != nil {
-		internalErrorResponse(w, err.Error())
+		internalErrorResponse(w, r, h.log, err)
+	}
+}
+
+// MarkOrderReady is used by cooks in manual cooking mode to report that the order is cooked.
+func (h *Tracking) MarkOrderReady(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	orderNumber := r.PathValue("order_number")
+
+	if err := h.service.MarkOrderReady(ctx, orderNumber); err != nil {
+		serviceErrorResponse(w, r, h.log, err)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusAccepted, envelope{"order_number": orderNumber}, nil); err != nil {
+		internalErrorResponse(w, r, h.log, err)
 	}
 }
 
@@ -64,12 +89,115 @@ func (h *Tracking) ListWorkers(w http.ResponseWriter, r *http.Request) {
 
 	workersList, err := h.service.ListWorkers(ctx)
 	if err != nil {
-		errorResponse(w, getCode(err), err.Error())
+		serviceErrorResponse(w, r, h.log, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(workersList); err != nil {
-		internalErrorResponse(w, err.Error())
+		internalErrorResponse(w, r, h.log, err)
+	}
+}
+
+func (h *Tracking) GetWorker(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	name := r.PathValue("name")
+
+	worker, err := h.service.GetWorker(ctx, name)
+	if err != nil {
+		serviceErrorResponse(w, r, h.log, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(worker); err != nil {
+		internalErrorResponse(w, r, h.log, err)
+	}
+}
+
+func (h *Tracking) GetWorkerStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	name := r.PathValue("name")
+
+	stats, err := h.service.GetWorkerStats(ctx, name)
+	if err != nil {
+		serviceErrorResponse(w, r, h.log, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		internalErrorResponse(w, r, h.log, err)
+	}
+}
+
+func (h *Tracking) GetKitchenCapacity(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	capacity, err := h.service.GetKitchenCapacity(ctx)
+	if err != nil {
+		serviceErrorResponse(w, r, h.log, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(capacity); err != nil {
+		internalErrorResponse(w, r, h.log, err)
+	}
+}
+
+// PauseWorker stops worker from taking new orders, orders in progress are finished.
+func (h *Tracking) PauseWorker(w http.ResponseWriter, r *http.Request) {
+	h.sendWorkerCommand(w, r, models.WorkerCommand{Command: types.WorkerCommandPause})
+}
+
+// ResumeWorker makes paused worker take new orders again.
+func (h *Tracking) ResumeWorker(w http.ResponseWriter, r *http.Request) {
+	h.sendWorkerCommand(w, r, models.WorkerCommand{Command: types.WorkerCommandResume})
+}
+
+// DrainWorker makes worker finish orders in progress and shut down.
+func (h *Tracking) DrainWorker(w http.ResponseWriter, r *http.Request) {
+	h.sendWorkerCommand(w, r, models.WorkerCommand{Command: types.WorkerCommandDrain})
+}
+
+// SetWorkerOrderTypes changes order types the worker handles.
+func (h *Tracking) SetWorkerOrderTypes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req dto.WorkerOrderTypesRequest
+	if err := readJSON(w, r, &req); err != nil {
+		h.log.Error(ctx, types.ActionValidationFailed, "failed to decode request", err)
+		errorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	v := validator.New()
+	dto.ValidateWorkerOrderTypesRequest(v, req)
+	if !v.Valid() {
+		failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	h.sendWorkerCommand(w, r, models.WorkerCommand{
+		Command:    types.WorkerCommandOrderTypes,
+		OrderTypes: req.OrderTypes,
+	})
+}
+
+// sendWorkerCommand queues command for the worker from the path. Worker applies it
+// on its next heartbeat, so the command is only accepted here.
+func (h *Tracking) sendWorkerCommand(w http.ResponseWriter, r *http.Request, cmd models.WorkerCommand) {
+	ctx := r.Context()
+	cmd.WorkerName = r.PathValue("name")
+
+	sent, err := h.service.SendWorkerCommand(ctx, cmd)
+	if err != nil {
+		serviceErrorResponse(w, r, h.log, err)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusAccepted, envelope{"command": sent}, nil); err != nil {
+		internalErrorResponse(w, r, h.log, err)
 	}
 }
@@ -1,22 +1,202 @@
 package server
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"wheres-my-pizza/internal/adapter/http/handler"
+	"wheres-my-pizza/internal/adapter/http/handler/dto"
+	"wheres-my-pizza/internal/domain/models"
 	"wheres-my-pizza/internal/domain/types"
 	"wheres-my-pizza/pkg/logger"
 )
 
 func (a *API) withMiddleware() http.Handler {
 	return a.RequestIDMiddleware(
-		a.RequestLoggingMiddleware(a.mux),
+		a.RequestLoggingMiddleware(
+			a.AuthMiddleware(
+				a.RateLimitMiddleware(a.mux),
+			),
+		),
 	)
 }
 
+// AuthMiddleware authenticates requests by API key from 'X-API-Key' or 'Authorization: Bearer' header
+// and records authenticated requests to the audit log. Routes are scoped by roles with allow.
+// Health check and the OpenAPI document are always open. Client IP which failed authentication too
+// many times is rejected before the key is looked up.
+func (a *API) AuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.cfg.Auth.Enabled || r.URL.Path == "/health" || r.URL.Path == "/openapi.json" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := r.Context()
+		ip := clientIP(r)
+
+		if a.failures != nil {
+			if blocked, retryAfter := a.failures.Blocked(ip); blocked {
+				a.log.Debug(ctx, types.ActionRateLimited, "too many failed authentications", "URL", r.URL.Path, "remote-addr", r.RemoteAddr)
+				w.Header().Set("Retry-After", strconv.Itoa(max(ceilSeconds(retryAfter), 1)))
+				writeError(w, r, http.StatusTooManyRequests, "too many failed authentications")
+				return
+			}
+		}
+
+		key, err := a.auth.Authenticate(ctx, apiKeyFromRequest(r))
+		if err != nil {
+			if !errors.Is(err, models.ErrUnauthorized) {
+				a.log.Error(ctx, types.ActionAuthFailed, "failed to authenticate the request", err, "URL", r.URL.Path, "remote-addr", r.RemoteAddr)
+				writeError(w, r, http.StatusInternalServerError, "failed to authenticate the request")
+				return
+			}
+			if a.failures != nil {
+				a.failures.Fail(ip)
+			}
+			a.log.Warn(ctx, types.ActionAuthFailed, "request is not authenticated", "URL", r.URL.Path, "remote-addr", r.RemoteAddr)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="wheres-my-pizza"`)
+			writeError(w, r, http.StatusUnauthorized, models.Message(err))
+			return
+		}
+
+		rw := &responseWriterWrapper{
+			ResponseWriter: w,
+		}
+
+		next.ServeHTTP(rw, r.WithContext(handler.WithAPIKey(ctx, key)))
+
+		entry := models.AuditEntry{
+			KeyName:    key.Name,
+			Role:       key.Role,
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Status:     rw.status,
+			RequestID:  w.Header().Get("X-Request-ID"),
+			RemoteAddr: r.RemoteAddr,
+		}
+		if key.ID != 0 {
+			entry.KeyID = &key.ID
+		}
+		if entry.Status == 0 {
+			entry.Status = http.StatusOK
+		}
+
+		// audit is written even if the client has gone
+		a.auth.Audit(context.WithoutCancel(ctx), entry)
+		a.log.Debug(ctx, types.ActionRequestAudited, "request audited", "key-name", key.Name, "role", key.Role, "method", r.Method, "URL", r.URL.Path, "status", entry.Status)
+	})
+}
+
+// RateLimitMiddleware limits requests with token buckets per route and client. Client is the API key
+// of the request if it is authenticated, client IP otherwise. Requests are let through if the limiter fails.
+func (a *API) RateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.limiter == nil || r.URL.Path == "/health" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := r.Context()
+		_, route := a.mux.Handler(r)
+		client := clientID(r)
+
+		res, err := a.limiter.Allow(ctx, route, client)
+		if err != nil {
+			a.log.Error(ctx, types.ActionDBQueryFailed, "failed to check rate limit", err, "route", route, "client", client)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+
+		if !res.Allowed {
+			a.log.Debug(ctx, types.ActionRateLimited, "request rate limited", "route", route, "client", client)
+			w.Header().Set("Retry-After", strconv.Itoa(max(ceilSeconds(res.RetryAfter), 1)))
+			writeError(w, r, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientID returns API key of the request or client IP if it is not authenticated.
+func clientID(r *http.Request) string {
+	if key, ok := handler.APIKeyFromContext(r.Context()); ok {
+		if key.ID == 0 {
+			return "key:" + key.Name
+		}
+		return "key:" + strconv.Itoa(key.ID)
+	}
+
+	return "ip:" + clientIP(r)
+}
+
+// clientIP returns IP address of the client.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// allow restricts route to API keys with one of roles. Does nothing if authentication is disabled.
+func (a *API) allow(next http.HandlerFunc, roles ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.cfg.Auth.Enabled {
+			key, ok := handler.APIKeyFromContext(r.Context())
+			if !ok || !slices.Contains(roles, key.Role) {
+				writeError(w, r, http.StatusForbidden, models.ErrForbidden.Error())
+				return
+			}
+		}
+
+		next(w, r)
+	}
+}
+
+// apiKeyFromRequest returns API key from 'X-API-Key' header or bearer token.
+func apiKeyFromRequest(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
+
+// writeError writes problem+json response in the same shape as handlers do.
+func writeError(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	problem := dto.NewProblem(status, detail)
+	problem.Instance, _ = r.Context().Value(models.GetRequestIDKey()).(string)
+
+	w.Header().Set("Content-Type", dto.ProblemContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(problem)
+}
+
 // RequestLoggingMiddleware injects a request ID into the context and logs the request details.
 func (a *API) RequestLoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
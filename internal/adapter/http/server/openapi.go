@@ -0,0 +1,35 @@
+package server
+
+import (
+	_ "embed"
+	"net/http"
+
+	"wheres-my-pizza/pkg/openapi"
+)
+
+// openAPISpec describes routes of every mode, operations are tagged with the mode or 'system'
+// for routes of setupDefaultRoutes. It is written by hand: keep it in sync with routes and
+// request and response types, openapi_test.go checks it.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// openAPIDocument returns document with operations of the registered routes only.
+func (a *API) openAPIDocument() ([]byte, error) {
+	spec, err := openapi.Parse(openAPISpec)
+	if err != nil {
+		return nil, err
+	}
+	return spec.Marshal(a.patterns)
+}
+
+// OpenAPI - returns OpenAPI document of the service.
+func (a *API) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	if a.openAPI == nil {
+		writeError(w, r, http.StatusInternalServerError, "OpenAPI document is not available")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(a.openAPI)
+}
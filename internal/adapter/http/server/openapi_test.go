@@ -0,0 +1,120 @@
+package server
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"wheres-my-pizza/internal/adapter/http/handler/dto"
+	"wheres-my-pizza/internal/config"
+	"wheres-my-pizza/internal/domain/models"
+	"wheres-my-pizza/internal/domain/types"
+	"wheres-my-pizza/pkg/logger"
+	"wheres-my-pizza/pkg/openapi"
+)
+
+// openAPISchemas are types of request and response bodies by their schema in the spec.
+var openAPISchemas = map[string]any{
+	"CreateOrderRequest":      dto.CreateOrderRequest{},
+	"OrderItem":               dto.OrderItem{},
+	"CreateOrderResponse":     dto.CreateOrderResponse{},
+	"CreateOrderBatchRequest": dto.CreateOrderBatchRequest{},
+	"BatchOrderResult":        dto.BatchOrderResult{},
+	"CreateCustomerRequest":   dto.CreateCustomerRequest{},
+	"CustomerAddressRequest":  dto.CustomerAddressRequest{},
+	"FavoriteItemRequest":     dto.FavoriteItemRequest{},
+	"IssueAPIKeyRequest":      dto.IssueAPIKeyRequest{},
+	"SetLogLevelRequest":      dto.SetLogLevelRequest{},
+	"WorkerOrderTypesRequest": dto.WorkerOrderTypesRequest{},
+	"Problem":                 dto.Problem{},
+	"FieldError":              dto.FieldError{},
+
+	"OrderStatus":       models.OrderStatus{},
+	"OrderHistory":      models.OrderHistory{},
+	"Worker":            models.Worker{},
+	"WorkerSession":     models.WorkerSession{},
+	"WorkerDetails":     models.WorkerDetails{},
+	"WorkerCommand":     models.WorkerCommand{},
+	"WorkerStats":       models.WorkerStats{},
+	"OrderTypeStats":    models.OrderTypeStats{},
+	"KitchenCapacity":   models.KitchenCapacity{},
+	"OrderTypeCapacity": models.OrderTypeCapacity{},
+	"QueueState":        models.QueueState{},
+	"Customer":          models.Customer{},
+	"CustomerAddress":   models.CustomerAddress{},
+	"FavoriteItem":      models.FavoriteItem{},
+	"CustomerDetails":   models.CustomerDetails{},
+	"CustomerOrder":     models.CustomerOrder{},
+	"CustomerOrderItem": models.CustomerOrderItem{},
+	"APIKey":            models.APIKey{},
+	"IssuedAPIKey":      models.IssuedAPIKey{},
+	"AuditEntry":        models.AuditEntry{},
+	"LogLevels":         logger.Levels{},
+}
+
+// httpModes are modes which serve HTTP API.
+var httpModes = []types.ServiceMode{types.ModeOrder, types.ModeTracking}
+
+func newTestAPI(t *testing.T, mode types.ServiceMode) *API {
+	t.Helper()
+	return New(config.Config{Mode: mode}, nil, nil, nil, nil, logger.InitLogger("test", logger.Options{Level: "ERROR"}))
+}
+
+func parseSpec(t *testing.T, data []byte) *openapi.Spec {
+	t.Helper()
+	spec, err := openapi.Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spec
+}
+
+func TestOpenAPIRoutes(t *testing.T) {
+	spec := parseSpec(t, openAPISpec)
+
+	for _, mode := range httpModes {
+		t.Run(string(mode), func(t *testing.T) {
+			api := newTestAPI(t, mode)
+			if err := spec.CheckRoutes(api.patterns, "system", string(mode)); err != nil {
+				t.Errorf("spec differs from routes:\n%v", err)
+			}
+		})
+	}
+}
+
+func TestOpenAPISchemas(t *testing.T) {
+	spec := parseSpec(t, openAPISpec)
+
+	for name, v := range openAPISchemas {
+		t.Run(name, func(t *testing.T) {
+			if err := spec.CheckSchema(name, reflect.TypeOf(v)); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	for _, mode := range httpModes {
+		t.Run(string(mode), func(t *testing.T) {
+			api := newTestAPI(t, mode)
+			if api.openAPI == nil {
+				t.Fatal("document is not built")
+			}
+			if !json.Valid(api.openAPI) {
+				t.Fatal("document is not valid JSON")
+			}
+
+			// The served document describes routes of the mode only.
+			doc := parseSpec(t, api.openAPI)
+			if err := doc.CheckRoutes(api.patterns, "system", string(mode)); err != nil {
+				t.Errorf("served document differs from routes:\n%v", err)
+			}
+			for _, other := range httpModes {
+				if other != mode && len(doc.Routes(string(other))) > 0 {
+					t.Errorf("served document has routes of %s: %v", other, doc.Routes(string(other)))
+				}
+			}
+		})
+	}
+}
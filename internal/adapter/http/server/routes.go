@@ -7,6 +7,15 @@ import (
 	"wheres-my-pizza/internal/domain/types"
 )
 
+// Roles allowed to call routes, see setupOrderRoutes and setupTrackingRoutes.
+var (
+	everyone  = types.AllRoles
+	customers = []string{types.RoleCustomer, types.RoleManager}
+	ordering  = []string{types.RoleCustomer, types.RolePartner, types.RoleManager}
+	staff     = []string{types.RoleKitchen, types.RoleManager}
+	managers  = []string{types.RoleManager}
+)
+
 // setupRoutes - setups http routes
 func (a *API) setupRoutes() {
 	a.setupDefaultRoutes()
@@ -19,22 +28,60 @@ func (a *API) setupRoutes() {
 	}
 }
 
+// handle registers handler of the route, patterns of registered routes are checked against the
+// OpenAPI spec.
+func (a *API) handle(pattern string, handler http.HandlerFunc) {
+	a.mux.HandleFunc(pattern, handler)
+	a.patterns = append(a.patterns, pattern)
+}
+
 // setupDefaultRoutes - setups default http routes
 func (a *API) setupDefaultRoutes() {
 	// System Health
-	a.mux.HandleFunc("/health", a.HealthCheck)
+	a.handle("/health", a.HealthCheck)
+	a.handle("GET /openapi.json", a.OpenAPI)
+
+	// API keys
+	a.handle("POST /api-keys", a.allow(a.routes.auth.IssueAPIKey, managers...))
+	a.handle("GET /api-keys", a.allow(a.routes.auth.ListAPIKeys, managers...))
+	a.handle("DELETE /api-keys/{id}", a.allow(a.routes.auth.RevokeAPIKey, managers...))
+	a.handle("GET /audit-log", a.allow(a.routes.auth.ListAuditLog, managers...))
+
+	// Process administration
+	a.handle("GET /admin/log-level", a.allow(a.routes.admin.GetLogLevel, managers...))
+	a.handle("PUT /admin/log-level", a.allow(a.routes.admin.SetLogLevel, managers...))
 }
 
 // setupOrderRoutes setups routes for order service
 func (a *API) setupOrderRoutes() {
-	a.mux.HandleFunc("POST /orders", a.routes.order.CreateOrder)
+	a.handle("POST /orders", a.allow(a.routes.order.CreateOrder, ordering...))
+	a.handle("POST /orders/batch", a.allow(a.routes.order.CreateOrderBatch, ordering...))
+
+	// Customer accounts
+	a.handle("POST /customers", a.allow(a.routes.order.CreateCustomer, customers...))
+	a.handle("POST /customers/{id}/addresses", a.allow(a.routes.order.AddCustomerAddress, customers...))
+	a.handle("DELETE /customers/{id}/addresses/{address_id}", a.allow(a.routes.order.DeleteCustomerAddress, customers...))
+	a.handle("POST /customers/{id}/favorites", a.allow(a.routes.order.AddCustomerFavorite, customers...))
+	a.handle("DELETE /customers/{id}/favorites/{favorite_id}", a.allow(a.routes.order.DeleteCustomerFavorite, customers...))
 }
 
 // setupTrackingRoutes setups routes for tracking service
 func (a *API) setupTrackingRoutes() {
-	a.mux.HandleFunc("GET /orders/{order_number}/status", a.routes.tracking.GetOrderStatus)
-	a.mux.HandleFunc("GET /orders/{order_number}/history", a.routes.tracking.GetTrackingHistory)
-	a.mux.HandleFunc("GET /workers/status", a.routes.tracking.ListWorkers)
+	a.handle("GET /orders/{order_number}/status", a.allow(a.routes.tracking.GetOrderStatus, everyone...))
+	a.handle("GET /orders/{order_number}/history", a.allow(a.routes.tracking.GetTrackingHistory, everyone...))
+	a.handle("POST /orders/{order_number}/ready", a.allow(a.routes.tracking.MarkOrderReady, staff...))
+	a.handle("GET /workers/status", a.allow(a.routes.tracking.ListWorkers, staff...))
+	a.handle("GET /workers/{name}", a.allow(a.routes.tracking.GetWorker, staff...))
+	a.handle("GET /workers/{name}/stats", a.allow(a.routes.tracking.GetWorkerStats, staff...))
+	a.handle("GET /kitchen/capacity", a.allow(a.routes.tracking.GetKitchenCapacity, staff...))
+	a.handle("GET /customers/{id}", a.allow(a.routes.tracking.GetCustomer, customers...))
+	a.handle("GET /customers/{id}/orders", a.allow(a.routes.tracking.ListCustomerOrders, customers...))
+
+	// Kitchen worker control
+	a.handle("POST /workers/{name}/pause", a.allow(a.routes.tracking.PauseWorker, managers...))
+	a.handle("POST /workers/{name}/resume", a.allow(a.routes.tracking.ResumeWorker, managers...))
+	a.handle("POST /workers/{name}/drain", a.allow(a.routes.tracking.DrainWorker, managers...))
+	a.handle("POST /workers/{name}/order-types", a.allow(a.routes.tracking.SetWorkerOrderTypes, managers...))
 }
 
 // HealthCheck - returns system information.
@@ -11,52 +11,97 @@ import (
 	"wheres-my-pizza/internal/config"
 	"wheres-my-pizza/internal/domain/types"
 	"wheres-my-pizza/pkg/logger"
+	"wheres-my-pizza/pkg/ratelimit"
 )
 
 const serverIPAddress = "%s:%d"
 
 type API struct {
-	mode   types.ServiceMode
-	mux    *http.ServeMux
-	server *http.Server
-	routes *handlers // routes/handlers
+	mode     types.ServiceMode
+	mux      *http.ServeMux
+	server   *http.Server
+	routes   *handlers // routes/handlers
+	auth     handler.AuthService
+	failures *ratelimit.Failures // failed authentications per client IP, nil if auth is disabled
+	limiter  RateLimiter
+
+	patterns []string // of registered routes
+	openAPI  []byte   // OpenAPI document of the routes
 
 	addr string
 	cfg  config.HTTPServer
 	log  logger.Logger
 }
 
+// RateLimiter limits requests of clients to routes, see ratelimit.Limiter.
+type RateLimiter interface {
+	Allow(ctx context.Context, route, client string) (ratelimit.Result, error)
+}
+
 type handlers struct {
 	order    *handler.Order
 	tracking *handler.Tracking
+	auth     *handler.Auth
+	admin    *handler.Admin
 }
 
-func New(cfg config.Config, orderService handler.OrderService, trackingService handler.TrackingService, logger logger.Logger) *API {
+func New(
+	cfg config.Config,
+	orderService handler.OrderService,
+	trackingService handler.TrackingService,
+	authService handler.AuthService,
+	limiter RateLimiter, // nil - requests are not limited
+	logger logger.Logger,
+) *API {
 	addr := fmt.Sprintf(serverIPAddress, "0.0.0.0", cfg.HTTPServer.Port)
 
+	// Levels are changed for the whole process, not only for the HTTP component.
+	admin := handler.NewAdmin(logger)
+	logger = logger.Component("http")
+
 	handlers := &handlers{
 		order:    handler.NewOrder(orderService, logger),
 		tracking: handler.NewTracking(trackingService, logger),
+		auth:     handler.NewAuth(authService, logger),
+		admin:    admin,
 	}
 
 	api := &API{
 		mode: cfg.Mode,
 
-		mux:    http.NewServeMux(),
-		routes: handlers,
+		mux:     http.NewServeMux(),
+		routes:  handlers,
+		auth:    authService,
+		limiter: limiter,
 
 		addr: addr,
 		cfg:  cfg.HTTPServer,
 		log:  logger,
 	}
 
-	api.server = &http.Server{
-		Addr:    api.addr,
-		Handler: api.mux,
+	if cfg.HTTPServer.Auth.Enabled {
+		limit, err := cfg.HTTPServer.Auth.Failures()
+		if err != nil {
+			api.log.Error(context.Background(), types.ActionValidationFailed, "invalid failed authentications limit, they are not limited", err)
+		} else {
+			api.failures = ratelimit.NewFailures(limit)
+		}
 	}
 
 	api.setupRoutes()
 
+	// The document is only served, a broken one doesn't stop the service.
+	openAPI, err := api.openAPIDocument()
+	if err != nil {
+		api.log.Error(context.Background(), types.ActionOpenAPIFailed, "failed to build OpenAPI document", err)
+	}
+	api.openAPI = openAPI
+
+	api.server = &http.Server{
+		Addr:    api.addr,
+		Handler: api.withMiddleware(),
+	}
+
 	return api
 }
 
@@ -74,11 +119,30 @@ func (a *API) Stop(ctx context.Context) error {
 }
 
 func (a *API) Run(ctx context.Context, errCh chan<- error) {
+	if a.failures != nil {
+		go a.cleanupFailures(ctx)
+	}
+
 	go func() {
 		a.log.Info(ctx, "http_server_run", "started http server", "address", a.addr)
-		if err := http.ListenAndServe(a.addr, a.withMiddleware()); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		if err := a.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			errCh <- fmt.Errorf("failed to start HTTP server: %w", err)
 			return
 		}
 	}()
 }
+
+// cleanupFailures removes buckets of clients which did not fail authentication for long enough.
+func (a *API) cleanupFailures(ctx context.Context) {
+	ticker := time.NewTicker(max(a.failures.MaxIdle(), time.Minute))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.failures.Cleanup()
+		}
+	}
+}
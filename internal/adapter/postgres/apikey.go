@@ -0,0 +1,252 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"wheres-my-pizza/internal/domain/models"
+)
+
+type apiKeyRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAPIKeyRepo(pool *pgxpool.Pool) *apiKeyRepository {
+	return &apiKeyRepository{
+		pool: pool,
+	}
+}
+
+// Create stores API key by its hash. Returns models.ErrCustomerNotFound if the customer of the key
+// doesn't exist.
+func (repo *apiKeyRepository) Create(ctx context.Context, key models.APIKey, keyHash string) (models.APIKey, error) {
+	const op = "apiKeyRepository.Create"
+
+	query := `
+	INSERT INTO api_keys (
+		name,
+		role,
+		prefix,
+		key_hash,
+		created_by,
+		customer_id
+	)
+	SELECT $1, $2, $3, $4, $5, $6
+	WHERE $6::integer IS NULL OR EXISTS (SELECT 1 FROM customers WHERE id = $6)
+	RETURNING
+		id, created_at;`
+
+	if err := repo.pool.QueryRow(ctx, query, key.Name, key.Role, key.Prefix, keyHash, key.CreatedBy, key.CustomerID).
+		Scan(&key.ID, &key.CreatedAt); err != nil {
+		if err == pgx.ErrNoRows {
+			return models.APIKey{}, wrap(op, models.ErrCustomerNotFound)
+		}
+		return models.APIKey{}, wrap(op, err)
+	}
+
+	return key, nil
+}
+
+// Authenticate returns not revoked API key by its hash.
+func (repo *apiKeyRepository) Authenticate(ctx context.Context, keyHash string) (models.APIKey, error) {
+	const op = "apiKeyRepository.Authenticate"
+
+	query := `
+	SELECT
+		id, name, role, customer_id, prefix, created_by, created_at, last_used_at, revoked_at
+	FROM
+		api_keys
+	WHERE
+		key_hash = $1
+		AND revoked_at IS NULL;`
+
+	var key models.APIKey
+	if err := repo.pool.QueryRow(ctx, query, keyHash).Scan(
+		&key.ID,
+		&key.Name,
+		&key.Role,
+		&key.CustomerID,
+		&key.Prefix,
+		&key.CreatedBy,
+		&key.CreatedAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+	); err != nil {
+		if err == pgx.ErrNoRows {
+			return models.APIKey{}, wrap(op, models.ErrAPIKeyNotFound)
+		}
+		return models.APIKey{}, wrap(op, err)
+	}
+
+	return key, nil
+}
+
+// MarkUsed sets last_used_at of the API key to now unless it was set within precision, so a busy
+// key is not updated on every request.
+func (repo *apiKeyRepository) MarkUsed(ctx context.Context, id int, precision time.Duration) error {
+	const op = "apiKeyRepository.MarkUsed"
+
+	query := `
+	UPDATE api_keys
+	SET
+		last_used_at = now()
+	WHERE
+		id = $1
+		AND (last_used_at IS NULL OR last_used_at < now() - make_interval(secs => $2));`
+
+	if _, err := repo.pool.Exec(ctx, query, id, precision.Seconds()); err != nil {
+		return wrap(op, err)
+	}
+
+	return nil
+}
+
+// Revoke revokes API key, revoking it again is not an error.
+func (repo *apiKeyRepository) Revoke(ctx context.Context, id int) (models.APIKey, error) {
+	const op = "apiKeyRepository.Revoke"
+
+	query := `
+	UPDATE api_keys
+	SET
+		revoked_at = COALESCE(revoked_at, now())
+	WHERE
+		id = $1
+	RETURNING
+		id, name, role, customer_id, prefix, created_by, created_at, last_used_at, revoked_at;`
+
+	var key models.APIKey
+	if err := repo.pool.QueryRow(ctx, query, id).Scan(
+		&key.ID,
+		&key.Name,
+		&key.Role,
+		&key.CustomerID,
+		&key.Prefix,
+		&key.CreatedBy,
+		&key.CreatedAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+	); err != nil {
+		if err == pgx.ErrNoRows {
+			return models.APIKey{}, wrap(op, models.ErrAPIKeyNotFound)
+		}
+		return models.APIKey{}, wrap(op, err)
+	}
+
+	return key, nil
+}
+
+func (repo *apiKeyRepository) List(ctx context.Context) ([]models.APIKey, error) {
+	const op = "apiKeyRepository.List"
+
+	query := `
+	SELECT
+		id, name, role, customer_id, prefix, created_by, created_at, last_used_at, revoked_at
+	FROM
+		api_keys
+	ORDER BY
+		id;`
+
+	rows, err := repo.pool.Query(ctx, query)
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+
+	keys, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.APIKey, error) {
+		var key models.APIKey
+		err := row.Scan(
+			&key.ID,
+			&key.Name,
+			&key.Role,
+			&key.CustomerID,
+			&key.Prefix,
+			&key.CreatedBy,
+			&key.CreatedAt,
+			&key.LastUsedAt,
+			&key.RevokedAt,
+		)
+		return key, err
+	})
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+
+	return keys, nil
+}
+
+func (repo *apiKeyRepository) AddAudit(ctx context.Context, entry models.AuditEntry) error {
+	const op = "apiKeyRepository.AddAudit"
+
+	query := `
+	INSERT INTO api_audit_log (
+		key_id,
+		key_name,
+		role,
+		method,
+		path,
+		status,
+		request_id,
+		remote_addr
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8);`
+
+	if _, err := repo.pool.Exec(ctx, query,
+		entry.KeyID,
+		entry.KeyName,
+		entry.Role,
+		entry.Method,
+		entry.Path,
+		entry.Status,
+		entry.RequestID,
+		entry.RemoteAddr,
+	); err != nil {
+		return wrap(op, err)
+	}
+
+	return nil
+}
+
+// ListAudit returns latest audit entries, only of the key if keyID is not nil.
+func (repo *apiKeyRepository) ListAudit(ctx context.Context, keyID *int, limit int) ([]models.AuditEntry, error) {
+	const op = "apiKeyRepository.ListAudit"
+
+	query := `
+	SELECT
+		id, created_at, key_id, key_name, role, method, path, status,
+		COALESCE(request_id, ''), COALESCE(remote_addr, '')
+	FROM
+		api_audit_log
+	WHERE
+		$1::integer IS NULL OR key_id = $1
+	ORDER BY
+		id DESC
+	LIMIT $2;`
+
+	rows, err := repo.pool.Query(ctx, query, keyID, limit)
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+
+	entries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.AuditEntry, error) {
+		var entry models.AuditEntry
+		err := row.Scan(
+			&entry.ID,
+			&entry.CreatedAt,
+			&entry.KeyID,
+			&entry.KeyName,
+			&entry.Role,
+			&entry.Method,
+			&entry.Path,
+			&entry.Status,
+			&entry.RequestID,
+			&entry.RemoteAddr,
+		)
+		return entry, err
+	})
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+
+	return entries, nil
+}
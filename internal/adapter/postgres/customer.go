@@ -0,0 +1,334 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"wheres-my-pizza/internal/domain/models"
+)
+
+// uniqueViolation is the postgres error code of a unique constraint violation.
+const uniqueViolation = "23505"
+
+type customerRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewCustomerRepo(pool *pgxpool.Pool) *customerRepository {
+	return &customerRepository{
+		pool: pool,
+	}
+}
+
+// Create creates a customer. Returns models.ErrCustomerExists if its phone or email is taken.
+func (repo *customerRepository) Create(ctx context.Context, customer models.Customer) (models.Customer, error) {
+	const op = "customerRepository.Create"
+
+	query := `
+	INSERT INTO customers (
+		name,
+		phone,
+		email
+	) VALUES ($1, $2, $3)
+	RETURNING
+		id, created_at;`
+
+	if err := repo.pool.QueryRow(ctx, query, customer.Name, customer.Phone, customer.Email).
+		Scan(&customer.ID, &customer.CreatedAt); err != nil {
+		if isUniqueViolation(err) {
+			return models.Customer{}, wrap(op, models.ErrCustomerExists)
+		}
+		return models.Customer{}, wrap(op, err)
+	}
+
+	return customer, nil
+}
+
+func (repo *customerRepository) Get(ctx context.Context, id int) (models.Customer, error) {
+	const op = "customerRepository.Get"
+
+	query := `
+	SELECT
+		id,
+		created_at,
+		name,
+		phone,
+		email
+	FROM
+		customers
+	WHERE
+		id = $1;`
+
+	var customer models.Customer
+	if err := repo.pool.QueryRow(ctx, query, id).Scan(
+		&customer.ID,
+		&customer.CreatedAt,
+		&customer.Name,
+		&customer.Phone,
+		&customer.Email,
+	); err != nil {
+		if err == pgx.ErrNoRows {
+			return models.Customer{}, wrap(op, models.ErrCustomerNotFound)
+		}
+		return models.Customer{}, wrap(op, err)
+	}
+
+	return customer, nil
+}
+
+// AddAddress saves a delivery address of the customer.
+func (repo *customerRepository) AddAddress(ctx context.Context, address models.CustomerAddress) (models.CustomerAddress, error) {
+	const op = "customerRepository.AddAddress"
+
+	query := `
+	INSERT INTO customer_addresses (
+		customer_id,
+		label,
+		address
+	)
+	SELECT $1, $2, $3
+	WHERE EXISTS (SELECT 1 FROM customers WHERE id = $1)
+	RETURNING
+		id, created_at;`
+
+	if err := repo.pool.QueryRow(ctx, query, address.CustomerID, address.Label, address.Address).
+		Scan(&address.ID, &address.CreatedAt); err != nil {
+		if err == pgx.ErrNoRows {
+			return models.CustomerAddress{}, wrap(op, models.ErrCustomerNotFound)
+		}
+		if isUniqueViolation(err) {
+			return models.CustomerAddress{}, wrap(op, models.ErrAddressExists)
+		}
+		return models.CustomerAddress{}, wrap(op, err)
+	}
+
+	return address, nil
+}
+
+// GetAddress returns saved address of the customer.
+func (repo *customerRepository) GetAddress(ctx context.Context, customerID, addressID int) (models.CustomerAddress, error) {
+	const op = "customerRepository.GetAddress"
+
+	query := `
+	SELECT
+		id,
+		customer_id,
+		label,
+		address,
+		created_at
+	FROM
+		customer_addresses
+	WHERE
+		id = $1
+		AND customer_id = $2;`
+
+	var address models.CustomerAddress
+	if err := repo.pool.QueryRow(ctx, query, addressID, customerID).Scan(
+		&address.ID,
+		&address.CustomerID,
+		&address.Label,
+		&address.Address,
+		&address.CreatedAt,
+	); err != nil {
+		if err == pgx.ErrNoRows {
+			return models.CustomerAddress{}, wrap(op, models.ErrAddressNotFound)
+		}
+		return models.CustomerAddress{}, wrap(op, err)
+	}
+
+	return address, nil
+}
+
+func (repo *customerRepository) DeleteAddress(ctx context.Context, customerID, addressID int) error {
+	const op = "customerRepository.DeleteAddress"
+
+	tag, err := repo.pool.Exec(ctx, `DELETE FROM customer_addresses WHERE id = $1 AND customer_id = $2;`, addressID, customerID)
+	if err != nil {
+		return wrap(op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return wrap(op, models.ErrAddressNotFound)
+	}
+
+	return nil
+}
+
+func (repo *customerRepository) ListAddresses(ctx context.Context, customerID int) ([]models.CustomerAddress, error) {
+	const op = "customerRepository.ListAddresses"
+
+	query := `
+	SELECT
+		id,
+		customer_id,
+		label,
+		address,
+		created_at
+	FROM
+		customer_addresses
+	WHERE
+		customer_id = $1
+	ORDER BY
+		id;`
+
+	rows, err := repo.pool.Query(ctx, query, customerID)
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+
+	addresses, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.CustomerAddress, error) {
+		var address models.CustomerAddress
+		err := row.Scan(&address.ID, &address.CustomerID, &address.Label, &address.Address, &address.CreatedAt)
+		return address, err
+	})
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+
+	return addresses, nil
+}
+
+// AddFavorite adds a menu item to favorites of the customer.
+func (repo *customerRepository) AddFavorite(ctx context.Context, favorite models.FavoriteItem) (models.FavoriteItem, error) {
+	const op = "customerRepository.AddFavorite"
+
+	query := `
+	INSERT INTO customer_favorites (
+		customer_id,
+		name,
+		price
+	)
+	SELECT $1, $2, $3
+	WHERE EXISTS (SELECT 1 FROM customers WHERE id = $1)
+	RETURNING
+		id, created_at;`
+
+	if err := repo.pool.QueryRow(ctx, query, favorite.CustomerID, favorite.Name, favorite.Price).
+		Scan(&favorite.ID, &favorite.CreatedAt); err != nil {
+		if err == pgx.ErrNoRows {
+			return models.FavoriteItem{}, wrap(op, models.ErrCustomerNotFound)
+		}
+		if isUniqueViolation(err) {
+			return models.FavoriteItem{}, wrap(op, models.ErrFavoriteExists)
+		}
+		return models.FavoriteItem{}, wrap(op, err)
+	}
+
+	return favorite, nil
+}
+
+func (repo *customerRepository) DeleteFavorite(ctx context.Context, customerID, favoriteID int) error {
+	const op = "customerRepository.DeleteFavorite"
+
+	tag, err := repo.pool.Exec(ctx, `DELETE FROM customer_favorites WHERE id = $1 AND customer_id = $2;`, favoriteID, customerID)
+	if err != nil {
+		return wrap(op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return wrap(op, models.ErrFavoriteNotFound)
+	}
+
+	return nil
+}
+
+func (repo *customerRepository) ListFavorites(ctx context.Context, customerID int) ([]models.FavoriteItem, error) {
+	const op = "customerRepository.ListFavorites"
+
+	query := `
+	SELECT
+		id,
+		customer_id,
+		name,
+		price,
+		created_at
+	FROM
+		customer_favorites
+	WHERE
+		customer_id = $1
+	ORDER BY
+		id;`
+
+	rows, err := repo.pool.Query(ctx, query, customerID)
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+
+	favorites, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.FavoriteItem, error) {
+		var favorite models.FavoriteItem
+		err := row.Scan(&favorite.ID, &favorite.CustomerID, &favorite.Name, &favorite.Price, &favorite.CreatedAt)
+		return favorite, err
+	})
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+
+	return favorites, nil
+}
+
+// ListOrders returns orders of the customer with their items, newest first.
+func (repo *customerRepository) ListOrders(ctx context.Context, customerID, limit, offset int) ([]models.CustomerOrder, error) {
+	const op = "customerRepository.ListOrders"
+
+	query := `
+	SELECT
+		o.number,
+		o.type,
+		o.status,
+		o.table_number,
+		o.delivery_address,
+		o.total_amount,
+		o.created_at,
+		o.completed_at,
+		COALESCE(
+			(
+				SELECT
+					json_agg(json_build_object('name', i.name, 'quantity', i.quantity, 'price', i.price) ORDER BY i.id)
+				FROM
+					order_items i
+				WHERE
+					i.order_id = o.id
+			),
+			'[]'
+		)
+	FROM
+		orders o
+	WHERE
+		o.customer_id = $1
+	ORDER BY
+		o.created_at DESC, o.id DESC
+	LIMIT $2 OFFSET $3;`
+
+	rows, err := repo.pool.Query(ctx, query, customerID, limit, offset)
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+
+	orders, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.CustomerOrder, error) {
+		var order models.CustomerOrder
+		err := row.Scan(
+			&order.Number,
+			&order.Type,
+			&order.Status,
+			&order.TableNumber,
+			&order.DeliveryAddress,
+			&order.TotalAmount,
+			&order.CreatedAt,
+			&order.CompletedAt,
+			&order.Items,
+		)
+		return order, err
+	})
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+
+	return orders, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
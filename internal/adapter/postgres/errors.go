@@ -0,0 +1,53 @@
+package postgres
+
+import (
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"wheres-my-pizza/internal/domain/models"
+)
+
+// wrap wraps err of the repository operation. Domain errors keep their kind, lost connections,
+// timeouts, serialization failures and deadlocks are transient, everything else is internal.
+func wrap(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	kind := models.KindOf(err)
+	if kind == models.KindInternal && isTransient(err) {
+		kind = models.KindTransient
+	}
+	return models.NewError(op, kind, err)
+}
+
+// isTransient reports whether retrying the query may succeed.
+func isTransient(err error) bool {
+	if pgconn.Timeout(err) || pgconn.SafeToRetry(err) {
+		return true
+	}
+
+	var connErr *pgconn.ConnectError
+	if errors.As(err, &connErr) {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "40001", // serialization_failure
+			"40P01", // deadlock_detected
+			"53300", // too_many_connections
+			"57P01": // admin_shutdown
+			return true
+		}
+		// class 08 - connection exception
+		return strings.HasPrefix(pgErr.Code, "08")
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -22,33 +23,98 @@ func NewOrderRepo(pool *pgxpool.Pool) *orderRepository {
 }
 
 func (r *orderRepository) Create(ctx context.Context, req *models.CreateOrder, changedBy, notes string) (*models.Order, error) {
-	var order models.Order
+	const op = "orderRepository.Create"
 
 	// Start a transaction
 	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, wrap(op, fmt.Errorf("failed to begin transaction: %w", err))
 	}
 	defer tx.Rollback(ctx)
 
+	order, err := insertOrder(ctx, tx, req, changedBy, notes)
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+
+	// Commit the transaction
+	if err := tx.Commit(ctx); err != nil {
+		return nil, wrap(op, fmt.Errorf("failed to commit transaction: %w", err))
+	}
+
+	return order, nil
+}
+
+// CreateBatch creates orders in one transaction. Returns error of every order by its index.
+// In partial mode every order is inserted under its own savepoint and failed orders are skipped,
+// otherwise the first failed order rolls back the whole batch and models.ErrBatchAborted is returned
+// with the error of the order.
+func (r *orderRepository) CreateBatch(ctx context.Context, reqs []*models.CreateOrder, changedBy, notes string, partial bool) ([]*models.Order, []error, error) {
+	const op = "orderRepository.CreateBatch"
+
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, nil, wrap(op, fmt.Errorf("failed to begin transaction: %w", err))
+	}
+	defer tx.Rollback(ctx)
+
+	orders := make([]*models.Order, len(reqs))
+	errs := make([]error, len(reqs))
+	for i, req := range reqs {
+		if !partial {
+			if orders[i], errs[i] = insertOrder(ctx, tx, req, changedBy, notes); errs[i] != nil {
+				return nil, errs, wrap(op, models.ErrBatchAborted)
+			}
+			continue
+		}
+
+		// Nested transaction is a savepoint, failed order does not abort the others
+		sp, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, nil, wrap(op, fmt.Errorf("failed to create savepoint: %w", err))
+		}
+		if orders[i], errs[i] = insertOrder(ctx, sp, req, changedBy, notes); errs[i] != nil {
+			if err := sp.Rollback(ctx); err != nil {
+				return nil, nil, wrap(op, fmt.Errorf("failed to rollback to savepoint: %w", err))
+			}
+			continue
+		}
+		if err := sp.Commit(ctx); err != nil {
+			return nil, nil, wrap(op, fmt.Errorf("failed to release savepoint: %w", err))
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, wrap(op, fmt.Errorf("failed to commit transaction: %w", err))
+	}
+
+	return orders, errs, nil
+}
+
+// insertOrder inserts order with its items and initial status.
+func insertOrder(ctx context.Context, tx pgx.Tx, req *models.CreateOrder, changedBy, notes string) (*models.Order, error) {
+	var order models.Order
+
 	// Insert the order
-	err = tx.QueryRow(ctx,
+	err := tx.QueryRow(ctx,
 		`INSERT INTO orders (
 			number, 
 			customer_name, 
+			customer_id, 
 			type, 
 			table_number, 
 			delivery_address, 
 			total_amount, 
 			priority, 
 			status
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING 
-			id, created_at, updated_at, number, customer_name, 
+			id, created_at, updated_at, number, customer_name, customer_id, 
 			type, table_number, delivery_address, total_amount, 
 			priority, status, processed_by, completed_at`,
 		req.Number,
 		req.CustomerName,
+		req.CustomerID,
 		req.Type,
 		req.TableNumber,
 		req.DeliveryAddress,
@@ -61,6 +127,7 @@ func (r *orderRepository) Create(ctx context.Context, req *models.CreateOrder, c
 		&order.UpdatedAt,
 		&order.Number,
 		&order.CustomerName,
+		&order.CustomerID,
 		&order.Type,
 		&order.TableNumber,
 		&order.DeliveryAddress,
@@ -110,15 +177,13 @@ func (r *orderRepository) Create(ctx context.Context, req *models.CreateOrder, c
 		return nil, fmt.Errorf("failed to log initial order status: %w", err)
 	}
 
-	// Commit the transaction
-	if err := tx.Commit(ctx); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
 	return &order, nil
 }
 
-func (r *orderRepository) GetAndIncrementSequence(ctx context.Context, date string) (int, error) {
+// GetAndIncrementSequence allocates n order numbers of the date and returns the last of them.
+func (r *orderRepository) GetAndIncrementSequence(ctx context.Context, date string, n int) (int, error) {
+	const op = "orderRepository.GetAndIncrementSequence"
+
 	var seq int
 
 	// Configure transaction with serializable isolation level
@@ -131,38 +196,43 @@ func (r *orderRepository) GetAndIncrementSequence(ctx context.Context, date stri
 	// Start a transaction
 	tx, err := r.pool.BeginTx(ctx, txOptions)
 	if err != nil {
-		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+		return 0, wrap(op, fmt.Errorf("failed to begin transaction: %w", err))
 	}
 	defer tx.Rollback(ctx)
 
 	// Use the new pgx syntax for querying
 	err = tx.QueryRow(ctx,
 		`INSERT INTO order_sequences (date, last_value) 
-		VALUES ($1, 1)
+		VALUES ($1, $2)
 		ON CONFLICT (date) DO UPDATE 
-		SET last_value = order_sequences.last_value + 1,
+		SET last_value = order_sequences.last_value + $2,
 		    updated_at = NOW()
 		RETURNING last_value`,
 		date,
+		n,
 	).Scan(&seq)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get/increment sequence: %w", err)
+		return 0, wrap(op, fmt.Errorf("failed to get/increment sequence: %w", err))
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+		return 0, wrap(op, fmt.Errorf("failed to commit transaction: %w", err))
 	}
 
 	return seq, nil
 }
 
-// SetStatus updates order status and logs it in one transaction.
+// SetStatus updates order status and logs it in one transaction. Only a 'received' order can
+// start cooking, for others ErrOrderNotReceived is returned: a redelivered message of an order
+// which is cooked or ready already must not cook it again. Only the worker cooking the order can
+// make it ready or return it to the queue, otherwise ErrOrderNotOwned is returned: the order was
+// requeued by the reaper and may be cooked by another worker.
 func (r *orderRepository) SetStatus(ctx context.Context, orderNumber, workerName, status, notes string) (string, error) {
 	const op = "orderRepository.SetStatus"
 
 	tx, err := r.pool.Begin(ctx)
 	if err != nil {
-		return "", fmt.Errorf("%s: %v", op, err)
+		return "", wrap(op, err)
 	}
 
 	query := `
@@ -175,11 +245,18 @@ func (r *orderRepository) SetStatus(ctx context.Context, orderNumber, workerName
 	if status == types.StatusOrderReady {
 		query += ", completed_at = now()"
 	}
+	if status == types.StatusOrderCooking {
+		query += ", marked_ready_at = NULL" // mark of a previous cooking attempt
+	}
 
 	query += `
 	FROM orders AS old
 	WHERE o.id = old.id
 	  AND o.number = $3
+	  AND CASE $1
+	    WHEN 'cooking' THEN o.status = 'received'
+	    ELSE o.status = 'cooking' AND o.processed_by = $2
+	  END
 	RETURNING old.status AS old_status, o.id;`
 
 	var (
@@ -189,9 +266,9 @@ func (r *orderRepository) SetStatus(ctx context.Context, orderNumber, workerName
 	if err := tx.QueryRow(ctx, query, status, workerName, orderNumber).Scan(&oldStatus, &orderID); err != nil {
 		tx.Rollback(ctx)
 		if err == pgx.ErrNoRows {
-			return "", models.ErrOrderNotFound
+			return "", wrap(op, r.notUpdated(ctx, orderNumber, status))
 		}
-		return "", fmt.Errorf("%s: %v", op, err)
+		return "", wrap(op, err)
 	}
 
 	query = `
@@ -203,10 +280,220 @@ func (r *orderRepository) SetStatus(ctx context.Context, orderNumber, workerName
 	if _, err := tx.Exec(ctx, query, orderID, status, workerName, notes); err != nil {
 		tx.Rollback(ctx)
 		if err == pgx.ErrNoRows {
-			return "", models.ErrOrderNotFound
+			return "", wrap(op, models.ErrOrderNotFound)
 		}
-		return "", fmt.Errorf("%s: %v", op, err)
+		return "", wrap(op, err)
 	}
 
 	return oldStatus, tx.Commit(ctx)
 }
+
+// notUpdated returns why the order was not updated to status: it doesn't exist, isn't 'received'
+// to start cooking or isn't cooked by the worker.
+func (r *orderRepository) notUpdated(ctx context.Context, orderNumber, status string) error {
+	var exists bool
+	if err := r.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM orders WHERE number = $1);`, orderNumber).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return models.ErrOrderNotFound
+	}
+	if status == types.StatusOrderCooking {
+		return models.ErrOrderNotReceived
+	}
+	return models.ErrOrderNotOwned
+}
+
+// ListStuckCooking returns orders which are in 'cooking' longer than stuckAfter
+// and whose worker is offline.
+func (r *orderRepository) ListStuckCooking(ctx context.Context, stuckAfter time.Duration) ([]models.CreateOrder, error) {
+	const op = "orderRepository.ListStuckCooking"
+
+	query := `
+	SELECT 
+		o.id,
+		o.number,
+		o.customer_name,
+		o.type,
+		o.table_number,
+		o.delivery_address,
+		o.total_amount,
+		o.priority,
+		o.status
+	FROM 
+		orders o
+	INNER JOIN workers w ON w.name = o.processed_by
+	WHERE 
+		o.status = 'cooking'
+		AND w.status = 'offline'
+		AND o.updated_at < now() - make_interval(secs => $1)
+	ORDER BY 
+		o.priority DESC, o.updated_at;`
+
+	rows, err := r.pool.Query(ctx, query, stuckAfter.Seconds())
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+
+	var ids []int
+	orders, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.CreateOrder, error) {
+		var (
+			id    int
+			order models.CreateOrder
+		)
+		if err := row.Scan(
+			&id,
+			&order.Number,
+			&order.CustomerName,
+			&order.Type,
+			&order.TableNumber,
+			&order.DeliveryAddress,
+			&order.TotalAmount,
+			&order.Priority,
+			&order.Status,
+		); err != nil {
+			return models.CreateOrder{}, err
+		}
+		ids = append(ids, id)
+		return order, nil
+	})
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+
+	for i := range orders {
+		if orders[i].Items, err = listItems(ctx, r.pool, ids[i]); err != nil {
+			return nil, wrap(op, err)
+		}
+	}
+
+	return orders, nil
+}
+
+func listItems(ctx context.Context, pool *pgxpool.Pool, orderID int) ([]models.CreateOrderItem, error) {
+	query := `
+	SELECT 
+		name,
+		quantity,
+		price
+	FROM 
+		order_items
+	WHERE 
+		order_id = $1
+	ORDER BY 
+		id;`
+
+	rows, err := pool.Query(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.CreateOrderItem, error) {
+		var item models.CreateOrderItem
+		err := row.Scan(&item.Name, &item.Quantity, &item.Price)
+		return item, err
+	})
+}
+
+// Requeue returns order from 'cooking' to 'received' and logs 'requeued' status in one transaction.
+// publish is called inside the transaction, the order is not changed if it fails.
+// Returns models.ErrOrderNotFound if the order is not in 'cooking' anymore.
+func (r *orderRepository) Requeue(ctx context.Context, orderNumber, changedBy, notes string, publish func() error) error {
+	const op = "orderRepository.Requeue"
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return wrap(op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	UPDATE orders
+	SET 
+		status = $1,
+		processed_by = NULL,
+		updated_at = now()
+	WHERE 
+		number = $2
+		AND status = $3
+	RETURNING id;`
+
+	var orderID int
+	if err := tx.QueryRow(ctx, query, types.StatusOrderReceived, orderNumber, types.StatusOrderCooking).Scan(&orderID); err != nil {
+		if err == pgx.ErrNoRows {
+			return wrap(op, models.ErrOrderNotFound)
+		}
+		return wrap(op, err)
+	}
+
+	query = `
+		INSERT INTO
+			order_status_log (order_id, status, changed_by, notes)
+		VALUES
+			($1, $2, $3, $4);`
+
+	if _, err := tx.Exec(ctx, query, orderID, types.StatusOrderRequeued, changedBy, notes); err != nil {
+		return wrap(op, err)
+	}
+
+	if err := publish(); err != nil {
+		return wrap(op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return wrap(op, err)
+	}
+
+	return nil
+}
+
+// MarkReady marks order being cooked ready, the worker cooking it in manual mode finishes it.
+// Returns models.ErrOrderNotFound if there's no such order and models.ErrOrderNotCooking
+// if it isn't being cooked.
+func (r *orderRepository) MarkReady(ctx context.Context, orderNumber string) error {
+	const op = "orderRepository.MarkReady"
+
+	query := `
+	UPDATE 
+		orders
+	SET 
+		marked_ready_at = now()
+	WHERE 
+		number = $1
+		AND status = $2;`
+
+	res, err := r.pool.Exec(ctx, query, orderNumber, types.StatusOrderCooking)
+	if err != nil {
+		return wrap(op, err)
+	}
+
+	if res.RowsAffected() == 0 {
+		var exists bool
+		if err := r.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM orders WHERE number = $1);`, orderNumber).Scan(&exists); err != nil {
+			return wrap(op, err)
+		}
+		if !exists {
+			return wrap(op, models.ErrOrderNotFound)
+		}
+		return wrap(op, models.ErrOrderNotCooking)
+	}
+
+	return nil
+}
+
+// IsMarkedReady reports if the order was marked ready since its cooking started.
+func (r *orderRepository) IsMarkedReady(ctx context.Context, orderNumber string) (bool, error) {
+	const op = "orderRepository.IsMarkedReady"
+
+	query := `SELECT marked_ready_at IS NOT NULL FROM orders WHERE number = $1;`
+
+	var marked bool
+	if err := r.pool.QueryRow(ctx, query, orderNumber).Scan(&marked); err != nil {
+		if err == pgx.ErrNoRows {
+			return false, wrap(op, models.ErrOrderNotFound)
+		}
+		return false, wrap(op, err)
+	}
+
+	return marked, nil
+}
@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"wheres-my-pizza/pkg/ratelimit"
+)
+
+// rateLimitStore keeps rate limit buckets in postgres, so limits hold across order-service replicas.
+type rateLimitStore struct {
+	pool *pgxpool.Pool
+}
+
+func NewRateLimitStore(pool *pgxpool.Pool) *rateLimitStore {
+	return &rateLimitStore{
+		pool: pool,
+	}
+}
+
+// Take refills the bucket and takes a token in one statement, the row lock serializes
+// concurrent requests of the same client.
+func (s *rateLimitStore) Take(ctx context.Context, key string, limit ratelimit.Limit) (float64, bool, error) {
+	const op = "rateLimitStore.Take"
+
+	// refilled tokens are LEAST(burst, tokens + elapsed * rate)
+	query := `
+	INSERT INTO rate_limit_buckets AS b (
+		key,
+		tokens,
+		allowed,
+		updated_at
+	) VALUES ($1, $3::double precision - 1, true, now())
+	ON CONFLICT (key) DO UPDATE
+	SET
+		tokens = LEAST($3, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::double precision * $2::double precision)
+			- (LEAST($3, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::double precision * $2::double precision) >= 1)::integer,
+		allowed = LEAST($3, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::double precision * $2::double precision) >= 1,
+		updated_at = now()
+	RETURNING
+		tokens, allowed;`
+
+	var (
+		tokens  float64
+		allowed bool
+	)
+	if err := s.pool.QueryRow(ctx, query, key, limit.Rate, limit.Burst).Scan(&tokens, &allowed); err != nil {
+		return 0, false, wrap(op, err)
+	}
+
+	return tokens, allowed, nil
+}
+
+func (s *rateLimitStore) Cleanup(ctx context.Context, idle time.Duration) error {
+	const op = "rateLimitStore.Cleanup"
+
+	query := `
+	DELETE FROM rate_limit_buckets
+	WHERE 
+		updated_at < now() - make_interval(secs => $1);`
+
+	if _, err := s.pool.Exec(ctx, query, idle.Seconds()); err != nil {
+		return wrap(op, err)
+	}
+
+	return nil
+}
@@ -2,7 +2,7 @@ package postgres
 
 import (
 	"context"
-	"fmt"
+	"time"
 
 	"wheres-my-pizza/internal/domain/models"
 
@@ -42,9 +42,9 @@ func (repo *statusRepository) GetCurrent(ctx context.Context, orderNumber string
 	if err := repo.pool.QueryRow(ctx, query, orderNumber).
 		Scan(&statusInfo.OrderNumber, &statusInfo.Status, &statusInfo.UpdatedAt, &statusInfo.Completion, &statusInfo.ProcessedBy); err != nil {
 		if err == pgx.ErrNoRows {
-			return models.OrderStatus{}, models.ErrOrderNotFound
+			return models.OrderStatus{}, wrap(op, models.ErrOrderNotFound)
 		}
-		return models.OrderStatus{}, fmt.Errorf("%s: %v", op, err)
+		return models.OrderStatus{}, wrap(op, err)
 	}
 
 	return statusInfo, nil
@@ -66,7 +66,7 @@ func (repo *statusRepository) ListOrderHistory(ctx context.Context, orderNumber
 
 	rows, err := repo.pool.Query(ctx, query, orderNumber)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %v", op, err)
+		return nil, wrap(op, err)
 	}
 
 	historyList, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.OrderHistory, error) {
@@ -77,12 +77,167 @@ func (repo *statusRepository) ListOrderHistory(ctx context.Context, orderNumber
 		return history, nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("%s: %v", op, err)
+		return nil, wrap(op, err)
 	}
 
 	if len(historyList) == 0 {
-		return nil, models.ErrOrderNotFound
+		return nil, wrap(op, models.ErrOrderNotFound)
 	}
 
 	return historyList, nil
 }
+
+// GetProgress returns progress of the order with its items. Kitchen queues are FIFO (they are
+// not priority queues), so orders ahead are waiting orders of the same type created earlier.
+func (repo *statusRepository) GetProgress(ctx context.Context, orderNumber string) (models.OrderProgress, error) {
+	const op = "statusRepository.GetProgress"
+
+	query := `
+	SELECT 
+		o.id,
+		o.type,
+		o.status,
+		o.updated_at,
+		o.completed_at,
+		(
+			SELECT 
+				count(*)
+			FROM 
+				orders a
+			WHERE 
+				a.type = o.type
+				AND a.status = 'received'
+				AND a.created_at < o.created_at
+		)
+	FROM 
+		orders o
+	WHERE 
+		o.number = $1;`
+
+	var (
+		id       int
+		progress models.OrderProgress
+	)
+	if err := repo.pool.QueryRow(ctx, query, orderNumber).Scan(
+		&id,
+		&progress.Type,
+		&progress.Status,
+		&progress.StatusChanged,
+		&progress.CompletedAt,
+		&progress.Ahead,
+	); err != nil {
+		if err == pgx.ErrNoRows {
+			return models.OrderProgress{}, wrap(op, models.ErrOrderNotFound)
+		}
+		return models.OrderProgress{}, wrap(op, err)
+	}
+
+	items, err := listItems(ctx, repo.pool, id)
+	if err != nil {
+		return models.OrderProgress{}, wrap(op, err)
+	}
+	progress.Items = items
+
+	return progress, nil
+}
+
+// ListCooking returns orders of the type being cooked by workers online within threshold,
+// with their items and the time cooking started.
+func (repo *statusRepository) ListCooking(ctx context.Context, orderType string, threshold time.Duration) ([]models.CookingOrder, error) {
+	const op = "statusRepository.ListCooking"
+
+	query := `
+	SELECT 
+		o.id,
+		o.type,
+		o.updated_at
+	FROM 
+		orders o
+	INNER JOIN workers w ON w.name = o.processed_by
+	WHERE 
+		o.type = $1
+		AND o.status = 'cooking'
+		AND w.status = 'online'
+		AND w.last_seen > now() - make_interval(secs => $2)
+	ORDER BY 
+		o.updated_at;`
+
+	rows, err := repo.pool.Query(ctx, query, orderType, threshold.Seconds())
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+
+	var ids []int
+	orders, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.CookingOrder, error) {
+		var (
+			id    int
+			order models.CookingOrder
+		)
+		if err := row.Scan(&id, &order.Order.Type, &order.Started); err != nil {
+			return models.CookingOrder{}, err
+		}
+		ids = append(ids, id)
+		return order, nil
+	})
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+
+	for i := range orders {
+		if orders[i].Order.Items, err = listItems(ctx, repo.pool, ids[i]); err != nil {
+			return nil, wrap(op, err)
+		}
+	}
+
+	return orders, nil
+}
+
+// MeanCookTime returns mean time between 'cooking' and 'ready' of the last samples orders
+// of the order type and number of orders it was calculated from.
+func (repo *statusRepository) MeanCookTime(ctx context.Context, orderType string, samples int) (time.Duration, int, error) {
+	const op = "statusRepository.MeanCookTime"
+
+	query := `
+	WITH cooks AS (
+		SELECT 
+			r.changed_at - c.changed_at AS cook_time
+		FROM 
+			order_status_log c
+		INNER JOIN orders o ON o.id = c.order_id
+		INNER JOIN LATERAL (
+			SELECT 
+				changed_at
+			FROM 
+				order_status_log
+			WHERE 
+				order_id = c.order_id
+				AND status = 'ready'
+				AND changed_by = c.changed_by
+				AND changed_at >= c.changed_at
+			ORDER BY 
+				changed_at
+			LIMIT 1
+		) r ON true
+		WHERE 
+			c.status = 'cooking'
+			AND o.type = $1
+		ORDER BY 
+			c.changed_at DESC
+		LIMIT $2
+	)
+	SELECT 
+		count(*),
+		COALESCE(EXTRACT(EPOCH FROM avg(cook_time)), 0)
+	FROM 
+		cooks;`
+
+	var (
+		count   int
+		seconds float64
+	)
+	if err := repo.pool.QueryRow(ctx, query, orderType, samples).Scan(&count, &seconds); err != nil {
+		return 0, 0, wrap(op, err)
+	}
+
+	return time.Duration(seconds * float64(time.Second)), count, nil
+}
@@ -2,7 +2,8 @@ package postgres
 
 import (
 	"context"
-	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"wheres-my-pizza/internal/domain/models"
@@ -29,47 +30,286 @@ func (repo *workerRepository) List(ctx context.Context) ([]models.Worker, error)
 		name,
 		status,
 		orders_processed,
+		concurrency,
+		active_orders,
 		last_seen
 	FROM 
 		workers;`
 
 	rows, err := repo.pool.Query(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %v", op, err)
+		return nil, wrap(op, err)
 	}
 
 	workers, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Worker, error) {
 		var worker models.Worker
-		if err := row.Scan(&worker.Name, &worker.Status, &worker.ProcessedOrders, &worker.LastSeen); err != nil {
+		if err := row.Scan(
+			&worker.Name,
+			&worker.Status,
+			&worker.ProcessedOrders,
+			&worker.Concurrency,
+			&worker.ActiveOrders,
+			&worker.LastSeen,
+		); err != nil {
 			return models.Worker{}, err
 		}
 		return worker, nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("%s: %v", op, err)
+		return nil, wrap(op, err)
 	}
 
 	if len(workers) == 0 {
-		return nil, models.ErrWorkerNotFound
+		return nil, wrap(op, models.ErrWorkerNotFound)
 	}
 	return workers, nil
 }
 
-// MarkOnline marks a worker as online by inserting or updating its record.
+// Get returns the worker by name.
+func (repo *workerRepository) Get(ctx context.Context, name string) (models.Worker, error) {
+	const op = "workerRepository.Get"
+
+	query := `
+	SELECT 
+		name,
+		type,
+		status,
+		orders_processed,
+		concurrency,
+		active_orders,
+		last_seen
+	FROM 
+		workers
+	WHERE 
+		name = $1;`
+
+	var (
+		worker     models.Worker
+		orderTypes string
+	)
+	if err := repo.pool.QueryRow(ctx, query, name).Scan(
+		&worker.Name,
+		&orderTypes,
+		&worker.Status,
+		&worker.ProcessedOrders,
+		&worker.Concurrency,
+		&worker.ActiveOrders,
+		&worker.LastSeen,
+	); err != nil {
+		if err == pgx.ErrNoRows {
+			return models.Worker{}, wrap(op, models.ErrWorkerNotFound)
+		}
+		return models.Worker{}, wrap(op, err)
+	}
+	worker.OrderTypes = strings.Split(orderTypes, ",")
+
+	return worker, nil
+}
+
+// ListSessions returns up to limit latest sessions of the worker.
+func (repo *workerRepository) ListSessions(ctx context.Context, name string, limit int) ([]models.WorkerSession, error) {
+	const op = "workerRepository.ListSessions"
+
+	query := `
+	SELECT 
+		started_at,
+		ended_at,
+		orders_processed
+	FROM 
+		worker_sessions
+	WHERE 
+		worker_name = $1
+	ORDER BY 
+		started_at DESC
+	LIMIT $2;`
+
+	rows, err := repo.pool.Query(ctx, query, name, limit)
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+
+	sessions, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.WorkerSession, error) {
+		var session models.WorkerSession
+		err := row.Scan(&session.StartedAt, &session.EndedAt, &session.OrdersProcessed)
+		return session, err
+	})
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+
+	return sessions, nil
+}
+
+// Stats aggregates performance of the worker. Cook time is the time between 'cooking' and the
+// following 'ready' set by the worker. A cooking which wasn't followed by 'ready' from the worker
+// is a failure, unless the worker is still cooking the order.
+func (repo *workerRepository) Stats(ctx context.Context, name string) (models.WorkerStats, error) {
+	const op = "workerRepository.Stats"
+
+	stats := models.WorkerStats{
+		WorkerName: name,
+	}
+
+	query := `
+	SELECT 
+		count(*),
+		COALESCE(EXTRACT(EPOCH FROM sum(COALESCE(s.ended_at, w.last_seen) - s.started_at)) / 3600, 0)
+	FROM 
+		worker_sessions s
+	INNER JOIN workers w ON w.name = s.worker_name
+	WHERE 
+		s.worker_name = $1;`
+
+	if err := repo.pool.QueryRow(ctx, query, name).Scan(&stats.Sessions, &stats.OnlineHours); err != nil {
+		return models.WorkerStats{}, wrap(op, err)
+	}
+
+	query = `
+	WITH cooks AS (
+		SELECT 
+			o.type,
+			r.changed_at - c.changed_at AS cook_time,
+			o.status = 'cooking' AND o.processed_by = c.changed_by AS in_progress
+		FROM 
+			order_status_log c
+		INNER JOIN orders o ON o.id = c.order_id
+		LEFT JOIN LATERAL (
+			SELECT 
+				changed_at
+			FROM 
+				order_status_log
+			WHERE 
+				order_id = c.order_id
+				AND status = 'ready'
+				AND changed_by = c.changed_by
+				AND changed_at >= c.changed_at
+			ORDER BY 
+				changed_at
+			LIMIT 1
+		) r ON true
+		WHERE 
+			c.changed_by = $1
+			AND c.status = 'cooking'
+	)
+	SELECT 
+		type,
+		count(cook_time),
+		count(*) FILTER (WHERE cook_time IS NULL AND NOT in_progress),
+		COALESCE(EXTRACT(EPOCH FROM avg(cook_time)), 0),
+		COALESCE(EXTRACT(EPOCH FROM percentile_cont(0.95) WITHIN GROUP (ORDER BY cook_time)), 0)
+	FROM 
+		cooks
+	GROUP BY 
+		type
+	ORDER BY 
+		type;`
+
+	rows, err := repo.pool.Query(ctx, query, name)
+	if err != nil {
+		return models.WorkerStats{}, wrap(op, err)
+	}
+
+	stats.OrderTypes, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.OrderTypeStats, error) {
+		var ts models.OrderTypeStats
+		err := row.Scan(&ts.OrderType, &ts.Completed, &ts.Failures, &ts.MeanCookTime, &ts.P95CookTime)
+		return ts, err
+	})
+	if err != nil {
+		return models.WorkerStats{}, wrap(op, err)
+	}
+
+	if stats.OrderTypes == nil {
+		stats.OrderTypes = []models.OrderTypeStats{}
+	}
+
+	for _, ts := range stats.OrderTypes {
+		stats.Completed += ts.Completed
+		stats.Failures += ts.Failures
+	}
+
+	if stats.OnlineHours > 0 {
+		stats.OrdersPerHour = float64(stats.Completed) / stats.OnlineHours
+	}
+
+	return stats, nil
+}
+
+// CountOnline returns number of online workers which handle orderType and were seen within threshold.
+func (repo *workerRepository) CountOnline(ctx context.Context, orderType string, threshold time.Duration) (int, error) {
+	const op = "workerRepository.CountOnline"
+
+	query := `
+	SELECT 
+		count(*)
+	FROM 
+		workers
+	WHERE 
+		status = 'online'
+		AND last_seen > now() - make_interval(secs => $2)
+		AND $1 = ANY(string_to_array(type, ','));`
+
+	var count int
+	if err := repo.pool.QueryRow(ctx, query, orderType, threshold.Seconds()).Scan(&count); err != nil {
+		return 0, wrap(op, err)
+	}
+
+	return count, nil
+}
+
+// OnlineSlots returns number of orders of orderType which online workers seen within threshold
+// can cook at the same time.
+func (repo *workerRepository) OnlineSlots(ctx context.Context, orderType string, threshold time.Duration) (int, error) {
+	const op = "workerRepository.OnlineSlots"
+
+	query := `
+	SELECT 
+		COALESCE(sum(concurrency), 0)
+	FROM 
+		workers
+	WHERE 
+		status = 'online'
+		AND last_seen > now() - make_interval(secs => $2)
+		AND $1 = ANY(string_to_array(type, ','));`
+
+	var slots int
+	if err := repo.pool.QueryRow(ctx, query, orderType, threshold.Seconds()).Scan(&slots); err != nil {
+		return 0, wrap(op, err)
+	}
+
+	return slots, nil
+}
+
+// MarkOnline marks a worker as online by inserting or updating its record and starts a new session.
 // If the worker already exists and is online but last_seen is recent (within heartbeat), registration fails.
 // if worker marker 'online' worker still will be successfully marked if last_seen < Now() - heartbeat * 2
-func (repo *workerRepository) MarkOnline(ctx context.Context, name, orderTypes string, heartbeat time.Duration) error {
+// Session left open by a crashed worker is closed at its last_seen.
+func (repo *workerRepository) MarkOnline(ctx context.Context, name, orderTypes string, concurrency int, heartbeat time.Duration) error {
 	const op = "workerRepository.MarkOnline"
 
+	tx, err := repo.pool.Begin(ctx)
+	if err != nil {
+		return wrap(op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	// last_seen before registration, nil for a new worker
+	var lastSeen *time.Time
+	if err := tx.QueryRow(ctx, `SELECT last_seen FROM workers WHERE name = $1;`, name).Scan(&lastSeen); err != nil && err != pgx.ErrNoRows {
+		return wrap(op, err)
+	}
+
 	query := `
-		INSERT INTO workers (name, type, status, last_seen)
-		VALUES ($1, $2, 'online', now())
+		INSERT INTO workers (name, type, status, concurrency, active_orders, last_seen, heartbeat_interval)
+		VALUES ($1, $2, 'online', $4, 0, now(), make_interval(secs => $5))
 		ON CONFLICT (name)
 		DO UPDATE
 		SET 
 			status = 'online',
 			type = $2,
-			last_seen = now()
+			concurrency = $4,
+			active_orders = 0,
+			last_seen = now(),
+			heartbeat_interval = make_interval(secs => $5)
 		WHERE 
 			workers.name = $1
 			AND (
@@ -78,84 +318,336 @@ func (repo *workerRepository) MarkOnline(ctx context.Context, name, orderTypes s
 			);
 		`
 
-	res, err := repo.pool.Exec(ctx, query, name, orderTypes, int64(heartbeat.Seconds())*2)
+	res, err := tx.Exec(ctx, query, name, orderTypes, int64(heartbeat.Seconds())*2, concurrency, heartbeat.Seconds())
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return wrap(op, err)
 	}
 
 	if res.RowsAffected() == 0 {
-		return fmt.Errorf("%s: %v", op, models.ErrWorkerAlreadyOnline)
+		return wrap(op, models.ErrWorkerAlreadyOnline)
+	}
+
+	query = `
+		UPDATE 
+			worker_sessions
+		SET 
+			ended_at = COALESCE($2, now())
+		WHERE 
+			worker_name = $1
+			AND ended_at IS NULL;`
+
+	if _, err := tx.Exec(ctx, query, name, lastSeen); err != nil {
+		return wrap(op, err)
+	}
+
+	if _, err := tx.Exec(ctx, `INSERT INTO worker_sessions (worker_name) VALUES ($1);`, name); err != nil {
+		return wrap(op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return wrap(op, err)
 	}
 
 	return nil
 }
 
-func (repo *workerRepository) UpdateLastSeen(ctx context.Context, name string) error {
+// UpdateLastSeen updates last seen timestamp and heartbeat interval of the worker. A worker which was
+// marked offline by the reaper while it was alive gets status back and starts a new session.
+func (repo *workerRepository) UpdateLastSeen(ctx context.Context, name, status string, heartbeat time.Duration) error {
 	const op = "workerRepository.UpdateLastSeen"
 
+	query := `
+		WITH prev AS (
+			SELECT 
+				name,
+				status
+			FROM 
+				workers
+			WHERE 
+				name = $1
+			FOR UPDATE
+		), worker AS (
+			UPDATE 
+				workers w
+			SET 
+				last_seen = now(),
+				heartbeat_interval = make_interval(secs => $3),
+				status = CASE WHEN prev.status = 'offline' THEN $2 ELSE w.status END
+			FROM 
+				prev
+			WHERE 
+				w.name = prev.name
+			RETURNING w.name, prev.status AS old_status
+		), session AS (
+			INSERT INTO worker_sessions (worker_name)
+			SELECT name FROM worker WHERE old_status = 'offline'
+		)
+		SELECT count(*) FROM worker;`
+
+	var updated int
+	if err := repo.pool.QueryRow(ctx, query, name, status, heartbeat.Seconds()).Scan(&updated); err != nil {
+		return wrap(op, err)
+	}
+
+	if updated == 0 {
+		return wrap(op, models.ErrWorkerNotFound)
+	}
+
+	return nil
+}
+
+func (repo *workerRepository) IncrOrdersProcessed(ctx context.Context, name string) error {
+	const op = "workerRepository.IncrOrdersProcessed"
+
+	// Counted for the worker and its current session.
+	query := `
+		WITH worker AS (
+			UPDATE 
+				workers
+			SET 
+				orders_processed = orders_processed + 1
+			WHERE 
+				name = $1
+			RETURNING name
+		), session AS (
+			UPDATE 
+				worker_sessions
+			SET 
+				orders_processed = orders_processed + 1
+			WHERE 
+				worker_name IN (SELECT name FROM worker)
+				AND ended_at IS NULL
+		)
+		SELECT count(*) FROM worker;`
+
+	var updated int
+	if err := repo.pool.QueryRow(ctx, query, name).Scan(&updated); err != nil {
+		return wrap(op, err)
+	}
+
+	if updated == 0 {
+		return wrap(op, models.ErrOrderNotFound)
+	}
+
+	return nil
+}
+
+// AddActiveOrders changes number of orders the worker is cooking right now by delta.
+func (repo *workerRepository) AddActiveOrders(ctx context.Context, name string, delta int) error {
+	const op = "workerRepository.AddActiveOrders"
+
 	query := `
 		UPDATE 
 			workers
 		SET 
-			last_seen = now()
+			active_orders = GREATEST(active_orders + $2, 0)
 		WHERE 
 			name = $1;`
 
-	res, err := repo.pool.Exec(ctx, query, name)
+	res, err := repo.pool.Exec(ctx, query, name, delta)
 	if err != nil {
-		return fmt.Errorf("%s: %v", op, err)
+		return wrap(op, err)
 	}
 
 	if res.RowsAffected() == 0 {
-		return models.ErrOrderNotFound
+		return wrap(op, models.ErrWorkerNotFound)
 	}
 
 	return nil
 }
 
-func (repo *workerRepository) IncrOrdersProcessed(ctx context.Context, name string) error {
-	const op = "workerRepository.IncrOrdersProcessed"
+// MarkStaleOffline marks offline workers which are online or paused but missed two of their own
+// heartbeats and returns their names.
+func (repo *workerRepository) MarkStaleOffline(ctx context.Context) ([]string, error) {
+	const op = "workerRepository.MarkStaleOffline"
+
+	// Sessions of dead workers end when they were seen last time.
+	query := `
+		WITH stale AS (
+			UPDATE 
+				workers
+			SET 
+				status = 'offline',
+				active_orders = 0
+			WHERE 
+				status <> 'offline'
+				AND last_seen < now() - 2 * heartbeat_interval
+			RETURNING name, last_seen
+		), session AS (
+			UPDATE 
+				worker_sessions s
+			SET 
+				ended_at = stale.last_seen
+			FROM 
+				stale
+			WHERE 
+				s.worker_name = stale.name
+				AND s.ended_at IS NULL
+		)
+		SELECT name FROM stale;`
+
+	rows, err := repo.pool.Query(ctx, query)
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+
+	return names, nil
+}
+
+func (repo *workerRepository) MarkOffline(ctx context.Context, name string) error {
+	const op = "workerRepository.MarkOffline"
+
+	// Current session of the worker ends together with it.
+	query := `
+		WITH worker AS (
+			UPDATE 
+				workers
+			SET 
+				status = 'offline',
+				active_orders = 0,
+				last_seen = now()
+			WHERE 
+				name = $1
+			RETURNING name
+		), session AS (
+			UPDATE 
+				worker_sessions
+			SET 
+				ended_at = now()
+			WHERE 
+				worker_name IN (SELECT name FROM worker)
+				AND ended_at IS NULL
+		)
+		SELECT count(*) FROM worker;`
+
+	var updated int
+	if err := repo.pool.QueryRow(ctx, query, name).Scan(&updated); err != nil {
+		return wrap(op, err)
+	}
+
+	if updated == 0 {
+		return wrap(op, models.ErrOrderNotFound)
+	}
+
+	return nil
+}
+
+// SetStatus sets status of the worker.
+func (repo *workerRepository) SetStatus(ctx context.Context, name, status string) error {
+	const op = "workerRepository.SetStatus"
 
 	query := `
 		UPDATE 
 			workers
 		SET 
-			orders_processed = orders_processed + 1
+			status = $2
 		WHERE 
 			name = $1;`
 
-	res, err := repo.pool.Exec(ctx, query, name)
+	res, err := repo.pool.Exec(ctx, query, name, status)
 	if err != nil {
-		return fmt.Errorf("%s: %v", op, err)
+		return wrap(op, err)
 	}
 
 	if res.RowsAffected() == 0 {
-		return models.ErrOrderNotFound
+		return wrap(op, models.ErrWorkerNotFound)
 	}
 
 	return nil
 }
 
-func (repo *workerRepository) MarkOffline(ctx context.Context, name string) error {
-	const op = "workerRepository.MarkOffline"
+// SetOrderTypes sets comma-separated list of order types the worker handles.
+func (repo *workerRepository) SetOrderTypes(ctx context.Context, name, orderTypes string) error {
+	const op = "workerRepository.SetOrderTypes"
 
 	query := `
 		UPDATE 
 			workers
 		SET 
-			status = 'offline',
-			last_seen = now()
+			type = $2
 		WHERE 
 			name = $1;`
 
-	res, err := repo.pool.Exec(ctx, query, name)
+	res, err := repo.pool.Exec(ctx, query, name, orderTypes)
 	if err != nil {
-		return fmt.Errorf("%s: %v", op, err)
+		return wrap(op, err)
 	}
 
 	if res.RowsAffected() == 0 {
-		return models.ErrOrderNotFound
+		return wrap(op, models.ErrWorkerNotFound)
 	}
 
 	return nil
 }
+
+// AddCommand queues command for the worker. Returns models.ErrWorkerNotFound if the worker doesn't exist.
+func (repo *workerRepository) AddCommand(ctx context.Context, cmd models.WorkerCommand) (models.WorkerCommand, error) {
+	const op = "workerRepository.AddCommand"
+
+	query := `
+		INSERT INTO worker_commands (worker_name, command, order_types)
+		SELECT name, $2, NULLIF($3, '')
+		FROM workers
+		WHERE name = $1
+		RETURNING id, created_at;`
+
+	err := repo.pool.QueryRow(ctx, query, cmd.WorkerName, cmd.Command, strings.Join(cmd.OrderTypes, ",")).
+		Scan(&cmd.ID, &cmd.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return models.WorkerCommand{}, wrap(op, models.ErrWorkerNotFound)
+		}
+		return models.WorkerCommand{}, wrap(op, err)
+	}
+
+	return cmd, nil
+}
+
+// TakeCommands marks pending commands of the worker processed and returns them in order they were sent.
+func (repo *workerRepository) TakeCommands(ctx context.Context, name string) ([]models.WorkerCommand, error) {
+	const op = "workerRepository.TakeCommands"
+
+	query := `
+		UPDATE 
+			worker_commands
+		SET 
+			processed_at = now()
+		WHERE 
+			worker_name = $1
+			AND processed_at IS NULL
+		RETURNING id, worker_name, command, COALESCE(order_types, ''), created_at;`
+
+	rows, err := repo.pool.Query(ctx, query, name)
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+
+	commands, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.WorkerCommand, error) {
+		var (
+			cmd        models.WorkerCommand
+			orderTypes string
+		)
+		if err := row.Scan(&cmd.ID, &cmd.WorkerName, &cmd.Command, &orderTypes, &cmd.CreatedAt); err != nil {
+			return models.WorkerCommand{}, err
+		}
+		if orderTypes != "" {
+			cmd.OrderTypes = strings.Split(orderTypes, ",")
+		}
+		return cmd, nil
+	})
+	if err != nil {
+		return nil, wrap(op, err)
+	}
+
+	// UPDATE ... RETURNING doesn't keep order
+	slices.SortFunc(commands, func(a, b models.WorkerCommand) int {
+		return a.ID - b.ID
+	})
+
+	return commands, nil
+}
@@ -2,13 +2,16 @@ package rabbit
 
 import (
 	"context"
-	"encoding/json"
+
+	amqp "github.com/rabbitmq/amqp091-go"
 
 	"wheres-my-pizza/internal/domain/models"
 )
 
-// Order represents the structure of an order to be published
+// Order represents the structure of an order to be published, it is the current version of the
+// message, see orderSchemaVersion.
 type Order struct {
+	SchemaVersion   int         `json:"schema_version"`
 	OrderNumber     string      `json:"order_number"`
 	CustomerName    string      `json:"customer_name"`
 	OrderType       string      `json:"order_type"`
@@ -48,6 +51,7 @@ func FromInternalToPublishOrder(ctx context.Context, m *models.CreateOrder) *Ord
 	}
 
 	return &Order{
+		SchemaVersion:   orderSchemaVersion,
 		OrderNumber:     m.Number,
 		CustomerName:    m.CustomerName,
 		OrderType:       m.Type,
@@ -88,16 +92,17 @@ func FromPublishToInternalOrder(m *Order) *models.CreateOrder {
 	}
 }
 
-func ToInternalOrder(body []byte) (*Order, error) {
-	publishedOrder := &Order{}
-	if err := json.Unmarshal(body, publishedOrder); err != nil {
+// DecodeOrder strictly decodes order message of any supported version, see orderSchema.
+func DecodeOrder(msg amqp.Delivery) (*Order, error) {
+	order, err := decodeMessage(orderSchema, msg)
+	if err != nil {
 		return nil, err
 	}
+	return &order, nil
+}
 
-	// order := FromPublishToInternalOrder(&publishedOrder)
-	// if order == nil {
-	// 	return nil, errors.New("failed to map request to internal CreateOrder struct")
-	// }
-
-	return publishedOrder, nil
+// StatusUpdate is the status update message, the current version of it, see statusUpdateSchemaVersion.
+type StatusUpdate struct {
+	SchemaVersion int `json:"schema_version"`
+	models.StatusUpdate
 }
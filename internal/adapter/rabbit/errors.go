@@ -0,0 +1,18 @@
+package rabbit
+
+import (
+	"errors"
+
+	"wheres-my-pizza/internal/domain/models"
+	"wheres-my-pizza/pkg/rabbit"
+)
+
+// publishError wraps error of publishing. Unroutable and nacked messages are internal errors: no
+// queue is bound for the message or the broker refused it, publishing it again fails the same way.
+// Other failures (lost connection, confirm timeout) are transient.
+func publishError(op string, err error) error {
+	if errors.Is(err, rabbit.ErrPublishReturned) || errors.Is(err, rabbit.ErrPublishNacked) {
+		return models.NewError(op, models.KindInternal, err)
+	}
+	return models.NewError(op, models.KindTransient, err)
+}
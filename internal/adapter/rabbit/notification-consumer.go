@@ -2,9 +2,10 @@ package rabbit
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"time"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
 
 	"wheres-my-pizza/internal/config"
 	"wheres-my-pizza/internal/domain/models"
@@ -17,8 +18,8 @@ type NotificationSubscriber struct {
 	cfg    config.RabbitMQ
 
 	exchangeName string
-	queueName    string
-	stop         chan struct{}
+	mu           sync.Mutex
+	cancel       context.CancelFunc // stops consuming
 	log          logger.Logger
 }
 
@@ -27,7 +28,7 @@ func NewNotificationSubscriber(client *rabbit.RabbitMQ, cfg config.RabbitMQ, log
 		reader:       client,
 		cfg:          cfg,
 		exchangeName: cfg.NotificationsExchange,
-		stop:         make(chan struct{}, 1),
+		cancel:       func() {},
 		log:          log,
 	}
 }
@@ -35,170 +36,106 @@ func NewNotificationSubscriber(client *rabbit.RabbitMQ, cfg config.RabbitMQ, log
 func (s *NotificationSubscriber) StartListening(ctx context.Context) (chan models.StatusUpdate, error) {
 	s.log.Info(ctx, "subscriber_start", "Starting to listen for notifications")
 
-	if err := s.declareAndBindQueue(); err != nil {
+	if err := s.reader.DeclareExchange(ctx, rabbit.Exchange{
+		Name:    s.exchangeName,
+		Kind:    "fanout",
+		Durable: true,
+	}); err != nil {
+		s.log.Error(ctx, "rabbit_init_queue", "Failed to declare exchange", err)
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s.mu.Lock()
+	s.cancel = cancel
+	s.mu.Unlock()
+
+	// Every subscriber has its own server-named queue, it is declared again on resubscribe.
+	msgs, err := s.reader.Consume(ctx, rabbit.Subscription{
+		Setup: s.declareAndBindQueue,
+	})
+	if err != nil {
+		cancel()
 		s.log.Error(ctx, "rabbit_init_queue", "Failed to declare/bind queue", err)
 		return nil, err
 	}
 
-	s.log.Info(ctx, "rabbit_queue_ready", fmt.Sprintf("Queue %s bound to exchange %s", s.queueName, s.exchangeName))
+	s.log.Info(ctx, "rabbit_listening", "Started listening for notifications")
 
 	updateCh := make(chan models.StatusUpdate, 1)
 
-	go s.startConsuming(ctx, updateCh)
+	go s.startConsuming(ctx, msgs, updateCh)
 
 	return updateCh, nil
 }
 
-func (s *NotificationSubscriber) declareAndBindQueue() error {
-	if err := s.reader.Channel.ExchangeDeclare(
-		s.exchangeName,
-		"fanout",
-		true, false, false, false, nil,
-	); err != nil {
-		return fmt.Errorf("failed to declare exchange: %w", err)
-	}
-
-	q, err := s.reader.Channel.QueueDeclare(
-		"", true, false, false, false, nil,
+// declareAndBindQueue declares exclusive queue of the subscriber and binds it to notifications exchange.
+// The queue is deleted by broker when subscriber channel's connection is closed.
+func (s *NotificationSubscriber) declareAndBindQueue(ch *amqp.Channel) (string, error) {
+	q, err := ch.QueueDeclare(
+		"", false, true, true, false, nil,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to declare queue: %w", err)
+		return "", fmt.Errorf("failed to declare queue: %w", err)
 	}
 
-	if err := s.reader.Channel.QueueBind(
+	if err := ch.QueueBind(
 		q.Name, "", s.exchangeName, false, nil,
 	); err != nil {
-		return fmt.Errorf("failed to bind queue: %w", err)
+		return "", fmt.Errorf("failed to bind queue: %w", err)
 	}
 
-	s.queueName = q.Name
+	s.log.Info(context.Background(), "rabbit_queue_ready", fmt.Sprintf("Queue %s bound to exchange %s", q.Name, s.exchangeName))
 
-	return nil
+	return q.Name, nil
 }
 
-func (s *NotificationSubscriber) startConsuming(ctx context.Context, outCh chan models.StatusUpdate) {
+func (s *NotificationSubscriber) startConsuming(ctx context.Context, msgs <-chan amqp.Delivery, outCh chan models.StatusUpdate) {
 	defer close(outCh)
 
-	for {
-		msgs, err := s.reader.Channel.Consume(
-			s.queueName, "", false, false, false, false, nil,
-		)
-		if err != nil {
-			s.log.Error(ctx, "rabbit_consume_start", "failed to consume messages", err)
-			return
-		}
-
-		s.log.Info(ctx, "rabbit_listening", "Started listening for notifications")
-
-		connClose := make(chan struct{}, 1)
-		go s.isAlive(ctx, connClose)
-
-	consumeLoop:
-		for {
-			select {
-			case msg := <-msgs:
-				update, err := decodeStatusUpdate(msg.Body)
-				if len(update.RequestID) != 0 {
-					ctx = logger.WithRequestID(ctx, update.RequestID) // request_id logging
-				}
-
-				if err != nil {
-					s.log.Error(ctx, "notification_decode", "Failed to decode status update", err)
-					if err := msg.Nack(false, false); err != nil {
-						s.log.Error(ctx, "rabbit_ack", "Failed to ack message", err)
-					}
-					continue
-				}
-
-				if err := msg.Ack(false); err != nil {
-					s.log.Error(ctx, "rabbit_ack", "Failed to ack message", err)
-				}
-
-				outCh <- update
-			case <-connClose:
-				s.log.Warn(ctx, "rabbit_channel_closed", "Channel closed by broker, attempting to reconnect", "error", err)
-
-				if err := s.reconnect(ctx); err != nil {
-					s.log.Error(ctx, "rabbit_reconnect_failed", "Reconnection failed", err)
-					return
-				}
-
-				break consumeLoop
-			case <-s.stop:
-				s.log.Info(ctx, "rabbit_consume_stop", "Stopped listening to notifications")
-				return
-			}
+	for msg := range msgs {
+		update, err := decodeStatusUpdate(msg)
+		msgCtx := ctx
+		if len(update.RequestID) != 0 {
+			msgCtx = logger.WithRequestID(ctx, update.RequestID) // request_id logging
 		}
-	}
-}
 
-func (s *NotificationSubscriber) isAlive(ctx context.Context, connClose chan struct{}) {
-	t := time.NewTicker(time.Second * 5)
-
-	for {
-		select {
-		case <-t.C:
-			if s.reader.Conn.IsClosed() {
-				s.log.Warn(ctx, "rabbit_connection_dead", "Detected closed connection")
-				connClose <- struct{}{}
-				return
+		if err != nil {
+			s.log.Error(msgCtx, "notification_decode", "Failed to decode status update", err)
+			if err := msg.Nack(false, false); err != nil {
+				s.log.Error(msgCtx, "rabbit_ack", "Failed to ack message", err)
 			}
-		case <-s.stop:
-			s.log.Debug(ctx, "is_alive_stop", "Stopped connection health check")
-			return
+			continue
 		}
-	}
-}
 
-func (s *NotificationSubscriber) reconnect(ctx context.Context) error {
-	var lastErr error
-
-	for attempt := 1; attempt <= 5; attempt++ {
-		s.log.Info(ctx, "rabbit_reconnect_attempt", fmt.Sprintf("Attempt %d to reconnect", attempt))
-
-		conn, err := rabbit.New(ctx, s.cfg.Conn, s.log)
-		if err == nil {
-			s.reader = conn
-			s.log.Info(ctx, "rabbit_reconnect_success", "Successfully reconnected to RabbitMQ")
-			return nil
+		if err := msg.Ack(false); err != nil {
+			s.log.Error(msgCtx, "rabbit_ack", "Failed to ack message", err)
 		}
 
-		lastErr = err
-		s.log.Warn(ctx, "rabbit_reconnect_failed", fmt.Sprintf("Attempt %d failed", attempt), err)
-
-		select {
-		case <-s.stop:
-			s.log.Info(ctx, "rabbit_reconnect_stopped", "Reconnect was stopped externally")
-			return fmt.Errorf("reconnect stopped externally")
-		case <-time.After(time.Duration(attempt) * time.Second):
-			time.Sleep(2 * time.Second)
-		}
+		outCh <- update
 	}
 
-	s.log.Error(ctx, "rabbit_reconnect_max_failed", "Failed to reconnect after max attempts", lastErr)
-	return fmt.Errorf("failed to reconnect after 5 attempts: %w", lastErr)
+	if err := s.reader.Err(); err != nil {
+		s.log.Error(ctx, "rabbit_consume_stop", "Stopped listening to notifications", err)
+		return
+	}
+	s.log.Info(ctx, "rabbit_consume_stop", "Stopped listening to notifications")
 }
 
+// Close stops consuming, the connection is shared and closed by its owner.
 func (s *NotificationSubscriber) Close() error {
-	select {
-	case s.stop <- struct{}{}:
-	default:
-	}
-
-	if _, err := s.reader.Channel.QueueDelete(s.queueName, false, false, true); err != nil {
-		s.log.Warn(context.Background(), "rabbitMQ_closing", "failed to close queue", "error", err)
-	}
+	s.mu.Lock()
+	s.cancel()
+	s.mu.Unlock()
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
-	defer cancel()
-
-	return s.reader.Close(ctx)
+	return nil
 }
 
-func decodeStatusUpdate(body []byte) (models.StatusUpdate, error) {
-	var update models.StatusUpdate
-	if err := json.Unmarshal(body, &update); err != nil {
+// decodeStatusUpdate strictly decodes status update message of any supported version.
+func decodeStatusUpdate(msg amqp.Delivery) (models.StatusUpdate, error) {
+	update, err := decodeMessage(statusUpdateSchema, msg)
+	if err != nil {
 		return models.StatusUpdate{}, err
 	}
-	return update, nil
+	return update.StatusUpdate, nil
 }
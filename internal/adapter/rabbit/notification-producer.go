@@ -11,7 +11,6 @@ import (
 
 	"wheres-my-pizza/internal/config"
 	"wheres-my-pizza/internal/domain/models"
-	"wheres-my-pizza/internal/domain/types"
 	"wheres-my-pizza/pkg/logger"
 	"wheres-my-pizza/pkg/rabbit"
 )
@@ -28,25 +27,19 @@ type NotificationProducer struct {
 	log logger.Logger
 }
 
-func NewProducerNotify(ctx context.Context, cfg config.RabbitMQ, log logger.Logger) (*NotificationProducer, error) {
+// NewProducerNotify creates status update producer on the shared RabbitMQ client.
+func NewProducerNotify(ctx context.Context, client *rabbit.RabbitMQ, cfg config.RabbitMQ, log logger.Logger) (*NotificationProducer, error) {
 	if len(cfg.NotificationsExchange) == 0 {
 		return nil, ErrEmptyExchangeName
 	}
 
-	// RabbitMQ connection
-	client, err := rabbit.New(ctx, cfg.Conn, log)
-	if err != nil {
-		log.Error(ctx, types.ActionRabbitConnectionFailed, "failed to connect RabbitMQ", err)
-		return nil, err
-	}
-
 	// declaring notification exchange
-	if err := client.Channel.ExchangeDeclare(
-		cfg.NotificationsExchange,
-		"fanout",
-		true, false, false, false, nil,
-	); err != nil {
-		return nil, fmt.Errorf("failed to declare exchange %s: %w", cfg.NotificationsExchange, err)
+	if err := client.DeclareExchange(ctx, rabbit.Exchange{
+		Name:    cfg.NotificationsExchange,
+		Kind:    "fanout",
+		Durable: true,
+	}); err != nil {
+		return nil, err
 	}
 
 	return &NotificationProducer{
@@ -60,16 +53,10 @@ func NewProducerNotify(ctx context.Context, cfg config.RabbitMQ, log logger.Logg
 
 // StatusUpdate publishes event about status change.
 func (p *NotificationProducer) StatusUpdate(ctx context.Context, req *models.StatusUpdate) error {
-	// Cheking if connected
-	if p.client.IsConnectionClosed() {
-		p.log.Debug(ctx, types.ActionRabbitReconnect, "trying to recconect to RabbitMQ")
-		if err := p.reconnect(ctx); err != nil {
-			return err
-		}
-	}
+	const op = "NotificationProducer.StatusUpdate"
 
 	// Marshal the struct to JSON
-	body, err := json.Marshal(req)
+	body, err := json.Marshal(StatusUpdate{SchemaVersion: statusUpdateSchemaVersion, StatusUpdate: *req})
 	if err != nil {
 		return fmt.Errorf("failed to marshal StatusUpdate: %w", err)
 	}
@@ -77,48 +64,24 @@ func (p *NotificationProducer) StatusUpdate(ctx context.Context, req *models.Sta
 	// Prepare the message
 	msg := amqp.Publishing{
 		ContentType:  "application/json",
+		Headers:      schemaHeaders(statusUpdateSchemaVersion),
 		Body:         body,
 		DeliveryMode: amqp.Persistent, // Persistent message (2). Means rabbitmq will store message in the disc.
 		Timestamp:    time.Now(),
 	}
 
-	// Publish to the exchange with empty routing key (fanout ignores it)
-	if err := p.client.Channel.PublishWithContext(
+	// Publish to the exchange with empty routing key (fanout ignores it) and wait for confirmation.
+	// Not mandatory: notification subscribers are optional, fanout without bound queues is a normal state.
+	if err := p.client.PublishConfirmed(
 		ctx,
 		p.exchangeName,
 		"",    // routing key is ignored for fanout
 		false, // mandatory
-		false, // immediate
 		msg,
+		p.cfg.ConfirmTimeout,
 	); err != nil {
-		return fmt.Errorf("failed to publish StatusUpdate: %w", err)
+		return publishError(op, fmt.Errorf("failed to publish StatusUpdate: %w", err))
 	}
 
 	return nil
 }
-
-func (r *NotificationProducer) reconnect(ctx context.Context) error {
-	fn := func() error {
-		conn, err := rabbit.New(ctx, r.cfg.Conn, r.log)
-		if err != nil {
-			return err
-		}
-		r.client = conn
-
-		return nil
-	}
-
-	if err := retry(ctx, r.cfg.ReconnectAttempt, r.cfg.ReconnectDelay, fn); err != nil {
-		return fmt.Errorf("failed to recconect rabbitMQ: %w", err)
-	}
-
-	return nil
-}
-
-func (r *NotificationProducer) Close(ctx context.Context) error {
-	if r.client == nil {
-		return nil
-	}
-
-	return r.client.Close(ctx)
-}
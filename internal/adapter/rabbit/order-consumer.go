@@ -4,6 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
 
 	"wheres-my-pizza/internal/config"
 	"wheres-my-pizza/internal/domain/models"
@@ -11,6 +15,7 @@ import (
 	"wheres-my-pizza/internal/services/kitchen"
 	"wheres-my-pizza/pkg/logger"
 	"wheres-my-pizza/pkg/rabbit"
+	"wheres-my-pizza/pkg/semaphore"
 )
 
 type OrderConsumer struct {
@@ -18,38 +23,47 @@ type OrderConsumer struct {
 
 	prefetchCount int
 	exchangeOrder string
-	orderTypes    []string
+
+	mu         sync.Mutex
+	orderTypes []string              // order types whose queues are declared
+	qos        map[chan int]struct{} // prefetch updates of running consumers, see SetPrefetch
+
+	// slots limits number of orders handled at the same time, shared by all order types.
+	concurrency int
+	slots       *semaphore.Semaphore
 
 	cfg config.RabbitMQ
 	log logger.Logger
 }
 
-func NewOrderConsumer(ctx context.Context, cfg config.RabbitMQ, prefetchCount int, orderTypes []string, log logger.Logger) (*OrderConsumer, error) {
+// NewOrderConsumer creates order consumer on the shared RabbitMQ client which handles
+// up to concurrency orders at the same time.
+func NewOrderConsumer(
+	ctx context.Context,
+	client *rabbit.RabbitMQ,
+	cfg config.RabbitMQ,
+	prefetchCount int,
+	concurrency int,
+	orderTypes []string,
+	log logger.Logger,
+) (*OrderConsumer, error) {
 	if len(orderTypes) == 0 {
 		return nil, errors.New("orderTypes not provided, slice len 0")
 	}
-
-	// RabbitMQ connection
-	client, err := rabbit.New(ctx, cfg.Conn, log)
-	if err != nil {
-		log.Error(ctx, types.ActionRabbitConnectionFailed, "failed to connect RabbitMQ", err)
-		return nil, err
+	if concurrency < 1 {
+		return nil, errors.New("concurrency must be at least 1")
 	}
 
 	// Creating exchange.
-	if err := client.Channel.ExchangeDeclare(
-		cfg.OrderExchange,
-		"topic",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	); err != nil {
-		return nil, fmt.Errorf("failed to declare exchange: %w", err)
+	if err := client.DeclareExchange(ctx, rabbit.Exchange{
+		Name:    cfg.OrderExchange,
+		Kind:    "topic",
+		Durable: true,
+	}); err != nil {
+		return nil, err
 	}
 
-	if err := InitQueuesForOrderTypes(client, cfg.OrderExchange, orderTypes); err != nil {
+	if err := InitQueuesForOrderTypes(ctx, client, cfg.OrderExchange, orderTypes); err != nil {
 		return nil, err
 	}
 
@@ -57,119 +71,201 @@ func NewOrderConsumer(ctx context.Context, cfg config.RabbitMQ, prefetchCount in
 		client:        client,
 		prefetchCount: prefetchCount,
 		exchangeOrder: cfg.OrderExchange,
-		orderTypes:    orderTypes,
+		orderTypes:    slices.Clone(orderTypes),
+		qos:           make(map[chan int]struct{}),
+		concurrency:   concurrency,
+		slots:         semaphore.NewSemaphore(concurrency),
 
 		cfg: cfg,
 		log: log,
 	}, nil
 }
 
-// Consumes consumes created order messages.
+// Consumes consumes created order messages. Every message is handled in its own goroutine
+// once a free slot is available and acknowledged separately (never with multiple=true), so orders
+// which finish out of order don't acknowledge each other.
+//
+// Cancelling ctx only stops consuming: prefetched messages are requeued, handlers get a context
+// which is not cancelled with ctx and can still acknowledge their messages. Consume returns after
+// all started handlers have finished.
+//
+// When the consumer channel is lost, its messages are redelivered and can't be acknowledged, so
+// contexts of their handlers are cancelled (orders are rolled back as on shutdown) and new
+// messages are handled after the interrupted handlers have finished.
 func (c *OrderConsumer) Consume(
 	ctx context.Context,
 	orderType string,
 	handler func(ctx context.Context, req *models.CreateOrder) error,
 ) error {
-	// Cheking if connected
-	if c.client.IsConnectionClosed() {
-		c.log.Debug(ctx, types.ActionRabbitReconnect, "trying to recconect to RabbitMQ")
-		if err := c.reconnect(ctx); err != nil {
-			return err
-		}
+	queueName := getQueueByOrderType(orderType)
+
+	// Order types may be changed at runtime, queue of a new one is declared first.
+	if err := c.declareQueue(ctx, orderType); err != nil {
+		c.log.Error(ctx, types.ActionRabbitConnectionFailed, "failed to declare queue", err, "queue", queueName)
+		return fmt.Errorf("failed to declare queue: %w", err)
 	}
 
-	// In RabbitMQ, basic.qos is a method used to configure the quality of service for consumers,
-	// specifically by controlling how many messages a consumer can receive without acknowledging them.
+	// Each consumer works on its own channel. The client restores QoS and the consumer after reconnect.
 	// Using basic.qos is critical to prevent worker overload and distribute the load evenly between workers.
-	if err := c.client.Channel.Qos(c.prefetchCount, 0, false); err != nil {
-		c.log.Error(ctx, types.ActionMessageProcessingFailed, "failed to set QoS", err, "prefetchCount", c.prefetchCount)
-		return fmt.Errorf("failed to set QoS: %w", err)
-	}
+	// Prefetch lower than concurrency would leave slots idle, so it is raised to the number of slots.
+	drained := make(chan struct{})
+	lost := make(chan struct{})
+	qos := make(chan int, 1)
 
-	queueName := getQueueByOrderType(orderType)
+	c.mu.Lock()
+	prefetch := max(c.prefetchCount, c.concurrency)
+	c.qos[qos] = struct{}{}
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.qos, qos)
+		c.mu.Unlock()
+	}()
 
-	msgs, err := c.client.Channel.Consume(
-		queueName,
-		"",
-		false,
-		false,
-		false,
-		false,
-		nil,
-	)
+	msgs, err := c.client.Consume(ctx, rabbit.Subscription{
+		Queue:    queueName,
+		Prefetch: prefetch,
+		Qos:      qos,
+		Drained:  drained,
+		Lost:     lost,
+	})
 	if err != nil {
 		c.log.Error(ctx, types.ActionMessageProcessingFailed, "failed to consume queue", err, "queue", queueName)
 		return fmt.Errorf("failed to start consuming: %w", err)
 	}
 
+	handlerCtx := context.WithoutCancel(ctx)
+	channel := newChannelHandlers(handlerCtx)
+
+	var inFlight sync.WaitGroup
+	defer func() {
+		inFlight.Wait()
+		channel.cancel()
+		close(drained)
+	}()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
+		case <-lost:
+			c.log.Warn(ctx, types.ActionMessageProcessingFailed, "consumer channel lost, interrupting orders in progress", "queue", queueName)
+			channel.cancel()
+			channel.wg.Wait()
+			channel = newChannelHandlers(handlerCtx)
 		case msg, ok := <-msgs:
 			if !ok {
 				c.log.Debug(ctx, "order_consumer_stop", "stopped consumimg messages")
-				return nil
+				// Not nil if the client gave up reconnecting.
+				return c.client.Err()
 			}
 
-			req, err := ToInternalOrder(msg.Body)
-			if err != nil {
-				msg.Nack(false, false)
-				c.log.Error(ctx, types.ActionValidationFailed, "failed to validate message", err)
-				continue
+			// Waiting for a free slot, the message stays unacknowledged and is redelivered if we stop.
+			if err := c.slots.AcquireContext(ctx); err != nil {
+				msg.Nack(false, true)
+				return nil
 			}
 
-			// request_id logging
-			if len(req.RequestID) != 0 {
-				ctx = logger.WithRequestID(ctx, req.RequestID)
-			}
+			inFlight.Add(1)
+			channel.wg.Add(1)
+			go func(msg amqp.Delivery, channel *channelHandlers) {
+				defer func() {
+					c.slots.Release()
+					channel.wg.Done()
+					inFlight.Done()
+				}()
 
-			order := FromPublishToInternalOrder(req)
-			if order == nil {
-				msg.Nack(false, false)
-				c.log.Error(ctx, types.ActionValidationFailed, "failed to validate message", err)
-				continue
-			}
+				c.handle(channel.ctx, msg, handler)
+			}(msg, channel)
+		}
+	}
+}
 
-			if err := handler(ctx, order); err != nil {
-				if isRecoverableError(err) {
-					msg.Nack(false, true) // Requeue
-				} else {
-					msg.Nack(false, false) // Sending to DLQ
-				}
+// channelHandlers are handlers of messages received on one consumer channel.
+type channelHandlers struct {
+	ctx    context.Context
+	cancel context.CancelFunc // interrupts handlers when the channel is lost
+	wg     sync.WaitGroup
+}
 
-				c.log.Error(ctx, types.ActionMessageProcessingFailed, "failed to handle message", err, "requeue", isRecoverableError(err))
-				continue
-			}
-			msg.Ack(false)
+func newChannelHandlers(ctx context.Context) *channelHandlers {
+	ctx, cancel := context.WithCancel(ctx)
+	return &channelHandlers{ctx: ctx, cancel: cancel}
+}
+
+// SetPrefetch changes prefetch count of running and future consumers, it is still raised
+// to the number of slots.
+func (c *OrderConsumer) SetPrefetch(prefetchCount int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.prefetchCount = prefetchCount
+	prefetch := max(prefetchCount, c.concurrency)
+
+	for qos := range c.qos {
+		// Replacing update which wasn't applied yet.
+		select {
+		case <-qos:
+		default:
 		}
+		qos <- prefetch
 	}
 }
 
-func (r *OrderConsumer) reconnect(ctx context.Context) error {
-	fn := func() error {
-		conn, err := rabbit.New(ctx, r.cfg.Conn, r.log)
-		if err != nil {
-			return err
-		}
-		r.client = conn
+// declareQueue declares queue of the order type if it's not declared yet.
+func (c *OrderConsumer) declareQueue(ctx context.Context, orderType string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
+	if slices.Contains(c.orderTypes, orderType) {
 		return nil
 	}
 
-	if err := retry(ctx, r.cfg.ReconnectAttempt, r.cfg.ReconnectDelay, fn); err != nil {
-		return fmt.Errorf("failed to recconect rabbitMQ: %w", err)
+	if err := InitQueuesForOrderTypes(ctx, c.client, c.exchangeOrder, []string{orderType}); err != nil {
+		return err
 	}
+	c.orderTypes = append(c.orderTypes, orderType)
 
 	return nil
 }
 
-func (r *OrderConsumer) Close(ctx context.Context) error {
-	if r.client == nil || r.client.IsConnectionClosed() {
-		return nil
+// handle decodes the message, calls handler and acknowledges the message.
+func (c *OrderConsumer) handle(ctx context.Context, msg amqp.Delivery, handler func(ctx context.Context, req *models.CreateOrder) error) {
+	// Message of unsupported version or invalid one never becomes valid, it goes to DLQ.
+	req, err := DecodeOrder(msg)
+	if err != nil {
+		msg.Nack(false, false)
+		c.log.Error(ctx, types.ActionValidationFailed, "failed to validate message", err)
+		return
+	}
+
+	// request_id logging
+	if len(req.RequestID) != 0 {
+		ctx = logger.WithRequestID(ctx, req.RequestID)
+	}
+
+	order := FromPublishToInternalOrder(req)
+	if order == nil {
+		msg.Nack(false, false)
+		c.log.Error(ctx, types.ActionValidationFailed, "failed to validate message", err)
+		return
 	}
 
-	return r.client.Close(ctx)
+	if err := handler(ctx, order); err != nil {
+		if isRecoverableError(err) {
+			msg.Nack(false, true) // Requeue
+		} else {
+			msg.Nack(false, false) // Sending to DLQ
+		}
+
+		c.log.Error(ctx, types.ActionMessageProcessingFailed, "failed to handle message", err, "requeue", isRecoverableError(err))
+		return
+	}
+
+	if err := msg.Ack(false); err != nil {
+		// Channel was lost while cooking, the broker redelivers the message.
+		c.log.Error(ctx, types.ActionMessageProcessingFailed, "failed to acknowledge message", err, "order-number", order.Number)
+	}
 }
 
 // - If any processing step fails (e.g., database unavailable), the worker must negatively acknowledge the
@@ -179,5 +275,7 @@ func (r *OrderConsumer) Close(ctx context.Context) error {
 
 // isRecoverableError returns true if the provided error must be requeued
 func isRecoverableError(err error) bool {
-	return errors.Is(err, kitchen.ErrNilOrder)
+	return errors.Is(err, kitchen.ErrNilOrder) ||
+		errors.Is(err, kitchen.ErrWorkerStopping) ||
+		errors.Is(err, kitchen.ErrCookingInterrupted)
 }
@@ -25,29 +25,19 @@ type OrderProducer struct {
 	log logger.Logger
 }
 
-func NewOrderProducer(ctx context.Context, cfg config.RabbitMQ, log logger.Logger) (*OrderProducer, error) {
-	// RabbitMQ connection
-	client, err := rabbit.New(ctx, cfg.Conn, log)
-	if err != nil {
-		log.Error(ctx, types.ActionRabbitConnectionFailed, "failed to connect RabbitMQ", err)
-		return nil, err
-	}
-
+// NewOrderProducer creates order producer on the shared RabbitMQ client.
+func NewOrderProducer(ctx context.Context, client *rabbit.RabbitMQ, cfg config.RabbitMQ, log logger.Logger) (*OrderProducer, error) {
 	// Creating exchange.
-	if err := client.Channel.ExchangeDeclare(
-		cfg.OrderExchange,
-		"topic", // exchange type of 'topic'
-		true,
-		false,
-		false,
-		false,
-		nil,
-	); err != nil {
-		return nil, fmt.Errorf("failed to declare exchange: %w", err)
+	if err := client.DeclareExchange(ctx, rabbit.Exchange{
+		Name:    cfg.OrderExchange,
+		Kind:    "topic", // exchange type of 'topic'
+		Durable: true,
+	}); err != nil {
+		return nil, err
 	}
 
 	// creating all possible queues and binding them to order exchange.
-	if err := InitQueuesForOrderTypes(client, cfg.OrderExchange, types.AllOrderTypes); err != nil {
+	if err := InitQueuesForOrderTypes(ctx, client, cfg.OrderExchange, types.AllOrderTypes); err != nil {
 		return nil, fmt.Errorf("failed to init order queues: %w", err)
 	}
 
@@ -61,17 +51,12 @@ func NewOrderProducer(ctx context.Context, cfg config.RabbitMQ, log logger.Logge
 
 // PublishCreateOrder publishes an order message to the orders_topic exchange
 func (r *OrderProducer) PublishCreateOrder(ctx context.Context, order *models.CreateOrder) error {
+	const op = "OrderProducer.PublishCreateOrder"
+
 	if order == nil {
 		return errors.New("nil order")
 	}
 
-	if r.client.IsConnectionClosed() {
-		r.log.Debug(ctx, types.ActionRabbitReconnect, "trying to recconect to RabbitMQ")
-		if err := r.reconnect(ctx); err != nil {
-			return err
-		}
-	}
-
 	// Marshal order to JSON
 	body, err := json.Marshal(FromInternalToPublishOrder(ctx, order))
 	if err != nil {
@@ -84,51 +69,73 @@ func (r *OrderProducer) PublishCreateOrder(ctx context.Context, order *models.Cr
 	// Create the message with persistent delivery mode
 	msg := amqp091.Publishing{
 		ContentType:  "application/json",
+		Headers:      schemaHeaders(orderSchemaVersion),
 		DeliveryMode: amqp091.Persistent, // Persistent message (2). Means rabbitmq will store message in the disc.
 		Priority:     uint8(order.Priority),
 		Timestamp:    time.Now(),
 		Body:         body,
 	}
 
-	// Publish to the orders_topic exchange
-	err = r.client.Channel.PublishWithContext(
+	// Publish to the orders_topic exchange and wait for broker confirmation.
+	// Unroutable order (no queue bound for its type) is returned by broker and treated as failure.
+	err = r.client.PublishConfirmed(
 		ctx,
 		r.exchangeOrder, // exchange name
 		routingKey,
-		true,  // mandatory
-		false, // immediate
+		true, // mandatory
 		msg,
+		r.cfg.ConfirmTimeout,
 	)
 	if err != nil {
 		r.log.Error(ctx, types.ActionRabbitMQPublishFailed, "failed to publish order", err)
-		return fmt.Errorf("failed to publish order: %w", err)
+		return publishError(op, fmt.Errorf("failed to publish order: %w", err))
 	}
 
 	return nil
 }
 
-func (r *OrderProducer) reconnect(ctx context.Context) error {
-	fn := func() error {
-		conn, err := rabbit.New(ctx, r.cfg.Conn, r.log)
+// PublishCreateOrders publishes orders in a batch and waits for broker confirmations of all of them.
+// Returns error of every order by its index, nil if the order was published.
+func (r *OrderProducer) PublishCreateOrders(ctx context.Context, orders []*models.CreateOrder) ([]error, error) {
+	const op = "OrderProducer.PublishCreateOrders"
+
+	errs := make([]error, len(orders))
+	batch := make([]rabbit.Publishing, 0, len(orders))
+	indexes := make([]int, 0, len(orders)) // batch index -> order index
+
+	for i, order := range orders {
+		body, err := json.Marshal(FromInternalToPublishOrder(ctx, order))
 		if err != nil {
-			return err
+			errs[i] = fmt.Errorf("failed to marshal order: %w", err)
+			continue
 		}
-		r.client = conn
 
-		return nil
+		batch = append(batch, rabbit.Publishing{
+			Key: createOrderPublishedKey(order),
+			Msg: amqp091.Publishing{
+				ContentType:  "application/json",
+				Headers:      schemaHeaders(orderSchemaVersion),
+				DeliveryMode: amqp091.Persistent,
+				Priority:     uint8(order.Priority),
+				Timestamp:    time.Now(),
+				Body:         body,
+			},
+		})
+		indexes = append(indexes, i)
 	}
 
-	if err := retry(ctx, r.cfg.ReconnectAttempt, r.cfg.ReconnectDelay, fn); err != nil {
-		return fmt.Errorf("failed to recconect rabbitMQ: %w", err)
+	published, err := r.client.PublishConfirmedBatch(ctx, r.exchangeOrder, true, batch, r.cfg.ConfirmTimeout)
+	if err != nil {
+		r.log.Error(ctx, types.ActionRabbitMQPublishFailed, "failed to publish orders", err)
+		return nil, publishError(op, fmt.Errorf("failed to publish orders: %w", err))
 	}
 
-	return nil
-}
-
-func (r *OrderProducer) Close(ctx context.Context) error {
-	if r.client == nil {
-		return nil
+	for j, err := range published {
+		if err != nil {
+			r.log.Error(ctx, types.ActionRabbitMQPublishFailed, "failed to publish order", err, "order-number", orders[indexes[j]].Number)
+			errs[indexes[j]] = publishError(op, fmt.Errorf("failed to publish order: %w", err))
+		}
 	}
 
-	return r.client.Close(ctx)
+	return errs, nil
 }
@@ -0,0 +1,66 @@
+package rabbit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"wheres-my-pizza/internal/domain/models"
+	"wheres-my-pizza/pkg/rabbit"
+)
+
+// QueueInspector reads state of kitchen queues.
+type QueueInspector struct {
+	client *rabbit.RabbitMQ
+}
+
+func NewQueueInspector(client *rabbit.RabbitMQ) *QueueInspector {
+	return &QueueInspector{
+		client: client,
+	}
+}
+
+// InspectOrderType returns state of the kitchen queue of the order type and its DLQ.
+func (i *QueueInspector) InspectOrderType(ctx context.Context, orderType string) (models.QueueState, models.QueueState, error) {
+	queueName := getQueueByOrderType(orderType)
+
+	queue, err := i.inspect(ctx, queueName)
+	if err != nil {
+		return models.QueueState{}, models.QueueState{}, err
+	}
+
+	dlq, err := i.inspect(ctx, getDLQKeyForQueue(queueName))
+	if err != nil {
+		return models.QueueState{}, models.QueueState{}, err
+	}
+
+	return queue, dlq, nil
+}
+
+func (i *QueueInspector) inspect(ctx context.Context, name string) (models.QueueState, error) {
+	stats, err := i.client.InspectQueue(ctx, name)
+	if err != nil {
+		// Queue is declared by the first producer or worker, until then it is just empty.
+		var amqpErr *amqp.Error
+		if errors.As(err, &amqpErr) && amqpErr.Code == amqp.NotFound {
+			return models.QueueState{Name: name}, nil
+		}
+		return models.QueueState{}, err
+	}
+
+	state := models.QueueState{
+		Name:      stats.Name,
+		Ready:     stats.Ready,
+		Unacked:   stats.Unacked,
+		Consumers: stats.Consumers,
+	}
+
+	if stats.HeadTimestamp != nil {
+		age := time.Since(*stats.HeadTimestamp).Seconds()
+		state.OldestMessageAge = &age
+	}
+
+	return state, nil
+}
@@ -1,6 +1,7 @@
 package rabbit
 
 import (
+	"context"
 	"fmt"
 
 	"wheres-my-pizza/internal/domain/models"
@@ -9,24 +10,22 @@ import (
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
+// dlxExchange is the Dead Letter exchange for all order queues.
+const dlxExchange = "dlx_exchange"
+
 // Creates queues for each type of order and binds them to the given exchange.
 // Also creates Dead Letter Exchange(DLX) and alongside with DLQ for each orderType and bind each the queue to that DLQ.
-func InitQueuesForOrderTypes(client *rabbit.RabbitMQ, exchange string, orderTypes []string) error {
+// Everything is registered in the client topology, so it is declared again after reconnect.
+func InitQueuesForOrderTypes(ctx context.Context, client *rabbit.RabbitMQ, exchange string, orderTypes []string) error {
 	// > Dead Letter Queue (DLQ) is a specialized queue that stores messages that cannot be delivered or processed by
 	// their intended queue. It acts as a safety net, preventing failed messages from being lost and allowing for
 	// inspection, troubleshooting, and potential reprocessing.
 	// Creating Dead Letter exchange
-	dlxExchange := "dlx_exchange"
-	err := client.Channel.ExchangeDeclare(
-		dlxExchange,
-		"topic",
-		true,  // durable
-		false, // autoDelete
-		false, // internal
-		false, // noWait
-		nil,
-	)
-	if err != nil {
+	if err := client.DeclareExchange(ctx, rabbit.Exchange{
+		Name:    dlxExchange,
+		Kind:    "topic",
+		Durable: true,
+	}); err != nil {
 		return fmt.Errorf("failed to declare DLX exchange: %w", err)
 	}
 
@@ -37,58 +36,41 @@ func InitQueuesForOrderTypes(client *rabbit.RabbitMQ, exchange string, orderType
 		// DLQ name
 		deadLQueue := getDLQKeyForQueue(queueName)
 
-		// arguments for the queue
-		args := amqp.Table{
-			"x-dead-letter-exchange":    dlxExchange,
-			"x-dead-letter-routing-key": deadLQueue,
-		}
-
-		// Creating new queue
-		_, err := client.Channel.QueueDeclare(
-			queueName,
-			true,
-			false,
-			false,
-			false,
-			args,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to declare queue %s: %w", queueName, err)
+		// Creating new queue with arguments for dead lettering
+		if _, err := client.DeclareQueue(ctx, rabbit.Queue{
+			Name:    queueName,
+			Durable: true,
+			Args: amqp.Table{
+				"x-dead-letter-exchange":    dlxExchange,
+				"x-dead-letter-routing-key": deadLQueue,
+			},
+		}); err != nil {
+			return err
 		}
 
 		// Binding queue to the exchange
-		bindingKey := getRoutingkeyByOrderType(ot)
-		if err := client.Channel.QueueBind(
-			queueName,
-			bindingKey,
-			exchange,
-			false,
-			nil,
-		); err != nil {
-			return fmt.Errorf("failed to bind queue %s: %w", queueName, err)
+		if err := client.BindQueue(ctx, rabbit.Binding{
+			Queue:    queueName,
+			Key:      getRoutingkeyByOrderType(ot),
+			Exchange: exchange,
+		}); err != nil {
+			return err
 		}
 
 		// DLQ queue
-		_, err = client.Channel.QueueDeclare(
-			deadLQueue,
-			true,  // durable
-			false, // autoDelete
-			false, // exclusive
-			false, // noWait
-			nil,
-		)
-		if err != nil {
+		if _, err := client.DeclareQueue(ctx, rabbit.Queue{
+			Name:    deadLQueue,
+			Durable: true,
+		}); err != nil {
 			return fmt.Errorf("failed to declare DLQ: %w", err)
 		}
 
 		// binding DLQ to the DLX
-		if err := client.Channel.QueueBind(
-			deadLQueue,
-			getDLQRoutingKeyQueueName(queueName),
-			dlxExchange,
-			false,
-			nil,
-		); err != nil {
+		if err := client.BindQueue(ctx, rabbit.Binding{
+			Queue:    deadLQueue,
+			Key:      getDLQRoutingKeyQueueName(queueName),
+			Exchange: dlxExchange,
+		}); err != nil {
 			return fmt.Errorf("failed to bind DLQ: %w", err)
 		}
 	}
@@ -0,0 +1,191 @@
+package rabbit
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"wheres-my-pizza/pkg/schema"
+)
+
+// Current versions of the messages. A message has its version in the schema_version header and body
+// field. Version 1 is the message published before messages were versioned.
+//
+// Changing a message: freeze its current type as orderVN/statusUpdateVN, register it with an upcaster
+// and an example, then bump the version. schema_test.go checks that examples of all versions decode
+// to the same message. Consumers must be deployed before producers.
+const (
+	orderSchemaVersion        = 2
+	statusUpdateSchemaVersion = 2
+)
+
+var (
+	orderSchema        = newOrderSchema()
+	statusUpdateSchema = newStatusUpdateSchema()
+)
+
+// schemaHeaders returns headers of a message of the version.
+func schemaHeaders(version int) amqp.Table {
+	return amqp.Table{schema.Field: int32(version)}
+}
+
+// headerVersion returns version from the schema_version header, 0 if there is no header.
+func headerVersion(headers amqp.Table) (int, error) {
+	switch v := headers[schema.Field].(type) {
+	case nil:
+		return 0, nil
+	case int8:
+		return int(v), nil
+	case uint8:
+		return int(v), nil
+	case int16:
+		return int(v), nil
+	case uint16:
+		return int(v), nil
+	case int32:
+		return int(v), nil
+	case uint32:
+		return int(v), nil
+	case int64:
+		return int(v), nil
+	case int:
+		return v, nil
+	case string:
+		return strconv.Atoi(v)
+	default:
+		return 0, fmt.Errorf("invalid %s header of type %T", schema.Field, v)
+	}
+}
+
+// decodeMessage decodes message of the registry, version of the header takes precedence over
+// version of the body.
+func decodeMessage[T any](r *schema.Registry[T], msg amqp.Delivery) (T, error) {
+	version, err := headerVersion(msg.Headers)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return r.Decode(version, msg.Body)
+}
+
+// orderV1 is the order message without version.
+type orderV1 struct {
+	OrderNumber     string        `json:"order_number"`
+	CustomerName    string        `json:"customer_name"`
+	OrderType       string        `json:"order_type"`
+	TableNumber     *int          `json:"table_number,omitempty"`
+	DeliveryAddress *string       `json:"delivery_address,omitempty"`
+	Items           []orderItemV1 `json:"items"`
+	TotalAmount     float64       `json:"total_amount"`
+	Priority        int           `json:"priority"`
+	RequestID       string        `json:"request_id,omitempty"`
+}
+
+type orderItemV1 struct {
+	Name     string  `json:"name"`
+	Quantity int     `json:"quantity"`
+	Price    float64 `json:"price"`
+}
+
+// upcastOrderV1 converts v1 order to v2, v2 only adds version.
+func upcastOrderV1(m orderV1) Order {
+	items := make([]OrderItem, 0, len(m.Items))
+	for _, item := range m.Items {
+		items = append(items, OrderItem{
+			Name:     item.Name,
+			Quantity: item.Quantity,
+			Price:    item.Price,
+		})
+	}
+
+	return Order{
+		SchemaVersion:   2,
+		OrderNumber:     m.OrderNumber,
+		CustomerName:    m.CustomerName,
+		OrderType:       m.OrderType,
+		TableNumber:     m.TableNumber,
+		DeliveryAddress: m.DeliveryAddress,
+		Items:           items,
+		TotalAmount:     m.TotalAmount,
+		Priority:        m.Priority,
+		RequestID:       m.RequestID,
+	}
+}
+
+func newOrderSchema() *schema.Registry[Order] {
+	r := schema.NewRegistry[Order]("order", orderSchemaVersion, []byte(`{
+		"schema_version": 2,
+		"order_number": "ORD_20241216_001",
+		"customer_name": "John Doe",
+		"order_type": "delivery",
+		"delivery_address": "123 Main St, City",
+		"items": [{"name": "Margherita Pizza", "quantity": 2, "price": 15.99}],
+		"total_amount": 31.98,
+		"priority": 1,
+		"request_id": "9f1c2d"
+	}`))
+
+	schema.Register(r, 1, upcastOrderV1, []byte(`{
+		"order_number": "ORD_20241216_001",
+		"customer_name": "John Doe",
+		"order_type": "delivery",
+		"delivery_address": "123 Main St, City",
+		"items": [{"name": "Margherita Pizza", "quantity": 2, "price": 15.99}],
+		"total_amount": 31.98,
+		"priority": 1,
+		"request_id": "9f1c2d"
+	}`))
+
+	return r
+}
+
+// statusUpdateV1 is the status update message without version.
+type statusUpdateV1 struct {
+	OrderNumber string    `json:"order_number"`
+	OldStatus   string    `json:"old_status"`
+	NewStatus   string    `json:"new_status"`
+	ChangedBy   string    `json:"changed_by"`
+	Timestamp   time.Time `json:"timestamp"`
+	Completion  time.Time `json:"estimated_completion"`
+	RequestID   string    `json:"request_id"`
+}
+
+// upcastStatusUpdateV1 converts v1 status update to v2, v2 only adds version.
+func upcastStatusUpdateV1(m statusUpdateV1) StatusUpdate {
+	update := StatusUpdate{SchemaVersion: 2}
+	update.OrderNumber = m.OrderNumber
+	update.OldStatus = m.OldStatus
+	update.NewStatus = m.NewStatus
+	update.ChangedBy = m.ChangedBy
+	update.Timestamp = m.Timestamp
+	update.Completion = m.Completion
+	update.RequestID = m.RequestID
+	return update
+}
+
+func newStatusUpdateSchema() *schema.Registry[StatusUpdate] {
+	r := schema.NewRegistry[StatusUpdate]("status_update", statusUpdateSchemaVersion, []byte(`{
+		"schema_version": 2,
+		"order_number": "ORD_20241216_001",
+		"old_status": "received",
+		"new_status": "cooking",
+		"changed_by": "chef_mario",
+		"timestamp": "2024-12-16T10:32:00Z",
+		"estimated_completion": "2024-12-16T10:44:00Z",
+		"request_id": "9f1c2d"
+	}`))
+
+	schema.Register(r, 1, upcastStatusUpdateV1, []byte(`{
+		"order_number": "ORD_20241216_001",
+		"old_status": "received",
+		"new_status": "cooking",
+		"changed_by": "chef_mario",
+		"timestamp": "2024-12-16T10:32:00Z",
+		"estimated_completion": "2024-12-16T10:44:00Z",
+		"request_id": "9f1c2d"
+	}`))
+
+	return r
+}
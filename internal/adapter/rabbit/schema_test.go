@@ -0,0 +1,158 @@
+package rabbit
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"wheres-my-pizza/internal/domain/models"
+)
+
+func TestSchemasCompatible(t *testing.T) {
+	if err := orderSchema.Check(); err != nil {
+		t.Errorf("order: %v", err)
+	}
+	if err := statusUpdateSchema.Check(); err != nil {
+		t.Errorf("status update: %v", err)
+	}
+}
+
+// v1 messages are published by producers before versioning: no header and no version field.
+func TestDecodeOrderV1(t *testing.T) {
+	table := 5
+	tests := []struct {
+		name string
+		body string
+		want Order
+	}{
+		{
+			name: "dine in",
+			body: `{"order_number":"ORD_20241216_002","customer_name":"Jane","order_type":"dine_in","table_number":5,"items":[{"name":"Caesar Salad","quantity":1,"price":8.99}],"total_amount":8.99,"priority":1}`,
+			want: Order{
+				SchemaVersion: orderSchemaVersion,
+				OrderNumber:   "ORD_20241216_002",
+				CustomerName:  "Jane",
+				OrderType:     "dine_in",
+				TableNumber:   &table,
+				Items:         []OrderItem{{Name: "Caesar Salad", Quantity: 1, Price: 8.99}},
+				TotalAmount:   8.99,
+				Priority:      1,
+			},
+		},
+		{
+			name: "takeout with request id",
+			body: `{"order_number":"ORD_20241216_003","customer_name":"Bob","order_type":"takeout","items":[],"total_amount":0,"priority":10,"request_id":"abc"}`,
+			want: Order{
+				SchemaVersion: orderSchemaVersion,
+				OrderNumber:   "ORD_20241216_003",
+				CustomerName:  "Bob",
+				OrderType:     "takeout",
+				Items:         []OrderItem{},
+				Priority:      10,
+				RequestID:     "abc",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeOrder(amqp.Delivery{Body: []byte(tt.body)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("DecodeOrder() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeOrderInvalid(t *testing.T) {
+	tests := map[string]amqp.Delivery{
+		"unknown field":   {Body: []byte(`{"order_number":"a","customer_name":"b","order_type":"takeout","items":[],"total_amount":0,"priority":1,"extra":1}`)},
+		"missing field":   {Body: []byte(`{"order_number":"a","customer_name":"b","order_type":"takeout","items":[],"priority":1}`)},
+		"missing item":    {Body: []byte(`{"order_number":"a","customer_name":"b","order_type":"takeout","items":[{"name":"x","price":1}],"total_amount":1,"priority":1}`)},
+		"future version":  {Headers: amqp.Table{"schema_version": int32(orderSchemaVersion + 1)}, Body: []byte(`{}`)},
+		"invalid header":  {Headers: amqp.Table{"schema_version": 1.5}, Body: []byte(`{}`)},
+		"version of body": {Headers: schemaHeaders(1), Body: []byte(`{"schema_version":2}`)},
+	}
+
+	for name, msg := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := DecodeOrder(msg); err == nil {
+				t.Error("error expected")
+			}
+		})
+	}
+}
+
+// Messages of current producers are decoded back to what was published.
+func TestOrderRoundTrip(t *testing.T) {
+	address := "123 Main St"
+	order := &models.CreateOrder{
+		Number:          "ORD_20241216_004",
+		CustomerName:    "John",
+		Type:            "delivery",
+		DeliveryAddress: &address,
+		Items:           []models.CreateOrderItem{{Name: "Pizza", Quantity: 2, Price: 15.99}},
+		TotalAmount:     31.98,
+		Priority:        5,
+	}
+
+	body, err := json.Marshal(FromInternalToPublishOrder(context.Background(), order))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecodeOrder(amqp.Delivery{Headers: schemaHeaders(orderSchemaVersion), Body: body})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if internal := FromPublishToInternalOrder(got); !reflect.DeepEqual(internal, order) {
+		t.Errorf("decoded %+v, want %+v", internal, order)
+	}
+}
+
+func TestDecodeStatusUpdate(t *testing.T) {
+	update := models.StatusUpdate{
+		OrderNumber: "ORD_20241216_001",
+		OldStatus:   "received",
+		NewStatus:   "cooking",
+		ChangedBy:   "chef_mario",
+		Timestamp:   time.Date(2024, 12, 16, 10, 32, 0, 0, time.UTC),
+		Completion:  time.Date(2024, 12, 16, 10, 44, 0, 0, time.UTC),
+		RequestID:   "9f1c2d",
+	}
+
+	// v1 producers published the model itself.
+	v1, err := json.Marshal(update)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, err := json.Marshal(StatusUpdate{SchemaVersion: statusUpdateSchemaVersion, StatusUpdate: update})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]amqp.Delivery{
+		"v1":                {Body: v1},
+		"v2":                {Headers: schemaHeaders(statusUpdateSchemaVersion), Body: v2},
+		"v2 without header": {Body: v2},
+	}
+
+	for name, msg := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := decodeStatusUpdate(msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, update) {
+				t.Errorf("decodeStatusUpdate() = %+v, want %+v", got, update)
+			}
+		})
+	}
+}
@@ -4,79 +4,257 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	svc "wheres-my-pizza/internal/app/services"
 	"wheres-my-pizza/internal/config"
 	"wheres-my-pizza/internal/domain/types"
 	"wheres-my-pizza/pkg/logger"
+	postgresclient "wheres-my-pizza/pkg/postgres"
+	rabbitclient "wheres-my-pizza/pkg/rabbit"
 )
 
 var ErrInvalidMode = errors.New("invalid mode")
 
+// Service is started by the supervisor. Start blocks until ctx is cancelled or the
+// service stops by itself, it closes everything the service opened before returning.
 type Service interface {
 	Start(ctx context.Context) error
 }
 
+// Reloader is a service which applies reloadable settings without restart, see config.Config.Reload.
+type Reloader interface {
+	Reload(ctx context.Context, cfg config.Config)
+}
+
 type App struct {
-	mode    types.ServiceMode
-	service Service
+	modes    []types.ServiceMode
+	services []runnable
+
+	// Postgres pool and RabbitMQ client shared by services, owned by the application
+	postgresDB *postgresclient.PostgreDB
+	rabbitMQ   *rabbitclient.RabbitMQ
 
 	cfg config.Config
 	log logger.Logger
 }
 
-// NewApplication
+// runnable is a service with its mode.
+type runnable struct {
+	mode    types.ServiceMode
+	service Service
+}
+
+// NewApplication creates services of the configured modes, they share the Postgres pool,
+// RabbitMQ client and logger.
 func NewApplication(ctx context.Context, cfg config.Config, log logger.Logger) (*App, error) {
+	modes := cfg.Modes
+	if len(modes) == 0 {
+		modes = []types.ServiceMode{cfg.Mode}
+	}
+
 	app := &App{
-		mode: cfg.Mode,
-		cfg:  cfg,
-		log:  log,
+		modes: modes,
+		cfg:   cfg,
+		log:   log,
 	}
 
-	if err := app.initService(ctx, app.mode); err != nil {
+	if err := app.initServices(ctx); err != nil {
+		app.close()
 		return nil, err
 	}
 
 	return app, nil
 }
 
+// Run runs services until shutdown signal or failure of any of them, see supervise.
 func (app *App) Run(ctx context.Context) error {
-	if app.service == nil {
-		if err := app.initService(ctx, app.mode); err != nil {
+	if len(app.services) == 0 {
+		if err := app.initServices(ctx); err != nil {
 			return err
 		}
 	}
+	defer app.close()
 
-	if err := app.service.Start(ctx); err != nil {
-		return err
+	return app.supervise(ctx)
+}
+
+func (app *App) initServices(ctx context.Context) error {
+	if app.needsPostgres() && app.postgresDB == nil {
+		db, err := postgresclient.New(ctx, app.cfg.Postgres)
+		if err != nil {
+			app.log.Error(ctx, types.ActionDBConnectionFailed, "failed to connect postgres", err)
+			return fmt.Errorf("failed to connect postgres: %w", err)
+		}
+		app.log.Info(ctx, types.ActionDBConnected, "connected to the database")
+		app.postgresDB = db
+
+		if app.cfg.Migrate.Auto && !slices.Contains(app.modes, types.ModeMigrate) {
+			if err := app.migrate(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	if app.needsRabbitMQ() && app.rabbitMQ == nil {
+		rabbitMQ, err := rabbitclient.New(ctx, app.cfg.RabbitMQ.Conn, app.log)
+		if err != nil {
+			app.log.Error(ctx, types.ActionRabbitConnectionFailed, "failed to connect rabbitmq", err)
+			return fmt.Errorf("failed to connect rabbitmq: %w", err)
+		}
+		app.rabbitMQ = rabbitMQ
+	}
+
+	for _, mode := range app.modes {
+		service, err := app.initService(ctx, mode)
+		if err != nil {
+			return err
+		}
+		app.services = append(app.services, runnable{mode: mode, service: service})
 	}
 
 	return nil
 }
 
-func (app *App) initService(ctx context.Context, mode types.ServiceMode) error {
+func (app *App) initService(ctx context.Context, mode types.ServiceMode) (Service, error) {
 	var (
 		service Service
 		err     error
 	)
+
+	cfg := app.cfg.ForMode(mode)
 	switch mode {
 	case types.ModeOrder:
-		service, err = svc.NewOrder(ctx, app.cfg, app.log)
+		service, err = svc.NewOrder(ctx, cfg, app.postgresDB, app.rabbitMQ, app.log)
 	case types.ModeKitchenWorker:
-		service, err = svc.NewKitchen(ctx, app.cfg, app.log)
+		service, err = svc.NewKitchen(ctx, cfg, app.postgresDB, app.rabbitMQ, app.log)
 	case types.ModeTracking:
-		service, err = svc.NewTracking(ctx, app.cfg, app.log)
+		service, err = svc.NewTracking(ctx, cfg, app.postgresDB, app.rabbitMQ, app.log)
 	case types.ModeNotificationSubscriber:
-		service, err = svc.NewNotificationSubscriber(ctx, app.cfg, app.log)
+		service, err = svc.NewNotificationSubscriber(ctx, cfg, app.rabbitMQ, app.log)
+	case types.ModeMigrate:
+		service, err = svc.NewMigrator(ctx, cfg, app.postgresDB, app.log)
 	default:
-		return ErrInvalidMode
+		return nil, ErrInvalidMode
 	}
 
 	if err != nil {
-		return fmt.Errorf("failed to init service: %w", err)
+		return nil, fmt.Errorf("failed to init %s: %w", mode, err)
 	}
 
-	app.service = service
+	return service, nil
+}
+
+// migrate applies pending migrations before services are created, replicas starting
+// at the same time wait for each other on the migration lock.
+func (app *App) migrate(ctx context.Context) error {
+	migrator, err := svc.NewMigrator(ctx, app.cfg, app.postgresDB, app.log)
+	if err != nil {
+		return err
+	}
+
+	if err := migrator.Up(ctx); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
 
 	return nil
 }
+
+// reload loads config again and applies its reloadable settings to the logger and services.
+// Changes of other settings are logged and ignored, invalid config is not applied at all.
+func (app *App) reload(ctx context.Context) {
+	cfg, changes, err := app.cfg.Reload()
+	if err != nil {
+		app.log.Error(ctx, types.ActionConfigReloadFailed, "failed to reload config, keeping current one", err)
+		return
+	}
+
+	var applied []string
+	for _, change := range changes {
+		if !change.Reloadable {
+			app.log.Warn(ctx, types.ActionConfigReloadRejected, "setting can't be changed without restart, ignored",
+				"setting", change.Path, "current", change.Old, "new", change.New)
+			continue
+		}
+		applied = append(applied, change.String())
+	}
+	if len(applied) == 0 {
+		app.log.Info(ctx, types.ActionConfigReloaded, "config reloaded, nothing to apply")
+		return
+	}
+
+	app.setLogLevels(ctx, cfg)
+	if app.rabbitMQ != nil {
+		app.rabbitMQ.SetReconnect(cfg.RabbitMQ.Conn)
+	}
+	for _, r := range app.services {
+		if reloader, ok := r.service.(Reloader); ok {
+			reloader.Reload(ctx, cfg.ForMode(r.mode))
+		}
+	}
+	app.cfg = *cfg
+
+	app.log.Info(ctx, types.ActionConfigReloaded, "config reloaded", "changes", applied)
+}
+
+// setLogLevels applies changed levels of cfg, levels set with the admin endpoint are kept
+// unless they are changed in the config too.
+func (app *App) setLogLevels(ctx context.Context, cfg *config.Config) {
+	var errs []error
+	if cfg.LogLevel != app.cfg.LogLevel {
+		errs = append(errs, app.log.SetLevel(cfg.LogLevel))
+	}
+
+	for component := range app.cfg.Log.Components {
+		if _, ok := cfg.Log.Components[component]; !ok {
+			errs = append(errs, app.log.SetComponentLevel(component, ""))
+		}
+	}
+	for component, lvl := range cfg.Log.Components {
+		if app.cfg.Log.Components[component] != lvl {
+			errs = append(errs, app.log.SetComponentLevel(component, lvl))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		app.log.Error(ctx, types.ActionConfigReloadFailed, "failed to change log levels", err)
+	}
+}
+
+// needsPostgres reports whether any of the services uses the database.
+func (app *App) needsPostgres() bool {
+	for _, mode := range app.modes {
+		if mode != types.ModeNotificationSubscriber {
+			return true
+		}
+	}
+	return false
+}
+
+// needsRabbitMQ reports whether any of the services uses the broker.
+func (app *App) needsRabbitMQ() bool {
+	for _, mode := range app.modes {
+		if mode != types.ModeMigrate {
+			return true
+		}
+	}
+	return false
+}
+
+// close closes resources shared by services, after all of them are stopped.
+func (app *App) close() {
+	if app.rabbitMQ != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+		defer cancel()
+
+		if err := app.rabbitMQ.Close(ctx); err != nil {
+			app.log.Error(ctx, types.ActionGracefulShutdown, "failed to close rabbitmq connection", err)
+		}
+		app.rabbitMQ = nil
+	}
+	if app.postgresDB != nil {
+		app.postgresDB.Pool.Close()
+		app.postgresDB = nil
+	}
+}
@@ -4,10 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
-	"os/signal"
 	"strings"
-	"syscall"
 	"time"
 	"unicode/utf8"
 
@@ -18,6 +15,7 @@ import (
 	"wheres-my-pizza/internal/services/kitchen"
 	"wheres-my-pizza/pkg/logger"
 	postgresclient "wheres-my-pizza/pkg/postgres"
+	rabbitclient "wheres-my-pizza/pkg/rabbit"
 )
 
 var (
@@ -25,12 +23,13 @@ var (
 	ErrDuplicateOrderType = fmt.Errorf("duplicate order type found")
 	ErrInvalidOrderType   = errors.New("invalid order type. must be Comma-separated list of order types the worker can handle (e.g., dine_in,takeout)")
 
-	ErrInvalidHeartbeatInterval = errors.New("heartbeat interval must be at least 5 seconds")
+	ErrInvalidConcurrency = errors.New("concurrency must be between 1 and 100")
 )
 
 type KitchenWorker interface {
 	Work(ctx context.Context, errCh chan<- error)
 	Stop(ctx context.Context)
+	SetHeartbeat(interval time.Duration)
 }
 
 // Feature: Order Service
@@ -39,7 +38,7 @@ type KitchenWorker interface {
 // processing engine of the restaurant. Multiple worker instances can run concurrently to handle
 // high order volumes and can be specialized to process specific types of orders.
 type KitchenService struct {
-	postgresDB    *postgresclient.PostgreDB
+	rabbitMQ      *rabbitclient.RabbitMQ
 	kitchenWorker KitchenWorker
 	consumer      *rabbit.OrderConsumer
 	producer      *rabbit.NotificationProducer
@@ -48,7 +47,7 @@ type KitchenService struct {
 	log logger.Logger
 }
 
-func NewKitchen(ctx context.Context, cfg config.Config, log logger.Logger) (*KitchenService, error) {
+func NewKitchen(ctx context.Context, cfg config.Config, db *postgresclient.PostgreDB, rabbitMQ *rabbitclient.RabbitMQ, log logger.Logger) (*KitchenService, error) {
 	// Validating worker name
 	if err := validateWorkerName(cfg.Services.Kitchen.WorkerName); err != nil {
 		log.Error(ctx, types.ActionValidationFailed, "failed to vailidate worker name", err)
@@ -61,29 +60,23 @@ func NewKitchen(ctx context.Context, cfg config.Config, log logger.Logger) (*Kit
 		return nil, fmt.Errorf("failed to vailidate provided order types: %w", err)
 	}
 
-	// validate heartbeat interval
+	// heartbeat interval is validated with the config
 	heartbeatDuration := time.Duration(cfg.Services.Kitchen.HeartbeatInterval) * time.Second
-	if heartbeatDuration <= time.Second*5 {
-		return nil, ErrInvalidHeartbeatInterval
-	}
 
-	// Postgres database connection
-	db, err := postgresclient.New(ctx, cfg.Postgres)
-	if err != nil {
-		log.Error(ctx, types.ActionDBConnectionFailed, "failed to connect postgres", err)
-		return nil, fmt.Errorf("failed to connect postgres: %v", err)
+	// validate number of orders cooked in parallel
+	concurrency := cfg.Services.Kitchen.Concurrency
+	if concurrency < 1 || concurrency > 100 {
+		return nil, ErrInvalidConcurrency
 	}
-	log.Info(ctx, types.ActionDBConnected, "connected to the database")
 
-	// RabbitMQ connection
 	// Initialize order consumer
-	consumer, err := rabbit.NewOrderConsumer(ctx, cfg.RabbitMQ, cfg.Services.Kitchen.Prefetch, validOrderTypes, log)
+	consumer, err := rabbit.NewOrderConsumer(ctx, rabbitMQ, cfg.RabbitMQ, cfg.Services.Kitchen.Prefetch, concurrency, validOrderTypes, log)
 	if err != nil {
 		log.Error(ctx, types.ActionRabbitConnectionFailed, "failed to create order consumer", err)
 		return nil, fmt.Errorf("failed to create order consumer: %w", err)
 	}
 	// Initialize notification producer
-	producer, err := rabbit.NewProducerNotify(ctx, cfg.RabbitMQ, log)
+	producer, err := rabbit.NewProducerNotify(ctx, rabbitMQ, cfg.RabbitMQ, log)
 	if err != nil {
 		log.Error(ctx, types.ActionRabbitConnectionFailed, "failed to create notification producer", err)
 		return nil, fmt.Errorf("failed to create notification producer: %w", err)
@@ -93,11 +86,30 @@ func NewKitchen(ctx context.Context, cfg config.Config, log logger.Logger) (*Kit
 	workerRepo := postgres.NewWorkerRepo(db.Pool)
 	orderRepo := postgres.NewOrderRepo(db.Pool)
 
+	// Kitchen cooks orders for estimated time, or until a cook marks them ready in manual mode
+	var cooker kitchen.Kitchen = kitchen.NewSimulatedKitchen()
+	if cfg.Services.Kitchen.Cooking.Mode == types.CookingModeManual {
+		cooker = kitchen.NewManualKitchen(orderRepo, cfg.Services.Kitchen.Cooking.PollInterval)
+	}
+
 	// Initialize kitchen-worker service
-	kitchenWorker := kitchen.NewWorker(workerRepo, orderRepo, consumer, producer, cfg.Services.Kitchen.WorkerName, validOrderTypes, heartbeatDuration, log)
+	kitchenWorker := kitchen.NewWorker(
+		workerRepo,
+		orderRepo,
+		consumer,
+		producer,
+		cooker,
+		cfg.Services.Kitchen.Cooking.Model(),
+		cfg.Services.Kitchen.WorkerName,
+		validOrderTypes,
+		concurrency,
+		heartbeatDuration,
+		cfg.Services.Kitchen.DrainTimeout,
+		log,
+	)
 
 	return &KitchenService{
-		postgresDB:    db,
+		rabbitMQ:      rabbitMQ,
 		kitchenWorker: kitchenWorker,
 		consumer:      consumer,
 		producer:      producer,
@@ -118,85 +130,45 @@ func (s *KitchenService) Start(ctx context.Context) error {
 	// kitchen worker starts to work in goroutine
 	go s.kitchenWorker.Work(ctx, errCh)
 
-	// Waiting signal
-	shutdownCh := make(chan os.Signal, 1)
-	signal.Notify(shutdownCh, syscall.SIGINT, syscall.SIGTERM)
+	// RabbitMQ client reconnects by itself, service stops only if it gave up. Either way
+	// the worker is drained in close, the same as on shutdown signal.
+	rabbitEvents := s.rabbitMQ.NotifyEvents(make(chan rabbitclient.Event, 1))
 
 	s.log.Info(ctx, types.ActionServiceStarted, "service started")
 
 	for {
 		select {
 		case <-ctx.Done():
-			s.log.Info(ctx, types.ActionGracefulShutdown, "context cancelled")
-			return ctx.Err()
+			s.log.Info(ctx, types.ActionGracefulShutdown, "shutting down service")
+			return nil
 		case errRun := <-errCh:
-			if errors.Is(errRun, kitchen.ErrWorkerStopped) {
-				if err := s.reconnect(ctx, shutdownCh, s.cfg.Services.Kitchen.ReconnectAttempt, s.cfg.Services.Kitchen.ReconnectDelay); err != nil {
-					return err
-				}
-				continue
+			if errors.Is(errRun, kitchen.ErrWorkerDrained) {
+				s.log.Info(ctx, types.ActionGracefulShutdown, "worker drained by command")
+				return nil
 			}
 			return errRun
-		case sig := <-shutdownCh:
-			s.log.Info(ctx, types.ActionGracefulShutdown, "shutting down application", "signal", sig.String())
-			return nil
+		case event := <-rabbitEvents:
+			if event.Type == rabbitclient.EventReconnectFailed {
+				return event.Err
+			}
 		}
 	}
 }
 
-// close stops worker and closes connections.
+// Reload applies reloadable settings, new prefetch count is set on consumer channels without
+// resubscribing.
+func (s *KitchenService) Reload(ctx context.Context, cfg config.Config) {
+	s.consumer.SetPrefetch(cfg.Services.Kitchen.Prefetch)
+	s.kitchenWorker.SetHeartbeat(time.Duration(cfg.Services.Kitchen.HeartbeatInterval) * time.Second)
+}
+
+// close drains worker, the connection is closed by the application after that.
 func (s *KitchenService) close(ctx context.Context) {
-	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	// Worker may take drain timeout to finish active orders.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.cfg.Services.Kitchen.DrainTimeout+time.Second*10)
 	defer cancel()
 
 	s.kitchenWorker.Stop(ctx)
-
-	if err := s.consumer.Close(ctx); err != nil {
-		s.log.Error(ctx, types.ActionGracefulShutdown, "failed to close rabbit connection", err)
-	}
-
-	if err := s.producer.Close(ctx); err != nil {
-		s.log.Error(ctx, types.ActionGracefulShutdown, "failed to close rabbit connection", err)
-	}
-
-	s.postgresDB.Pool.Close()
-}
-
-func (s *KitchenService) reconnect(ctx context.Context, shutdownCh chan os.Signal, attempts int, delay time.Duration) error {
-	var lastErr error
-
-	const action = "kitchen-create-attempt"
-	const failedAction = "kitchen-create-attempt-failed"
-
-	for i := 1; i <= attempts; i++ {
-		s.log.Info(ctx, action, fmt.Sprintf("attempt %d to recreate service", i))
-
-		newSvc, err := NewKitchen(ctx, s.cfg, s.log)
-		if err == nil {
-			// Closing old service
-			s.close(ctx)
-
-			*s = *newSvc
-
-			// Starting a new worker
-			go s.kitchenWorker.Work(ctx, make(chan error, 1))
-			return nil
-		}
-
-		lastErr = err
-		s.log.Error(ctx, failedAction, "failed to recreate kitchen service", err, "attempt", i)
-
-		select {
-		case <-shutdownCh:
-			s.log.Info(ctx, action, "reconnect was stopped externally")
-			return fmt.Errorf("reconnect stopped externally")
-		default:
-			time.Sleep(delay)
-		}
-	}
-
-	s.log.Warn(ctx, failedAction, "failed to recreate kitchen-worker", "total-attempts", attempts)
-	return lastErr
 }
 
 // ValidateOrderTypes handles all validation cases for the --order-types flag
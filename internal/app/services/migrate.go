@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"wheres-my-pizza/internal/config"
+	"wheres-my-pizza/internal/domain/types"
+	"wheres-my-pizza/migrations"
+	"wheres-my-pizza/pkg/logger"
+	"wheres-my-pizza/pkg/migrate"
+	postgresclient "wheres-my-pizza/pkg/postgres"
+)
+
+// ## Feature: Schema Migrations
+// Migrator applies SQL migrations embedded into the binary, so no external tool is needed
+// to prepare the database. In migrate mode it runs one command and exits, services apply
+// pending migrations on start when migrate.auto is set.
+type Migrator struct {
+	migrator *migrate.Migrator
+
+	cfg config.Config
+	log logger.Logger
+}
+
+func NewMigrator(ctx context.Context, cfg config.Config, db *postgresclient.PostgreDB, log logger.Logger) (*Migrator, error) {
+	migrator, err := migrate.New(db.Pool, migrations.FS)
+	if err != nil {
+		log.Error(ctx, types.ActionMigrationFailed, "failed to load migrations", err)
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	return &Migrator{
+		migrator: migrator,
+		cfg:      cfg,
+		log:      log,
+	}, nil
+}
+
+// Start runs the migrate command of the config.
+func (s *Migrator) Start(ctx context.Context) error {
+	switch s.cfg.Migrate.Command {
+	case types.MigrateDown:
+		return s.Down(ctx, s.cfg.Migrate.Arg)
+	case types.MigrateStatus:
+		return s.Status(ctx)
+	case types.MigrateForce:
+		return s.Force(ctx, s.cfg.Migrate.Arg)
+	default:
+		return s.Up(ctx)
+	}
+}
+
+// Up applies pending migrations.
+func (s *Migrator) Up(ctx context.Context) error {
+	applied, err := s.migrator.Up(ctx)
+	for _, m := range applied {
+		s.log.Info(ctx, types.ActionMigrationApplied, "migration applied", "version", m.Version, "name", m.Name)
+	}
+	if err != nil {
+		s.log.Error(ctx, types.ActionMigrationFailed, "failed to apply migrations", err)
+		return err
+	}
+
+	if len(applied) == 0 {
+		s.log.Info(ctx, types.ActionMigrationApplied, "schema is up to date")
+	}
+	return nil
+}
+
+// Down reverts steps latest migrations.
+func (s *Migrator) Down(ctx context.Context, steps int) error {
+	reverted, err := s.migrator.Down(ctx, steps)
+	for _, m := range reverted {
+		s.log.Info(ctx, types.ActionMigrationReverted, "migration reverted", "version", m.Version, "name", m.Name)
+	}
+	if err != nil {
+		s.log.Error(ctx, types.ActionMigrationFailed, "failed to revert migrations", err)
+		return err
+	}
+
+	return nil
+}
+
+// Force sets schema version without running migrations.
+func (s *Migrator) Force(ctx context.Context, version int) error {
+	if err := s.migrator.Force(ctx, version); err != nil {
+		s.log.Error(ctx, types.ActionMigrationFailed, "failed to force schema version", err, "version", version)
+		return err
+	}
+
+	s.log.Warn(ctx, types.ActionMigrationForced, "schema version forced", "version", version)
+	return nil
+}
+
+// Status prints applied version and migrations.
+func (s *Migrator) Status(ctx context.Context) error {
+	status, err := s.migrator.Status(ctx)
+	if err != nil {
+		s.log.Error(ctx, types.ActionMigrationFailed, "failed to read schema status", err)
+		return err
+	}
+
+	fmt.Printf("Schema version: %d (dirty: %t, pending: %d)\n", status.Version, status.Dirty, len(status.Pending()))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATE")
+	for _, m := range status.Migrations {
+		state := "pending"
+		if m.Version <= status.Version {
+			state = "applied"
+		}
+		fmt.Fprintf(w, "%06d\t%s\t%s\n", m.Version, m.Name, state)
+	}
+
+	return w.Flush()
+}
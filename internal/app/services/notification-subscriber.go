@@ -2,10 +2,6 @@ package services
 
 import (
 	"context"
-	"fmt"
-	"os"
-	"os/signal"
-	"syscall"
 
 	"wheres-my-pizza/internal/adapter/rabbit"
 	"wheres-my-pizza/internal/config"
@@ -33,13 +29,7 @@ type Service interface {
 	Close() error
 }
 
-func NewNotificationSubscriber(ctx context.Context, cfg config.Config, log logger.Logger) (*NotificationSubsriber, error) {
-	client, err := pkg.New(ctx, cfg.RabbitMQ.Conn, log)
-	if err != nil {
-		log.Error(ctx, "rabbit_connect", "failed to connect rabbitmq", err)
-		return nil, fmt.Errorf("failed to connect rabbitmq: %v", err)
-	}
-
+func NewNotificationSubscriber(ctx context.Context, cfg config.Config, client *pkg.RabbitMQ, log logger.Logger) (*NotificationSubsriber, error) {
 	reader := rabbit.NewNotificationSubscriber(client, cfg.RabbitMQ, log)
 	notifier := notification.NewNotifyPrinter(log)
 	service := notification.NewService(reader, notifier, log)
@@ -60,17 +50,13 @@ func (s *NotificationSubsriber) Start(ctx context.Context) error {
 	errCh := make(chan error, 1)
 	go s.service.Notify(ctx, errCh)
 
-	// Waiting signal
-	shutdownCh := make(chan os.Signal, 1)
-	signal.Notify(shutdownCh, syscall.SIGINT, syscall.SIGTERM)
-
 	s.log.Info(ctx, types.ActionServiceStarted, "service started")
 
 	select {
 	case errRun := <-errCh:
 		return errRun
-	case sig := <-shutdownCh:
-		s.log.Info(ctx, types.ActionGracefulShutdown, "shuting down application", "signal", sig.String())
+	case <-ctx.Done():
+		s.log.Info(ctx, types.ActionGracefulShutdown, "shutting down service")
 		return nil
 	}
 }
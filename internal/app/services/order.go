@@ -3,9 +3,6 @@ package services
 import (
 	"context"
 	"fmt"
-	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	httpserver "wheres-my-pizza/internal/adapter/http/server"
@@ -13,9 +10,12 @@ import (
 	"wheres-my-pizza/internal/adapter/rabbit"
 	"wheres-my-pizza/internal/config"
 	"wheres-my-pizza/internal/domain/types"
+	"wheres-my-pizza/internal/services/auth"
 	"wheres-my-pizza/internal/services/order"
 	"wheres-my-pizza/pkg/logger"
 	postgresclient "wheres-my-pizza/pkg/postgres"
+	rabbitclient "wheres-my-pizza/pkg/rabbit"
+	"wheres-my-pizza/pkg/ratelimit"
 	"wheres-my-pizza/pkg/semaphore"
 )
 
@@ -25,42 +25,62 @@ import (
 // them to a message queue for the kitchen staff to process. It acts as the gatekeeper, ensuring all incoming
 // data is correct and formatted before entering the system.
 type Order struct {
-	postgresDB *postgresclient.PostgreDB
-	httpServer *httpserver.API
-	producer   *rabbit.OrderProducer
+	rabbitMQ     *rabbitclient.RabbitMQ
+	httpServer   *httpserver.API
+	producer     *rabbit.OrderProducer
+	limiter      *ratelimit.Limiter
+	sem          *semaphore.Semaphore
+	orderService *order.Service
 
 	cfg config.Config
 	log logger.Logger
 }
 
-func NewOrder(ctx context.Context, cfg config.Config, log logger.Logger) (*Order, error) {
-	// Postgres database
-	db, err := postgresclient.New(ctx, cfg.Postgres)
-	if err != nil {
-		log.Error(ctx, "db_connect", "failed to connect postgres", err)
-		return nil, fmt.Errorf("failed to connect postgres: %v", err)
-	}
-	log.Info(ctx, types.ActionDBConnected, "connected to the database")
-
-	// RabbitMQ connection
+func NewOrder(ctx context.Context, cfg config.Config, db *postgresclient.PostgreDB, rabbitMQ *rabbitclient.RabbitMQ, log logger.Logger) (*Order, error) {
 	orderRepo := postgres.NewOrderRepo(db.Pool)
+	customerRepo := postgres.NewCustomerRepo(db.Pool)
 
-	producer, err := rabbit.NewOrderProducer(ctx, cfg.RabbitMQ, log)
+	producer, err := rabbit.NewOrderProducer(ctx, rabbitMQ, cfg.RabbitMQ, log)
 	if err != nil {
-		log.Error(ctx, types.ActionRabbitConnectionFailed, "failed to connect rabbitmq", err)
-		return nil, fmt.Errorf("failed to connect rabbitmq: %v", err)
+		log.Error(ctx, types.ActionRabbitConnectionFailed, "failed to create order producer", err)
+		return nil, fmt.Errorf("failed to create order producer: %w", err)
 	}
 
 	// Semaphore to control maximum number of concurrent orders to process.
 	sem := semaphore.NewSemaphore(cfg.Services.Order.MaxConcurrent)
 
-	orderService := order.NewService(cfg, orderRepo, producer, sem, time.Second, log)
+	orderService := order.NewService(cfg, orderRepo, customerRepo, producer, sem, log)
+
+	authService := auth.NewService(postgres.NewAPIKeyRepo(db.Pool), cfg.HTTPServer.Auth.AdminKey, log)
+
+	// Per-client rate limits, buckets in postgres are shared by replicas
+	var (
+		limiter    *ratelimit.Limiter
+		apiLimiter httpserver.RateLimiter
+	)
+	if rl := cfg.Services.Order.RateLimit; rl.Enabled {
+		defaultLimit, routes, err := rl.Limits()
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limits: %w", err)
+		}
 
-	api := httpserver.New(cfg, orderService, nil, log)
+		var store ratelimit.Store = ratelimit.NewMemoryStore()
+		if rl.Store == config.RateLimitStorePostgres {
+			store = postgres.NewRateLimitStore(db.Pool)
+		}
+
+		limiter = ratelimit.New(store, defaultLimit, routes)
+		apiLimiter = limiter
+	}
+
+	api := httpserver.New(cfg, orderService, nil, authService, apiLimiter, log)
 	return &Order{
-		postgresDB: db,
-		httpServer: api,
-		producer:   producer,
+		rabbitMQ:     rabbitMQ,
+		httpServer:   api,
+		producer:     producer,
+		limiter:      limiter,
+		sem:          sem,
+		orderService: orderService,
 
 		cfg: cfg,
 		log: log,
@@ -77,32 +97,64 @@ func (s *Order) Start(ctx context.Context) error {
 		s.log.Info(ctx, types.ActionGracefulShutdown, "order service closed")
 	}()
 
-	// Waiting signal
-	shutdownCh := make(chan os.Signal, 1)
-	signal.Notify(shutdownCh, syscall.SIGINT, syscall.SIGTERM)
+	// Cleanup is stopped before connections are closed.
+	cleanupCtx, stopCleanup := context.WithCancel(ctx)
+	defer stopCleanup()
+	if s.limiter != nil {
+		go s.cleanupRateLimits(cleanupCtx)
+	}
+
+	// RabbitMQ client reconnects by itself, service stops only if it gave up.
+	rabbitEvents := s.rabbitMQ.NotifyEvents(make(chan rabbitclient.Event, 1))
 
 	s.log.Info(ctx, types.ActionServiceStarted, "service started")
 
-	select {
-	case errRun := <-errCh:
-		return errRun
-	case sig := <-shutdownCh:
-		s.log.Info(ctx, types.ActionGracefulShutdown, "shuting down application", "signal", sig.String())
-		return nil
+	for {
+		select {
+		case errRun := <-errCh:
+			return errRun
+		case event := <-rabbitEvents:
+			if event.Type == rabbitclient.EventReconnectFailed {
+				return event.Err
+			}
+		case <-ctx.Done():
+			s.log.Info(ctx, types.ActionGracefulShutdown, "shutting down service")
+			return nil
+		}
+	}
+}
+
+// Reload applies reloadable settings: orders in progress keep their slots when the limit
+// is lowered, new orders wait until enough of them finish.
+func (s *Order) Reload(ctx context.Context, cfg config.Config) {
+	s.sem.Resize(cfg.Services.Order.MaxConcurrent)
+	s.orderService.Reload(cfg)
+}
+
+// cleanupRateLimits removes rate limit buckets of clients which are idle long enough for them to be full.
+func (s *Order) cleanupRateLimits(ctx context.Context) {
+	idle := max(s.limiter.MaxIdle(), time.Minute)
+
+	ticker := time.NewTicker(idle)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.limiter.Cleanup(ctx, idle); err != nil && ctx.Err() == nil {
+				s.log.Error(ctx, types.ActionDBQueryFailed, "failed to cleanup rate limit buckets", err)
+			}
+		}
 	}
 }
 
 func (s *Order) close(ctx context.Context) {
-	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Second*10)
 	defer cancel()
 
 	if err := s.httpServer.Stop(ctx); err != nil {
 		s.log.Error(ctx, types.ActionGracefulShutdown, "failed to shutdown HTTP server", err)
 	}
-
-	if err := s.producer.Close(ctx); err != nil {
-		s.log.Error(ctx, types.ActionGracefulShutdown, "failed to close rabbitMQ order client connection", err)
-	}
-
-	s.postgresDB.Pool.Close()
 }
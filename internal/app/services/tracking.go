@@ -3,18 +3,18 @@ package services
 import (
 	"context"
 	"fmt"
-	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	httpserver "wheres-my-pizza/internal/adapter/http/server"
 	"wheres-my-pizza/internal/adapter/postgres"
+	"wheres-my-pizza/internal/adapter/rabbit"
 	"wheres-my-pizza/internal/config"
 	"wheres-my-pizza/internal/domain/types"
+	"wheres-my-pizza/internal/services/auth"
 	"wheres-my-pizza/internal/services/tracking"
 	"wheres-my-pizza/pkg/logger"
 	postgresclient "wheres-my-pizza/pkg/postgres"
+	rabbitclient "wheres-my-pizza/pkg/rabbit"
 )
 
 // ## Feature: Tracking Service
@@ -22,34 +22,64 @@ import (
 // It offers a read-only HTTP API for external clients (like a customer-facing
 // app or an internal dashboard) to query the current status of orders, view an
 // order's history, and monitor the status of all kitchen workers. It directly
-// queries the database and does not interact with RabbitMQ.
+// queries the database, RabbitMQ is used to inspect kitchen queues for
+// capacity planning and to requeue orders left by dead workers.
 type Tracking struct {
-	postgresDB *postgresclient.PostgreDB
 	httpServer *httpserver.API
+	reaper     *tracking.Reaper
 
 	cfg config.Config
 	log logger.Logger
 }
 
-func NewTracking(ctx context.Context, cfg config.Config, log logger.Logger) (*Tracking, error) {
-	// Postgres database
-	db, err := postgresclient.New(ctx, cfg.Postgres)
-	if err != nil {
-		log.Error(ctx, "db_connect", "failed to connect postgres", err)
-		return nil, fmt.Errorf("failed to connect postgres: %v", err)
-	}
-	log.Info(ctx, types.ActionDBConnected, "connected to the database")
-
+// NewTracking creates tracking service, rabbitMQ is used to inspect kitchen queues.
+func NewTracking(ctx context.Context, cfg config.Config, db *postgresclient.PostgreDB, rabbitMQ *rabbitclient.RabbitMQ, log logger.Logger) (*Tracking, error) {
 	workerRepo := postgres.NewWorkerRepo(db.Pool)
 	statusRepo := postgres.NewStatusRepo(db.Pool)
-
-	trackingService := tracking.NewService(statusRepo, workerRepo, cfg.Services.Tracking.HeartbeatInterval, log)
-
-	api := httpserver.New(cfg, nil, trackingService, log)
+	orderRepo := postgres.NewOrderRepo(db.Pool)
+	customerRepo := postgres.NewCustomerRepo(db.Pool)
+	queueInspector := rabbit.NewQueueInspector(rabbitMQ)
+	cookingModel := cfg.Services.Kitchen.Cooking.Model()
+
+	trackingService := tracking.NewService(
+		statusRepo,
+		workerRepo,
+		orderRepo,
+		customerRepo,
+		queueInspector,
+		cookingModel,
+		cfg.Services.Tracking.HeartbeatInterval,
+		cfg.Services.Tracking.CapacityDrainTarget,
+		log,
+	)
+
+	authService := auth.NewService(postgres.NewAPIKeyRepo(db.Pool), cfg.HTTPServer.Auth.AdminKey, log)
+
+	api := httpserver.New(cfg, nil, trackingService, authService, nil, log)
+
+	// Reaper recovers orders stuck in 'cooking' by crashed workers
+	var reaper *tracking.Reaper
+	if cfg.Services.Tracking.ReaperInterval > 0 {
+		producer, err := rabbit.NewOrderProducer(ctx, rabbitMQ, cfg.RabbitMQ, log)
+		if err != nil {
+			log.Error(ctx, types.ActionRabbitConnectionFailed, "failed to create order producer", err)
+			return nil, fmt.Errorf("failed to create order producer: %w", err)
+		}
+
+		reaper = tracking.NewReaper(
+			workerRepo,
+			orderRepo,
+			producer,
+			cookingModel,
+			cfg.Services.Tracking.ReaperInterval,
+			cfg.Services.Tracking.HeartbeatInterval,
+			log,
+		)
+	}
 
 	return &Tracking{
-		postgresDB: db,
 		httpServer: api,
+		reaper:     reaper,
 		cfg:        cfg,
 
 		log: log,
@@ -66,28 +96,29 @@ func (s *Tracking) Start(ctx context.Context) error {
 		s.log.Info(ctx, types.ActionGracefulShutdown, "tracking service closed!")
 	}()
 
-	// Waiting signal
-	shutdownCh := make(chan os.Signal, 1)
-	signal.Notify(shutdownCh, syscall.SIGINT, syscall.SIGTERM)
+	// Reaper is stopped before connections are closed.
+	reaperCtx, stopReaper := context.WithCancel(ctx)
+	defer stopReaper()
+	if s.reaper != nil {
+		go s.reaper.Run(reaperCtx)
+	}
 
 	s.log.Info(ctx, types.ActionServiceStarted, "service started")
 
 	select {
 	case errRun := <-errCh:
 		return errRun
-	case sig := <-shutdownCh:
-		s.log.Info(ctx, types.ActionGracefulShutdown, "shuting down application", "signal", sig.String())
+	case <-ctx.Done():
+		s.log.Info(ctx, types.ActionGracefulShutdown, "shutting down service")
 		return nil
 	}
 }
 
 func (s *Tracking) close(ctx context.Context) {
-	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Second*10)
 	defer cancel()
 
 	if err := s.httpServer.Stop(ctx); err != nil {
 		s.log.Warn(ctx, types.ActionGracefulShutdown, "failed to shutdown HTTP server")
 	}
-
-	s.postgresDB.Pool.Close()
 }
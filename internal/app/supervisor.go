@@ -0,0 +1,71 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"wheres-my-pizza/internal/domain/types"
+)
+
+// exit is result of a service which returned from Start.
+type exit struct {
+	mode types.ServiceMode
+	err  error
+}
+
+// supervise starts services concurrently and waits for all of them to return.
+//
+// Shutdown signal is handled here once for every service: the shared context is cancelled
+// and each service closes itself. A failing service stops the others the same way, while
+// a service which stopped by itself without an error (e.g. drained kitchen worker) leaves
+// the rest running. Errors of all failed services are returned.
+//
+// SIGHUP reloads the config file, see reload.
+func (app *App) supervise(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	shutdownCh := make(chan os.Signal, 1)
+	signal.Notify(shutdownCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(shutdownCh)
+
+	reloadCh := make(chan os.Signal, 1)
+	signal.Notify(reloadCh, syscall.SIGHUP)
+	defer signal.Stop(reloadCh)
+
+	exits := make(chan exit, len(app.services))
+	for _, r := range app.services {
+		go func() {
+			exits <- exit{mode: r.mode, err: r.service.Start(ctx)}
+		}()
+	}
+
+	var errs []error
+	for running := len(app.services); running > 0; {
+		select {
+		case sig := <-shutdownCh:
+			app.log.Info(ctx, types.ActionGracefulShutdown, "shutting down application", "signal", sig.String())
+			cancel()
+		case <-reloadCh:
+			if ctx.Err() == nil {
+				app.reload(ctx)
+			}
+		case e := <-exits:
+			running--
+
+			if e.err != nil {
+				app.log.Error(ctx, types.ActionGracefulShutdown, "service failed, stopping application", e.err, "mode", e.mode)
+				errs = append(errs, fmt.Errorf("%s: %w", e.mode, e.err))
+				cancel()
+				continue
+			}
+			app.log.Info(ctx, types.ActionGracefulShutdown, "service stopped", "mode", e.mode, "running", running)
+		}
+	}
+
+	return errors.Join(errs...)
+}
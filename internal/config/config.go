@@ -4,162 +4,236 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"os"
 	"time"
 
+	"wheres-my-pizza/internal/domain/models"
 	"wheres-my-pizza/internal/domain/types"
 	"wheres-my-pizza/pkg/configparser"
 	"wheres-my-pizza/pkg/logger"
 	"wheres-my-pizza/pkg/postgres"
 	"wheres-my-pizza/pkg/rabbit"
+	"wheres-my-pizza/pkg/ratelimit"
 )
 
-const (
-	DefaultOrderServicePort    = 3000
-	DefaultTrackingServicePort = 3002
-)
-
-var (
+// Flags override file and environment when given on the command line, defaults are in tags of
+// the fields they set. Flag defaults here are shown in usage only.
+func init() {
 	// General
-	modeFlag = flag.String("mode", "", "application mode")
-	portFlag = flag.Int("port", -1, "The HTTP port for the API")
-	logLevel = flag.String("log-level", logger.LevelDebug, "Logger level. (DEBUG, INFO, WARN, ERROR)")
+	flag.String("mode", "", "application mode: a service, 'all', a comma-separated list of services or 'migrate'")
+	flag.String("log-level", logger.LevelDebug, "Logger level. (DEBUG, INFO, WARN, ERROR)")
 
 	// Order service
-	maxConcurrent = flag.Int("max-concurrent", 50, "Maximum number of concurrent orders to process.")
+	flag.Int("port", 3000, "The HTTP port for the API")
+	flag.Int("max-concurrent", 50, "Maximum number of concurrent orders to process.")
+
+	// Tracking service
+	flag.Int("tracking-port", 3002, "The HTTP port for the tracking API, --port sets it too when order-service doesn't run")
 
 	// Kitchen service
-	workerName   = flag.String("worker-name", "", "unique name for the worker (e.g., chef_mario) (required)")
-	orderTypes   = flag.String("order-types", "", "comma-separated list of order types the worker can handle (e.g., dine_in,takeout)")
-	heartbeatInt = flag.Int("heartbeat-interval", 30, "interval (seconds) between heartbeats")
-	prefetch     = flag.Int("prefetch", 1, "RabbitMQ prefetch count")
-)
+	flag.String("worker-name", "", "unique name for the worker (e.g., chef_mario) (required)")
+	flag.String("order-types", "", "comma-separated list of order types the worker can handle (e.g., dine_in,takeout)")
+	flag.Int("heartbeat-interval", 30, "interval (seconds) between heartbeats")
+	flag.Int("prefetch", 1, "RabbitMQ prefetch count")
+	flag.Int("concurrency", 1, "number of orders the worker cooks in parallel")
+}
 
 var (
 	ErrModeNotProvided = errors.New("mode flag not provided")
 	ErrInvalidModeFlag = errors.New("invalid mode flag")
+	ErrPortConflict    = errors.New("order-service and tracking-service ports must differ")
 )
 
 type (
 	// Config
 	Config struct {
-		Mode       types.ServiceMode
+		Mode       types.ServiceMode   `env:"MODE" flag:"mode" yaml:"mode"` // a service, 'all' or a comma-separated list
+		Modes      []types.ServiceMode `yaml:"-"`                           // services run by the process
 		Services   Services
-		HTTPServer HTTPServer
-		Postgres   postgres.Config
-		RabbitMQ   RabbitMQ
+		HTTPServer HTTPServer      `yaml:"http"`
+		Postgres   postgres.Config `yaml:"postgres"`
+		RabbitMQ   RabbitMQ        `yaml:"rabbitmq"`
+		Migrate    Migrate         `yaml:"migrate"`
+
+		LogLevel string `env:"LOG_LEVEL" flag:"log-level" default:"DEBUG" yaml:"log_level"`
+		Log      Log    `yaml:"log"`
 
-		LogLevel string
+		file    string // loaded again on reload
+		sources configparser.Sources
 	}
 
 	Services struct {
-		Order    OrderService
-		Kitchen  KitchenService
-		Tracking TrackingService
+		Order    OrderService    `yaml:"order"`
+		Kitchen  KitchenService  `yaml:"kitchen"`
+		Tracking TrackingService `yaml:"tracking"`
 	}
 
 	// HTTP service
 	HTTPServer struct {
-		Port int
+		Port int  `yaml:"-"` // of the service, see ForMode
+		Auth Auth `yaml:"auth"`
+	}
+
+	// API key authentication of HTTP endpoints
+	Auth struct {
+		Enabled      bool   `env:"HTTP_AUTH_ENABLED" default:"false" yaml:"enabled"`
+		AdminKey     string `env:"HTTP_AUTH_ADMIN_KEY" yaml:"admin_key"`                        // manager key to issue the first keys, not stored in the database
+		FailureLimit string `env:"HTTP_AUTH_FAILURE_LIMIT" default:"1:20" yaml:"failure_limit"` // failed authentications per client IP, 'rate per second:burst'
 	}
 
 	OrderService struct {
-		MaxConcurrent int
-		SemWait       time.Duration `env:"ORDER_SEMWAIT" default:"1s"`
+		Port          int           `env:"ORDER_PORT" flag:"port" default:"3000" yaml:"port"`
+		MaxConcurrent int           `env:"ORDER_MAX_CONCURRENT" flag:"max-concurrent" default:"50" yaml:"max_concurrent"`
+		SemWait       time.Duration `env:"ORDER_SEMWAIT" default:"1s" yaml:"semwait"`
+		BatchMaxSize  int           `env:"ORDER_BATCH_MAX_SIZE" default:"50" yaml:"batch_max_size"` // orders in POST /orders/batch
+		RateLimit     RateLimit     `yaml:"rate_limit"`
+	}
+
+	// Per-client rate limiting of order-service routes, limits are 'rate per second:burst'
+	RateLimit struct {
+		Enabled bool              `env:"ORDER_RATE_LIMIT_ENABLED" default:"true" yaml:"enabled"`
+		Store   string            `env:"ORDER_RATE_LIMIT_STORE" default:"memory" yaml:"store"` // memory or postgres (shared by replicas)
+		Default string            `env:"ORDER_RATE_LIMIT_DEFAULT" default:"10:20" yaml:"default"`
+		Routes  map[string]string `env:"ORDER_RATE_LIMIT_ROUTES" yaml:"routes"` // route pattern=limit, e.g. "POST /orders=5:10"
 	}
 
 	TrackingService struct {
-		HeartbeatInterval int
+		Port                int           `env:"TRACKING_PORT" flag:"tracking-port" default:"3002" yaml:"port"`
+		HeartbeatInterval   int           `env:"TRACKING_HEARTBEAT_INTERVAL" flag:"heartbeat-interval" default:"30" yaml:"heartbeat_interval"`
+		CapacityDrainTarget time.Duration `env:"TRACKING_CAPACITY_DRAIN_TARGET" default:"1m" yaml:"capacity.drain_target"` // time to clear kitchen backlog
+		ReaperInterval      time.Duration `env:"TRACKING_REAPER_INTERVAL" default:"30s" yaml:"reaper.interval"`            // 0 - stuck orders are not recovered
 	}
 
 	KitchenService struct {
-		WorkerName        string
-		OrderTypes        string
-		Prefetch          int
-		HeartbeatInterval int
-		ReconnectAttempt  int           `env:"KITCHEN_RECONNECT_ATTEMPT" default:"5"`
-		ReconnectDelay    time.Duration `env:"KITCHEN_RECONNECT_DELAY" default:"1s"`
+		WorkerName        string        `env:"KITCHEN_WORKER_NAME" flag:"worker-name" yaml:"worker_name"`
+		OrderTypes        string        `env:"KITCHEN_ORDER_TYPES" flag:"order-types" yaml:"order_types"` // empty - all types
+		Prefetch          int           `env:"KITCHEN_PREFETCH" flag:"prefetch" default:"1" yaml:"prefetch"`
+		Concurrency       int           `env:"KITCHEN_CONCURRENCY" flag:"concurrency" default:"1" yaml:"concurrency"`
+		HeartbeatInterval int           `env:"KITCHEN_HEARTBEAT_INTERVAL" flag:"heartbeat-interval" default:"30" yaml:"heartbeat_interval"`
+		DrainTimeout      time.Duration `env:"KITCHEN_DRAIN_TIMEOUT" default:"30s" yaml:"drain_timeout"` // time given to active orders on shutdown
+		Cooking           Cooking       `yaml:"cooking"`
+	}
+
+	// Cooking time model, see models.CookingModel
+	Cooking struct {
+		Mode          string                   `env:"KITCHEN_COOKING_MODE" default:"simulated" yaml:"mode"` // simulated or manual
+		OrderTypes    map[string]time.Duration `env:"KITCHEN_COOKING_ORDER_TYPES" default:"dine_in=8s,takeout=10s,delivery=12s" yaml:"order_types"`
+		Items         map[string]time.Duration `env:"KITCHEN_COOKING_ITEMS" yaml:"items"` // e.g. "margherita pizza=6s,caesar salad=2s"
+		DefaultItem   time.Duration            `env:"KITCHEN_COOKING_DEFAULT_ITEM" default:"0s" yaml:"default_item"`
+		Parallelism   int                      `env:"KITCHEN_COOKING_PARALLELISM" default:"2" yaml:"parallelism"`
+		QuantityScale float64                  `env:"KITCHEN_COOKING_QUANTITY_SCALE" default:"0.5" yaml:"quantity_scale"`
+		Jitter        float64                  `env:"KITCHEN_COOKING_JITTER" default:"0" yaml:"jitter"`
+		Seed          uint64                   `env:"KITCHEN_COOKING_SEED" default:"0" yaml:"seed"`                    // 0 - random
+		PollInterval  time.Duration            `env:"KITCHEN_COOKING_POLL_INTERVAL" default:"1s" yaml:"poll_interval"` // manual mode: how often ready mark is checked
+	}
+
+	// Logging besides the level
+	Log struct {
+		Format     string            `env:"LOG_FORMAT" default:"json" yaml:"format"` // json or text
+		Components map[string]string `env:"LOG_COMPONENTS" yaml:"components"`        // component=level overriding log level, e.g. "rabbit=DEBUG,http=INFO"
+		Sample     map[string]int    `env:"LOG_SAMPLE" yaml:"sample"`                // action=N writes every Nth record of the action, e.g. "heartbeat_sent=10"
+	}
+
+	// Embedded schema migrations
+	Migrate struct {
+		Auto    bool   `env:"MIGRATE_AUTO" default:"false" yaml:"auto"` // apply pending migrations on service start
+		Command string `yaml:"-"`                                       // migrate mode: up, down, status or force
+		Arg     int    `yaml:"-"`                                       // migrate mode: steps of down, version of force
 	}
 
 	RabbitMQ struct {
 		Conn                  rabbit.Config
-		OrderExchange         string        `env:"RABBITMQ_ORDER_EXCHANGE" default:"orders_topic"`
-		NotificationsExchange string        `env:"RABBITMQ_NOTIFICATIONS_EXCHANGE" default:"notifications_fanout"`
-		ReconnectAttempt      int           `env:"RABBITMQ_RECONNECT_ATTEMPT" default:"5"`
-		ReconnectDelay        time.Duration `env:"RABBITMQ_RECONNECT_DELAY" default:"1s"`
+		OrderExchange         string        `env:"RABBITMQ_ORDER_EXCHANGE" default:"orders_topic" yaml:"order.exchange"`
+		NotificationsExchange string        `env:"RABBITMQ_NOTIFICATIONS_EXCHANGE" default:"notifications_fanout" yaml:"notifications.exchange"`
+		ConfirmTimeout        time.Duration `env:"RABBITMQ_CONFIRM_TIMEOUT" default:"5s" yaml:"confirm.timeout"`
 	}
 )
 
-func New(filepath string) (*Config, error) {
-	cfg := &Config{}
+// Stores of rate limit buckets, see RateLimit.Store
+const (
+	RateLimitStoreMemory   = "memory"   // per replica
+	RateLimitStorePostgres = "postgres" // shared by replicas
+)
 
-	if err := configparser.LoadAndParseYaml(filepath, cfg); err != nil {
-		return nil, err
+// Limits parses default and per route rate limits.
+func (c RateLimit) Limits() (ratelimit.Limit, map[string]ratelimit.Limit, error) {
+	defaultLimit, err := ratelimit.ParseLimit(c.Default)
+	if err != nil {
+		return ratelimit.Limit{}, nil, err
 	}
 
-	if err := parseFlags(cfg); err != nil {
-		return nil, err
+	routes := make(map[string]ratelimit.Limit, len(c.Routes))
+	for route, s := range c.Routes {
+		limit, err := ratelimit.ParseLimit(s)
+		if err != nil {
+			return ratelimit.Limit{}, nil, fmt.Errorf("route %q: %w", route, err)
+		}
+		routes[route] = limit
 	}
 
-	return cfg, nil
+	return defaultLimit, routes, nil
 }
 
-func parseFlags(cfg *Config) error {
-	if modeFlag == nil {
-		return ErrModeNotProvided
-	}
+// Failures returns limit of failed authentications of a client.
+func (c Auth) Failures() (ratelimit.Limit, error) {
+	return ratelimit.ParseLimit(c.FailureLimit)
+}
 
-	cfg.LogLevel = *logLevel
-	if logLevel == nil || *logLevel == "" {
-		cfg.LogLevel = logger.LevelDebug
-	}
+// Model creates cooking time model.
+func (c Cooking) Model() *models.CookingModel {
+	return models.NewCookingModel(c.OrderTypes, c.Items, c.DefaultItem, c.Parallelism, c.QuantityScale, c.Jitter, c.Seed)
+}
 
-	if err := validateLogLevel(cfg.LogLevel); err != nil {
-		return err
+// LoggerOptions returns options of the logger.
+func (c Config) LoggerOptions() logger.Options {
+	return logger.Options{
+		Level:      c.LogLevel,
+		Format:     c.Log.Format,
+		Components: c.Log.Components,
+		Sample:     c.Log.Sample,
 	}
+}
 
-	cfg.Mode = types.ServiceMode(*modeFlag)
+// ForMode returns config of one of the services run by the process.
+func (c Config) ForMode(mode types.ServiceMode) Config {
+	c.Mode = mode
+	c.Modes = []types.ServiceMode{mode}
 
-	switch cfg.Mode {
+	switch mode {
 	case types.ModeOrder:
-		if portFlag == nil || *portFlag < 1024 || *portFlag > 65535 {
-			cfg.HTTPServer.Port = DefaultOrderServicePort
-		} else {
-			cfg.HTTPServer.Port = *portFlag
-		}
-
-		if maxConcurrent != nil && !(*maxConcurrent >= 0 && *maxConcurrent <= 1000) {
-			return errors.New("--max-concurrent flag must be between 1 and 1000")
-		}
-		cfg.Services.Order.MaxConcurrent = *maxConcurrent
-	case types.ModeKitchenWorker:
-		if workerName == nil || *workerName == "" {
-			return errors.New("missing required flag: --worker-name")
-		}
-
-		cfg.Services.Kitchen.WorkerName = *workerName
-		cfg.Services.Kitchen.OrderTypes = *orderTypes
-		cfg.Services.Kitchen.HeartbeatInterval = *heartbeatInt
-		cfg.Services.Kitchen.Prefetch = *prefetch
+		c.HTTPServer.Port = c.Services.Order.Port
 	case types.ModeTracking:
-		if portFlag != nil {
-			cfg.HTTPServer.Port = *portFlag
-		} else {
-			cfg.HTTPServer.Port = DefaultTrackingServicePort
-		}
-		cfg.Services.Tracking.HeartbeatInterval = *heartbeatInt
-	case types.ModeNotificationSubscriber:
-	default:
-		return ErrInvalidModeFlag
+		c.HTTPServer.Port = c.Services.Tracking.Port
 	}
 
-	return nil
+	return c
 }
 
-func validateLogLevel(lvl string) error {
-	switch lvl {
-	case logger.LevelDebug, logger.LevelError, logger.LevelWarn, logger.LevelInfo:
-		return nil
-	default:
-		return fmt.Errorf("invalid log level: %s", lvl)
+// New loads config from default values, YAML file, environment variables and flags, each
+// overriding the previous ones, and validates it. Invalid config is returned with all its
+// errors, so it can be printed.
+func New(filepath string) (*Config, error) {
+	cfg := &Config{file: filepath}
+
+	sources, err := configparser.Load(cfg, configparser.Layers{
+		File:  filepath,
+		Env:   os.LookupEnv,
+		Flags: givenFlags(),
+	})
+	if sources == nil {
+		return nil, err
 	}
+	cfg.sources = sources
+
+	// values which failed to load are reported together with invalid ones
+	return cfg, errors.Join(err, cfg.resolve(flag.Args()))
+}
+
+// givenFlags returns values of flags given on the command line.
+func givenFlags() map[string]string {
+	flags := make(map[string]string)
+	flag.Visit(func(f *flag.Flag) {
+		flags[f.Name] = f.Value.String()
+	})
+	return flags
 }
@@ -12,11 +12,25 @@ Available Services:
   kitchen-worker          - Kitchen order processing service
   tracking-service        - Order tracking API
   notification-subscriber - Status update subscriber
+  all                     - Every service above in one process
+  migrate                 - Apply embedded database migrations and exit
+
+Several services run in one process with a comma-separated list,
+e.g. --mode=order-service,kitchen-worker. They share the database pool
+and logger and are stopped together.
 
 Common Flags:
   --help                  - Show this help message
-  --config                - Path to config file (default: config.yaml)
+  --config-path           - Path to config file (default: config.yaml)
   --log-level             - Defines logger level (DEBUG, INFO, WARN, ERROR)
+  --print-config          - Print config with the source of every value and exit
+  --validate-config       - Check config, report all invalid values and exit
+
+Settings are taken from defaults < config file < environment < flags.
+Environment variables are named after the setting, e.g. ORDER_PORT,
+KITCHEN_WORKER_NAME, TRACKING_PORT.
+SIGHUP reloads log levels, max concurrent orders, semwait, kitchen prefetch
+and heartbeat interval, and RabbitMQ reconnect settings from the config file.
 
 Service-Specific Flags:
 
@@ -27,11 +41,20 @@ Order Service:
 Kitchen Worker:
   --worker-name        - Unique worker identifier (required)
   --order-types        - Comma-separated order types (dine_in,takeout,delivery)
-  --heartbeat-interval - Worker heartbeat in seconds (default: 30)
+  --heartbeat-interval - Worker heartbeat in seconds, at least 5 (default: 30)
   --prefetch           - RabbitMQ prefetch count (default: 1)
+  --concurrency        - Orders cooked in parallel, 1 to 100 (default: 1, env: KITCHEN_CONCURRENCY)
+
+  Drain timeout has no flag: KITCHEN_DRAIN_TIMEOUT or kitchen.drain_timeout
+  in the config file is the time active orders get to finish on shutdown
+  before they are returned to the queue (default: 30s).
 
 Tracking Service:
-  --port - HTTP port (default: 3002)
+  --port          - HTTP port (default: 3002)
+  --tracking-port - HTTP port when run with order-service, which takes --port (default: 3002)
+
+Migrate:
+  --mode=migrate [command] - up (default), down [N], status or force V
 
 Examples:
   ./restaurant-system --mode=order-service --port=3000 --max-concurrent 50
@@ -42,6 +65,11 @@ Examples:
 
   ./restaurant-system --mode=tracking-service --port=3002
   ./restaurant-system --mode=notification-subscriber
+
+  ./restaurant-system --mode=migrate up
+  ./restaurant-system --mode=migrate down 1
+
+  ./restaurant-system --mode=all --worker-name="gordon_ramsay" --port=3000 --tracking-port=3002
 `
 
 func PrintHelp() {
@@ -2,74 +2,74 @@ package config
 
 import (
 	"fmt"
+	"os"
 	"reflect"
+	"sort"
 	"strings"
-	"time"
+	"text/tabwriter"
+
+	"wheres-my-pizza/pkg/configparser"
 )
 
-// PrintConfig prints structure with field names and values in a clean format
-func PrintConfig(cfg any) {
+// PrintConfig prints configurable values by their YAML paths with layers they come from.
+// Secrets are masked.
+func PrintConfig(cfg *Config) {
 	fmt.Println("Configuration:")
 	fmt.Println("--------------")
-	printReflected(reflect.ValueOf(cfg), "", 0)
-}
 
-func printReflected(v reflect.Value, fieldName string, depth int) {
-	// Handle pointers
-	if v.Kind() == reflect.Ptr {
-		if v.IsNil() {
-			fmt.Printf("%s%s: <nil>\n", strings.Repeat("  ", depth), fieldName)
-			return
-		}
-		v = v.Elem()
+	fields, err := configparser.Fields(cfg)
+	if err != nil {
+		fmt.Println(err)
+		return
 	}
 
-	// Handle basic types directly
-	if v.Kind() != reflect.Struct {
-		if fieldName != "" {
-			fmt.Printf("%s%s: %v\n", strings.Repeat("  ", depth), fieldName, v.Interface())
-		} else {
-			fmt.Printf("%s%v\n", strings.Repeat("  ", depth), v.Interface())
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, f := range fields {
+		source, ok := cfg.sources[f.Path]
+		if !ok {
+			source = "unset"
 		}
-		return
-	}
 
-	// If this is a nested struct (with name), print only field name, not type name
-	if fieldName != "" {
-		fmt.Printf("%s%s:\n", strings.Repeat("  ", depth), fieldName)
-	}
+		value := formatValue(f.Value)
+		if isSecret(f.Path) && !f.Value.IsZero() {
+			value = "******"
+		}
 
-	t := v.Type()
+		fmt.Fprintf(w, "%s:\t%s\t# %s\n", f.Path, value, source)
+	}
+	w.Flush()
 
-	for i := 0; i < v.NumField(); i++ {
-		field := v.Field(i)
-		fieldType := t.Field(i)
+	if len(cfg.Modes) > 1 {
+		fmt.Printf("services: %v\n", cfg.Modes)
+	}
+}
 
-		// Skip unexported fields
-		if !field.CanInterface() {
-			continue
-		}
+// isSecret reports whether field at path holds a password, secret or key.
+func isSecret(path string) bool {
+	name := strings.ToLower(path[strings.LastIndex(path, ".")+1:])
+	return strings.Contains(name, "password") || strings.Contains(name, "secret") || strings.Contains(name, "key")
+}
 
-		// Mask sensitive fields
-		nameLower := strings.ToLower(fieldType.Name)
-		if strings.Contains(nameLower, "password") || strings.Contains(nameLower, "secret") || strings.Contains(nameLower, "key") {
-			fmt.Printf("%s%s: ******\n", strings.Repeat("  ", depth+1), fieldType.Name)
-			continue
+// formatValue formats v as it is written in environment variables.
+func formatValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		if v.Len() == 0 {
+			return `""`
 		}
-
-		// Handle time.Duration specially
-		if field.Type().String() == "time.Duration" {
-			fmt.Printf("%s%s: %v\n", strings.Repeat("  ", depth+1), fieldType.Name, field.Interface().(time.Duration))
-			continue
+		return v.String()
+	case reflect.Map:
+		if v.Len() == 0 {
+			return `""`
 		}
-
-		// Recurse for nested structs or pointers to structs
-		if field.Kind() == reflect.Struct || (field.Kind() == reflect.Ptr && field.Elem().Kind() == reflect.Struct) {
-			printReflected(field, fieldType.Name, depth+1)
-			continue
+		pairs := make([]string, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			pairs = append(pairs, fmt.Sprintf("%v=%v", iter.Key(), iter.Value()))
 		}
-
-		// Print normal value
-		fmt.Printf("%s%s: %v\n", strings.Repeat("  ", depth+1), fieldType.Name, field.Interface())
+		sort.Strings(pairs)
+		return strings.Join(pairs, ",")
+	default:
+		return fmt.Sprint(v.Interface())
 	}
 }
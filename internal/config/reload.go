@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+
+	"wheres-my-pizza/pkg/configparser"
+)
+
+// reloadable are settings which running services apply on reload, changing others needs restart.
+var reloadable = map[string]bool{
+	"log_level":                    true,
+	"log.components":               true,
+	"order.max_concurrent":         true,
+	"order.semwait":                true,
+	"kitchen.prefetch":             true,
+	"kitchen.heartbeat_interval":   true,
+	"rabbitmq.reconnect.attempt":   true,
+	"rabbitmq.reconnect.delay":     true,
+	"rabbitmq.reconnect.max_delay": true,
+}
+
+// Change is a setting whose value changed on reload, values of secrets are masked.
+type Change struct {
+	Path       string
+	Old        string
+	New        string
+	Reloadable bool
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Path, c.Old, c.New)
+}
+
+// Reload loads config again from the same file, environment and flags. The returned config is
+// a copy of c with reloadable settings taken from the new one, all changed settings are returned
+// with it. Nothing is returned if the new config is invalid.
+func (c *Config) Reload() (*Config, []Change, error) {
+	next, err := New(c.file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cfg := *c
+	cfg.sources = maps.Clone(c.sources)
+
+	fields, err := configparser.Fields(&cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	nextFields, err := configparser.Fields(next)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var changes []Change
+	for i, f := range fields {
+		change := Change{
+			Path:       f.Path,
+			Old:        formatValue(f.Value),
+			New:        formatValue(nextFields[i].Value),
+			Reloadable: reloadable[f.Path],
+		}
+		if change.Old == change.New {
+			continue
+		}
+		if isSecret(f.Path) {
+			change.Old, change.New = "******", "******"
+		}
+
+		if change.Reloadable {
+			f.Value.Set(nextFields[i].Value)
+			cfg.sources[f.Path] = next.sources[f.Path]
+		}
+		changes = append(changes, change)
+	}
+
+	return &cfg, changes, nil
+}
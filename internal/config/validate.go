@@ -0,0 +1,264 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"wheres-my-pizza/internal/domain/types"
+	"wheres-my-pizza/pkg/configparser"
+	"wheres-my-pizza/pkg/logger"
+	"wheres-my-pizza/pkg/validator"
+)
+
+// ValidationError lists invalid values of the config by their paths.
+type ValidationError struct {
+	Errors map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	keys := make([]string, 0, len(e.Errors))
+	for key := range e.Errors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, fmt.Sprintf("%s: %s", key, e.Errors[key]))
+	}
+	return "invalid config:\n  " + strings.Join(lines, "\n  ")
+}
+
+// resolve sets values derived from the loaded ones: services to run, their ports and the
+// migrate command from args, then validates the config.
+func (c *Config) resolve(args []string) error {
+	v := validator.New()
+
+	modes, err := parseModes(string(c.Mode))
+	if err != nil {
+		v.AddError("mode", err.Error())
+	}
+	c.Modes = modes
+	if len(modes) == 1 {
+		c.Mode = modes[0]
+	}
+
+	// Without order-service --port belongs to tracking-service, unless --tracking-port is given
+	if c.runs(types.ModeTracking) && !c.runs(types.ModeOrder) &&
+		c.sources["order.port"] == configparser.SourceFlag && c.sources["tracking.port"] != configparser.SourceFlag {
+		c.Services.Tracking.Port = c.Services.Order.Port
+		c.sources["tracking.port"] = configparser.SourceFlag
+	}
+	if len(modes) == 1 {
+		c.HTTPServer.Port = c.ForMode(c.Mode).HTTPServer.Port
+	}
+
+	if c.runs(types.ModeMigrate) {
+		if err := c.Migrate.parseArgs(args); err != nil {
+			v.AddError("migrate", err.Error())
+		}
+	}
+
+	c.validate(v)
+	if !v.Valid() {
+		return &ValidationError{Errors: v.Errors}
+	}
+	return nil
+}
+
+// validate checks the config, settings of services which don't run are not checked.
+func (c *Config) validate(v *validator.Validator) {
+	v.Check(validateLogLevel(c.LogLevel) == nil, "log_level", "must be DEBUG, INFO, WARN or ERROR")
+	v.Check(validator.PermittedValue(c.Log.Format, logger.FormatJSON, logger.FormatText), "log.format", "must be json or text")
+	for component, lvl := range c.Log.Components {
+		v.Check(validateLogLevel(lvl) == nil, "log.components", fmt.Sprintf("level of %s must be DEBUG, INFO, WARN or ERROR", component))
+	}
+	for action, n := range c.Log.Sample {
+		v.Check(n >= 1, "log.sample", fmt.Sprintf("rate of %s must be at least 1", action))
+	}
+
+	needsPostgres := slices.ContainsFunc(c.Modes, func(m types.ServiceMode) bool {
+		return m != types.ModeNotificationSubscriber
+	})
+	if needsPostgres {
+		pg := c.Postgres
+		v.Check(pg.Host != "", "postgres.host", "must be provided")
+		v.Check(validPort(pg.Port), "postgres.port", "must be a port number")
+		v.Check(pg.User != "", "postgres.user", "must be provided")
+		v.Check(pg.DBName != "", "postgres.database", "must be provided")
+		v.Check(pg.MaxOpenConns >= 1, "postgres.max_open_conn", "must be at least 1")
+		_, err := time.ParseDuration(pg.MaxIdleTime)
+		v.Check(err == nil, "postgres.max_idle_time", "must be a duration")
+	}
+
+	if len(c.Modes) > 0 && !c.runs(types.ModeMigrate) {
+		mq := c.RabbitMQ
+		v.Check(mq.Conn.Host != "", "rabbitmq.host", "must be provided")
+		v.Check(validPort(mq.Conn.Port), "rabbitmq.port", "must be a port number")
+		v.Check(mq.Conn.ReconnectAttempt >= 0, "rabbitmq.reconnect.attempt", "must not be negative")
+		v.Check(mq.Conn.ReconnectDelay > 0, "rabbitmq.reconnect.delay", "must be positive")
+		v.Check(mq.Conn.ReconnectMaxDelay >= mq.Conn.ReconnectDelay, "rabbitmq.reconnect.max_delay", "must not be less than reconnect delay")
+		v.Check(mq.OrderExchange != "", "rabbitmq.order.exchange", "must be provided")
+		v.Check(mq.NotificationsExchange != "", "rabbitmq.notifications.exchange", "must be provided")
+		v.Check(mq.ConfirmTimeout > 0, "rabbitmq.confirm.timeout", "must be positive")
+	}
+
+	if auth := c.HTTPServer.Auth; auth.Enabled && (c.runs(types.ModeOrder) || c.runs(types.ModeTracking)) {
+		if _, err := auth.Failures(); err != nil {
+			v.AddError("http.auth.failure_limit", err.Error())
+		}
+	}
+
+	if c.runs(types.ModeOrder) {
+		order := c.Services.Order
+		v.Check(validServicePort(order.Port), "order.port", "must be between 1024 and 65535")
+		v.Check(order.MaxConcurrent >= 1 && order.MaxConcurrent <= 1000, "order.max_concurrent", "must be between 1 and 1000")
+		v.Check(order.SemWait > 0, "order.semwait", "must be positive")
+		v.Check(order.BatchMaxSize >= 1, "order.batch_max_size", "must be at least 1")
+
+		if rl := order.RateLimit; rl.Enabled {
+			v.Check(validator.PermittedValue(rl.Store, RateLimitStoreMemory, RateLimitStorePostgres),
+				"order.rate_limit.store", fmt.Sprintf("must be %s or %s", RateLimitStoreMemory, RateLimitStorePostgres))
+			if _, _, err := rl.Limits(); err != nil {
+				v.AddError("order.rate_limit", err.Error())
+			}
+		}
+	}
+
+	if c.runs(types.ModeTracking) {
+		tracking := c.Services.Tracking
+		v.Check(validServicePort(tracking.Port), "tracking.port", "must be between 1024 and 65535")
+		v.Check(tracking.HeartbeatInterval >= 1, "tracking.heartbeat_interval", "must be at least 1 second")
+		v.Check(tracking.CapacityDrainTarget > 0, "tracking.capacity.drain_target", "must be positive")
+		v.Check(tracking.ReaperInterval >= 0, "tracking.reaper.interval", "must not be negative")
+	}
+
+	if c.runs(types.ModeOrder) && c.runs(types.ModeTracking) {
+		v.Check(c.Services.Order.Port != c.Services.Tracking.Port, "tracking.port", ErrPortConflict.Error())
+	}
+
+	if c.runs(types.ModeKitchenWorker) {
+		kitchen := c.Services.Kitchen
+		nameLen := utf8.RuneCountInString(kitchen.WorkerName)
+		v.Check(nameLen >= 1, "kitchen.worker_name", "must be provided with --worker-name")
+		v.Check(nameLen <= 100, "kitchen.worker_name", "must not be more than 100 characters long")
+		for _, orderType := range strings.Split(kitchen.OrderTypes, ",") {
+			orderType = strings.TrimSpace(orderType)
+			v.Check(kitchen.OrderTypes == "" || types.IsValidOrderType(orderType),
+				"kitchen.order_types", fmt.Sprintf("must be a comma-separated list of %s", strings.Join(types.AllOrderTypes, ", ")))
+		}
+		v.Check(kitchen.HeartbeatInterval >= 5, "kitchen.heartbeat_interval", "must be at least 5 seconds")
+		v.Check(kitchen.Prefetch >= 1, "kitchen.prefetch", "must be at least 1")
+		v.Check(kitchen.Concurrency >= 1 && kitchen.Concurrency <= 100, "kitchen.concurrency", "must be between 1 and 100")
+		v.Check(kitchen.DrainTimeout >= 0, "kitchen.drain_timeout", "must not be negative")
+
+		cooking := kitchen.Cooking
+		v.Check(validator.PermittedValue(cooking.Mode, types.CookingModeSimulated, types.CookingModeManual),
+			"kitchen.cooking.mode", fmt.Sprintf("must be %s or %s", types.CookingModeSimulated, types.CookingModeManual))
+		v.Check(cooking.Parallelism >= 1, "kitchen.cooking.parallelism", "must be at least 1")
+		v.Check(cooking.QuantityScale >= 0, "kitchen.cooking.quantity_scale", "must not be negative")
+		v.Check(cooking.Jitter >= 0 && cooking.Jitter < 1, "kitchen.cooking.jitter", "must be between 0 and 1")
+		v.Check(cooking.PollInterval > 0, "kitchen.cooking.poll_interval", "must be positive")
+	}
+}
+
+// runs reports whether the process runs the service.
+func (c *Config) runs(mode types.ServiceMode) bool {
+	return slices.Contains(c.Modes, mode)
+}
+
+// parseModes parses --mode flag: a service mode, a comma-separated list of them or 'all'.
+func parseModes(value string) ([]types.ServiceMode, error) {
+	if value == "" {
+		return nil, ErrModeNotProvided
+	}
+
+	switch types.ServiceMode(value) {
+	case types.ModeAll:
+		return slices.Clone(types.AllServiceModes), nil
+	case types.ModeMigrate:
+		// migrate exits when done, it doesn't run with services
+		return []types.ServiceMode{types.ModeMigrate}, nil
+	}
+
+	var modes []types.ServiceMode
+	for _, raw := range strings.Split(value, ",") {
+		mode := types.ServiceMode(strings.TrimSpace(raw))
+		if !types.IsValidServiceMode(mode) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidModeFlag, mode)
+		}
+		if slices.Contains(modes, mode) {
+			return nil, fmt.Errorf("%w: duplicate mode %q", ErrInvalidModeFlag, mode)
+		}
+		modes = append(modes, mode)
+	}
+
+	return modes, nil
+}
+
+// parseArgs parses migrate command given after flags: up, down [N], status or force V.
+func (c *Migrate) parseArgs(args []string) error {
+	c.Command = types.MigrateUp
+	if len(args) > 0 {
+		c.Command = args[0]
+		args = args[1:]
+	}
+
+	switch c.Command {
+	case types.MigrateUp, types.MigrateStatus:
+		if len(args) > 0 {
+			return fmt.Errorf("migrate %s takes no arguments", c.Command)
+		}
+	case types.MigrateDown:
+		c.Arg = 1
+		if len(args) > 1 {
+			return errors.New("usage: migrate down [N]")
+		}
+		if len(args) == 1 {
+			steps, err := strconv.Atoi(args[0])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("migrate down: invalid number of steps %q", args[0])
+			}
+			c.Arg = steps
+		}
+	case types.MigrateForce:
+		if len(args) != 1 {
+			return errors.New("usage: migrate force V")
+		}
+		version, err := strconv.Atoi(args[0])
+		if err != nil || version < 0 {
+			return fmt.Errorf("migrate force: invalid version %q", args[0])
+		}
+		c.Arg = version
+	default:
+		return fmt.Errorf("unknown migrate command %q: must be %s, %s, %s or %s",
+			c.Command, types.MigrateUp, types.MigrateDown, types.MigrateStatus, types.MigrateForce)
+	}
+
+	return nil
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n >= 1 && n <= 65535
+}
+
+// validServicePort reports whether port is allowed for HTTP services, privileged ones aren't.
+func validServicePort(port int) bool {
+	return port >= 1024 && port <= 65535
+}
+
+func validateLogLevel(lvl string) error {
+	switch lvl {
+	case logger.LevelDebug, logger.LevelError, logger.LevelWarn, logger.LevelInfo:
+		return nil
+	default:
+		return fmt.Errorf("invalid log level: %s", lvl)
+	}
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// APIKey is an issued API key, the key itself is stored hashed and is never returned again.
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Role       string     `json:"role"`
+	CustomerID *int       `json:"customer_id"` // nullable, customer of a customer key
+	Prefix     string     `json:"prefix"`      // first characters of the key to tell keys apart
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"` // nullable
+	RevokedAt  *time.Time `json:"revoked_at"`   // nullable
+}
+
+// IssuedAPIKey is a just issued API key with its plain value.
+type IssuedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// AuditEntry is a record of an authenticated HTTP request.
+type AuditEntry struct {
+	ID         int       `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	KeyID      *int      `json:"key_id"` // nullable, nil for the admin key from config
+	KeyName    string    `json:"key_name"`
+	Role       string    `json:"role"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Status     int       `json:"status"`
+	RequestID  string    `json:"request_id"`
+	RemoteAddr string    `json:"remote_addr"`
+}
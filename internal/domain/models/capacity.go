@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// QueueState is a snapshot of a kitchen queue.
+type QueueState struct {
+	Name             string   `json:"name"`
+	Ready            int      `json:"messages_ready"`
+	Unacked          *int     `json:"messages_unacked"` // nullable, unknown without management API
+	Consumers        int      `json:"consumers"`
+	OldestMessageAge *float64 `json:"oldest_message_age_seconds"` // nullable
+}
+
+// OrderTypeCapacity describes load and recommended number of workers for an order type.
+type OrderTypeCapacity struct {
+	OrderType          string     `json:"order_type"`
+	Queue              QueueState `json:"queue"`
+	DeadLetterQueue    QueueState `json:"dead_letter_queue"`
+	OnlineWorkers      int        `json:"online_workers"`
+	OnlineSlots        int        `json:"online_slots"` // orders online workers cook at the same time
+	CookingTime        float64    `json:"cooking_time_seconds"`
+	RecommendedWorkers int        `json:"recommended_workers"`
+}
+
+type KitchenCapacity struct {
+	Timestamp   time.Time           `json:"timestamp"`
+	DrainTarget float64             `json:"drain_target_seconds"`
+	OrderTypes  []OrderTypeCapacity `json:"order_types"`
+}
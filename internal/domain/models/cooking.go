@@ -0,0 +1,113 @@
+package models
+
+import (
+	"cmp"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"wheres-my-pizza/internal/domain/types"
+)
+
+// CookingModel estimates cooking time of an order from its content. Order type sets the base
+// time (packing, plating), items are prepared in parallel on a number of stations, every extra
+// unit of an item adds a share of its prep time, and optional jitter makes time vary.
+type CookingModel struct {
+	OrderTypes    map[string]time.Duration // base time by order type
+	Items         map[string]time.Duration // prep time by lowercase item name
+	DefaultItem   time.Duration            // prep time of items missing in Items
+	Parallelism   int                      // items prepared at the same time
+	QuantityScale float64                  // share of prep time added by every extra unit
+	Jitter        float64                  // max random deviation, share of estimated time
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// NewCookingModel creates cooking model, seed 0 means random seed.
+func NewCookingModel(
+	orderTypes map[string]time.Duration,
+	items map[string]time.Duration,
+	defaultItem time.Duration,
+	parallelism int,
+	quantityScale float64,
+	jitter float64,
+	seed uint64,
+) *CookingModel {
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+
+	lowered := make(map[string]time.Duration, len(items))
+	for name, prep := range items {
+		lowered[strings.ToLower(name)] = prep
+	}
+
+	return &CookingModel{
+		OrderTypes:    orderTypes,
+		Items:         lowered,
+		DefaultItem:   defaultItem,
+		Parallelism:   max(parallelism, 1),
+		QuantityScale: quantityScale,
+		Jitter:        jitter,
+		rnd:           rand.New(rand.NewPCG(seed, seed)),
+	}
+}
+
+// BaseTime returns base cooking time of the order type.
+func (m *CookingModel) BaseTime(orderType string) time.Duration {
+	if base, ok := m.OrderTypes[orderType]; ok {
+		return base
+	}
+	return types.GetSimulateCookingDuration(orderType)
+}
+
+// MaxBaseTime returns the longest base time of all order types.
+func (m *CookingModel) MaxBaseTime() time.Duration {
+	var longest time.Duration
+	for _, orderType := range types.AllOrderTypes {
+		longest = max(longest, m.BaseTime(orderType))
+	}
+	return longest
+}
+
+// Estimate returns cooking time of the order, Expected time with jitter applied.
+func (m *CookingModel) Estimate(order *CreateOrder) time.Duration {
+	total := m.Expected(order)
+
+	if m.Jitter > 0 {
+		m.mu.Lock()
+		deviation := (m.rnd.Float64()*2 - 1) * m.Jitter
+		m.mu.Unlock()
+
+		total += time.Duration(float64(total) * deviation)
+	}
+
+	return max(total, 0)
+}
+
+// Expected returns cooking time of the order without jitter.
+func (m *CookingModel) Expected(order *CreateOrder) time.Duration {
+	// Items time: longest first, every item goes to the least loaded station.
+	preps := make([]time.Duration, 0, len(order.Items))
+	for _, item := range order.Items {
+		prep, ok := m.Items[strings.ToLower(item.Name)]
+		if !ok {
+			prep = m.DefaultItem
+		}
+
+		extra := float64(max(item.Quantity-1, 0)) * m.QuantityScale
+		preps = append(preps, prep+time.Duration(float64(prep)*extra))
+	}
+	slices.SortFunc(preps, func(a, b time.Duration) int { return cmp.Compare(b, a) })
+
+	stations := make([]time.Duration, m.Parallelism)
+	for _, prep := range preps {
+		least := slices.Index(stations, slices.Min(stations))
+		stations[least] += prep
+	}
+
+	return m.BaseTime(order.Type) + slices.Max(stations)
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+type Customer struct {
+	ID        int       `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Name      string    `json:"name"`
+	Phone     *string   `json:"phone"` // nullable, phone or email is required
+	Email     *string   `json:"email"` // nullable
+}
+
+// CustomerAddress is a saved delivery address of a customer.
+type CustomerAddress struct {
+	ID         int       `json:"id"`
+	CustomerID int       `json:"customer_id"`
+	Label      *string   `json:"label"` // nullable, e.g. 'home' or 'work'
+	Address    string    `json:"address"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// FavoriteItem is a menu item a customer orders again and again.
+type FavoriteItem struct {
+	ID         int       `json:"id"`
+	CustomerID int       `json:"customer_id"`
+	Name       string    `json:"name"`
+	Price      float64   `json:"price"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CustomerDetails is a customer with saved addresses and favorites.
+type CustomerDetails struct {
+	Customer
+	Addresses []CustomerAddress `json:"addresses"`
+	Favorites []FavoriteItem    `json:"favorites"`
+}
+
+// CustomerOrder is an order in the customer order history.
+type CustomerOrder struct {
+	Number          string              `json:"order_number"`
+	Type            string              `json:"order_type"`
+	Status          string              `json:"status"`
+	TableNumber     *int                `json:"table_number,omitempty"`
+	DeliveryAddress *string             `json:"delivery_address,omitempty"`
+	TotalAmount     float64             `json:"total_amount"`
+	Items           []CustomerOrderItem `json:"items"`
+	CreatedAt       time.Time           `json:"created_at"`
+	CompletedAt     *time.Time          `json:"completed_at"` // nullable
+}
+
+type CustomerOrderItem struct {
+	Name     string  `json:"name"`
+	Quantity int     `json:"quantity"`
+	Price    float64 `json:"price"`
+}
@@ -1,9 +1,149 @@
 package models
 
-import "errors"
+import (
+	"errors"
+	"runtime"
+)
+
+// Kind classifies errors, HTTP status of a response and retrying depend on it.
+type Kind int
+
+const (
+	KindInternal     Kind = iota // unexpected failure
+	KindNotFound                 // requested entity doesn't exist
+	KindConflict                 // request conflicts with current state
+	KindTransient                // temporary failure, e.g. lost connection, retry may succeed
+	KindValidation               // invalid input
+	KindUnauthorized             // missing or invalid credentials
+	KindForbidden                // credentials don't allow the request
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindNotFound:
+		return "not_found"
+	case KindConflict:
+		return "conflict"
+	case KindTransient:
+		return "transient"
+	case KindValidation:
+		return "validation"
+	case KindUnauthorized:
+		return "unauthorized"
+	case KindForbidden:
+		return "forbidden"
+	default:
+		return "internal"
+	}
+}
 
 var (
-	ErrWorkerNotFound      = errors.New("worker is not found")
-	ErrOrderNotFound       = errors.New("order is not found")
-	ErrWorkerAlreadyOnline = errors.New("worker already exists and is online")
+	ErrWorkerNotFound      = newSentinel(KindNotFound, "worker is not found")
+	ErrOrderNotFound       = newSentinel(KindNotFound, "order is not found")
+	ErrOrderNotCooking     = newSentinel(KindConflict, "order is not being cooked")
+	ErrOrderNotReceived    = newSentinel(KindConflict, "order is not waiting to be cooked")
+	ErrOrderNotOwned       = newSentinel(KindConflict, "order is no longer cooked by this worker")
+	ErrWorkerAlreadyOnline = newSentinel(KindConflict, "worker already exists and is online")
+	ErrCustomerNotFound    = newSentinel(KindNotFound, "customer is not found")
+	ErrCustomerExists      = newSentinel(KindConflict, "customer with this phone or email already exists")
+	ErrAddressNotFound     = newSentinel(KindNotFound, "address is not found")
+	ErrAddressExists       = newSentinel(KindConflict, "address is already saved")
+	ErrFavoriteNotFound    = newSentinel(KindNotFound, "favorite is not found")
+	ErrFavoriteExists      = newSentinel(KindConflict, "favorite with this name already exists")
+	ErrAPIKeyNotFound      = newSentinel(KindNotFound, "api key is not found")
+	ErrUnauthorized        = newSentinel(KindUnauthorized, "missing or invalid api key")
+	ErrForbidden           = newSentinel(KindForbidden, "api key role is not allowed to access this resource")
+	ErrNotOwnCustomer      = newSentinel(KindForbidden, "api key is not allowed to access this customer")
+	ErrBatchAborted        = newSentinel(KindConflict, "batch is rolled back because of a failed order")
 )
+
+// Error is a domain error: operation which failed, kind of the failure and the stack where
+// the error chain got its first Error. Errors it wraps are matched by errors.Is and errors.As.
+type Error struct {
+	Op   string // e.g. "Service.GetOrderStatus", empty for sentinel errors
+	Kind Kind
+	Err  error
+
+	stack []uintptr
+}
+
+// newSentinel creates error to compare with errors.Is, it has no stack.
+func newSentinel(kind Kind, msg string) *Error {
+	return &Error{Kind: kind, Err: errors.New(msg)}
+}
+
+// NewError creates error of the operation with the given kind. Stack is captured unless err
+// already has one.
+func NewError(op string, kind Kind, err error) error {
+	return newError(op, kind, err)
+}
+
+// Wrap wraps err of the operation keeping its kind, see NewError. Nil err is returned as is.
+func Wrap(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return newError(op, KindOf(err), err)
+}
+
+func newError(op string, kind Kind, err error) *Error {
+	e := &Error{Op: op, Kind: kind, Err: err}
+	if StackOf(err) == nil {
+		pcs := make([]uintptr, 32)
+		n := runtime.Callers(3, pcs) // runtime.Callers, newError, NewError or Wrap
+		e.stack = pcs[:n]
+	}
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Op == "" {
+		return e.Err.Error()
+	}
+	return e.Op + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// StackTrace returns program counters of the stack where the error was created, nil for
+// sentinel errors and errors wrapping ones which have a stack.
+func (e *Error) StackTrace() []uintptr {
+	return e.stack
+}
+
+// KindOf returns kind of the outermost domain error in the chain, KindInternal if there is none.
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return KindInternal
+}
+
+// Message returns message err was created with, without operations it went through, e.g.
+// "order is not found". It may be shown to clients. Internal errors are returned as is.
+func Message(err error) string {
+	if KindOf(err) == KindInternal {
+		return err.Error()
+	}
+
+	var e *Error
+	for errors.As(err, &e) {
+		err = e.Err
+	}
+	return err.Error()
+}
+
+// StackOf returns stack of the innermost error in the chain which has one.
+func StackOf(err error) []uintptr {
+	var stack []uintptr
+	for err != nil {
+		if e, ok := err.(interface{ StackTrace() []uintptr }); ok && e.StackTrace() != nil {
+			stack = e.StackTrace()
+		}
+		err = errors.Unwrap(err)
+	}
+	return stack
+}
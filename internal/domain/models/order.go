@@ -11,6 +11,7 @@ type Order struct {
 	UpdatedAt       time.Time
 	Number          string
 	CustomerName    string
+	CustomerID      *int    // nullable, set when the order is placed by a registered customer
 	Type            string  // 'dine_in', 'takeout', or 'delivery'
 	TableNumber     *int    // nullable
 	DeliveryAddress *string // nullable
@@ -33,10 +34,12 @@ type OrderItem struct {
 type CreateOrder struct {
 	Number          string
 	CustomerName    string
+	CustomerID      *int // Registered customer, customer_name defaults to its name
 	Type            string
 	Items           []CreateOrderItem
 	TableNumber     *int    // Only for dine_in
 	DeliveryAddress *string // Only for delivery
+	AddressID       *int    // Saved address of the customer, only for delivery instead of delivery_address
 	TotalAmount     float64
 	Priority        int
 	Status          string
@@ -92,3 +95,9 @@ type OrderCreatedInfo struct {
 	Status      string
 	TotalAmount float64
 }
+
+// BatchOrderResult is an outcome of an order of a batch, Info is nil if the order failed.
+type BatchOrderResult struct {
+	Info *OrderCreatedInfo
+	Err  error
+}
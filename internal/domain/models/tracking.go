@@ -3,11 +3,29 @@ package models
 import "time"
 
 type OrderStatus struct {
-	OrderNumber string     `json:"order_number"`
-	Status      string     `json:"current_status"`
-	UpdatedAt   time.Time  `json:"updated_at"`
-	Completion  *time.Time `json:"estimated_completion"` // nullable
-	ProcessedBy *string    `json:"processed_by"`         // nullable
+	OrderNumber   string     `json:"order_number"`
+	Status        string     `json:"current_status"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	Completion    *time.Time `json:"estimated_completion"`     // nullable, completion time once the order is ready
+	QueuePosition *int       `json:"queue_position,omitempty"` // orders of the same type ahead, only while waiting
+	ProcessedBy   *string    `json:"processed_by"`             // nullable
+}
+
+// OrderProgress is what ETA of an order is estimated from.
+type OrderProgress struct {
+	Type          string
+	Status        string
+	StatusChanged time.Time // when the order got its current status
+	CompletedAt   *time.Time
+	Ahead         int // orders of the same type waiting in the queue before this one
+	Items         []CreateOrderItem
+}
+
+// CookingOrder is an order of the type being cooked by an online worker, it holds a slot
+// until it is ready.
+type CookingOrder struct {
+	Order   CreateOrder // type and items the cook time is estimated from
+	Started time.Time
 }
 
 type OrderHistory struct {
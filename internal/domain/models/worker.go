@@ -4,7 +4,52 @@ import "time"
 
 type Worker struct {
 	Name            string    `json:"worker_name"`
+	OrderTypes      []string  `json:"order_types,omitempty"`
 	Status          string    `json:"status"`
 	ProcessedOrders int       `json:"orders_processed"`
+	Concurrency     int       `json:"concurrency"`   // number of orders the worker can cook in parallel
+	ActiveOrders    int       `json:"active_orders"` // number of orders being cooked now
 	LastSeen        time.Time `json:"last_seen"`
 }
+
+// WorkerCommand is an administrative command for a running kitchen worker.
+type WorkerCommand struct {
+	ID         int       `json:"id"`
+	WorkerName string    `json:"worker_name"`
+	Command    string    `json:"command"`
+	OrderTypes []string  `json:"order_types,omitempty"` // only for order_types command
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// WorkerSession is a period between worker registration and its going offline.
+type WorkerSession struct {
+	StartedAt       time.Time  `json:"started_at"`
+	EndedAt         *time.Time `json:"ended_at"` // nullable, nil for the current session
+	OrdersProcessed int        `json:"orders_processed"`
+}
+
+// WorkerDetails is a worker with its recent sessions.
+type WorkerDetails struct {
+	Worker
+	Sessions []WorkerSession `json:"sessions"`
+}
+
+// WorkerStats is a performance summary of a worker over all its sessions.
+type WorkerStats struct {
+	WorkerName    string           `json:"worker_name"`
+	Sessions      int              `json:"sessions"`
+	OnlineHours   float64          `json:"online_hours"`
+	Completed     int              `json:"orders_completed"`
+	Failures      int              `json:"failures"`
+	OrdersPerHour float64          `json:"orders_per_hour"`
+	OrderTypes    []OrderTypeStats `json:"order_types"`
+}
+
+// OrderTypeStats is cooking performance of a worker for an order type.
+type OrderTypeStats struct {
+	OrderType    string  `json:"order_type"`
+	Completed    int     `json:"orders_completed"`
+	Failures     int     `json:"failures"` // cooking started but the order was not made ready by the worker
+	MeanCookTime float64 `json:"mean_cook_time_seconds"`
+	P95CookTime  float64 `json:"p95_cook_time_seconds"`
+}
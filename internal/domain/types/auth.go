@@ -0,0 +1,11 @@
+package types
+
+// API key roles, each scopes HTTP routes the key can call
+const (
+	RoleCustomer = "customer" // customer app: places and tracks orders, manages own account
+	RoleKitchen  = "kitchen"  // kitchen staff: watches workers and the kitchen, marks orders ready
+	RoleManager  = "manager"  // everything, including worker control and API keys
+	RolePartner  = "partner"  // delivery partners and aggregators: place and track orders
+)
+
+var AllRoles = []string{RoleCustomer, RoleKitchen, RoleManager, RolePartner}
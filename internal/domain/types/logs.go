@@ -7,6 +7,12 @@ const (
 	ActionRabbitMQConnected = "rabbitmq_connected"
 	ActionWorkerRegistered  = "worker_registered"
 	ActionGracefulShutdown  = "graceful_shutdown"
+	ActionAPIKeyIssued      = "api_key_issued"
+	ActionAPIKeyRevoked     = "api_key_revoked"
+	ActionMigrationApplied  = "migration_applied"
+	ActionMigrationReverted = "migration_reverted"
+	ActionConfigReloaded    = "config_reloaded"
+	ActionLogLevelChanged   = "log_level_changed"
 
 	// Debug level actions
 	ActionWorkerStop              = "worker_stop"
@@ -20,6 +26,13 @@ const (
 	ActionRabbitConnectionClosed  = "rabbitmq_connection_closed"
 	ActionRabbitConnectionClosing = "rabbitmq_connection_closing"
 	ActionRabbitReconnect         = "rabbitmq_reconnect"
+	ActionWorkerReaped            = "worker_reaped"
+	ActionOrderRequeued           = "order_requeued"
+	ActionWorkerCommand           = "worker_command"
+	ActionRequestAudited          = "request_audited"
+	ActionRateLimited             = "rate_limited"
+	ActionMigrationForced         = "migration_forced"
+	ActionConfigReloadRejected    = "config_reload_rejected"
 
 	// Error level actions
 	ActionValidationFailed         = "validation_failed"
@@ -31,4 +44,9 @@ const (
 	ActionDBConnectionFailed       = "db_connection_failed"
 	ActionRabbitConnectionFailed   = "rabbitmq_connection_failed"
 	ActionOrderProccessingFailed   = "order_proccess_failed"
+	ActionAuthFailed               = "auth_failed"
+	ActionMigrationFailed          = "migration_failed"
+	ActionConfigReloadFailed       = "config_reload_failed"
+	ActionRequestFailed            = "request_failed"
+	ActionOpenAPIFailed            = "openapi_failed"
 )
@@ -1,5 +1,7 @@
 package types
 
+import "slices"
+
 type ServiceMode string
 
 const (
@@ -7,4 +9,31 @@ const (
 	ModeKitchenWorker          ServiceMode = "kitchen-worker"
 	ModeTracking               ServiceMode = "tracking-service"
 	ModeNotificationSubscriber ServiceMode = "notification-subscriber"
+
+	// ModeAll runs every service in one process.
+	ModeAll ServiceMode = "all"
+
+	// ModeMigrate runs a migrate command on the database and exits.
+	ModeMigrate ServiceMode = "migrate"
+)
+
+// Commands of ModeMigrate
+const (
+	MigrateUp     = "up"     // apply pending migrations
+	MigrateDown   = "down"   // revert N latest migrations, 1 by default
+	MigrateStatus = "status" // print applied version and pending migrations
+	MigrateForce  = "force"  // set version without running migrations
 )
+
+// AllServiceModes are services started by ModeAll, in start order.
+var AllServiceModes = []ServiceMode{
+	ModeOrder,
+	ModeKitchenWorker,
+	ModeTracking,
+	ModeNotificationSubscriber,
+}
+
+// IsValidServiceMode reports whether mode is a single service mode.
+func IsValidServiceMode(mode ServiceMode) bool {
+	return slices.Contains(AllServiceModes, mode)
+}
@@ -11,6 +11,7 @@ const (
 	StatusOrderReady     = "ready"
 	StatusOrderCompleted = "completed"
 	StatusOrderCancelled = "cancelled"
+	StatusOrderRequeued  = "requeued" // status log only, order is returned to 'received'
 )
 
 // Must be one of: `'dine_in'`, `'takeout'`, or `'delivery'`.
@@ -26,6 +27,12 @@ const (
 	DefaultCookingTime  time.Duration = time.Second * 5
 )
 
+// Cooking modes of kitchen workers
+const (
+	CookingModeSimulated = "simulated" // order is cooked for its estimated time
+	CookingModeManual    = "manual"    // order is cooked until it's marked ready via API
+)
+
 // All order types
 var AllOrderTypes = []string{
 	OrderTypeDineIn,
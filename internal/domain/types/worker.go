@@ -2,5 +2,14 @@ package types
 
 const (
 	WorkerOnline  = "online"
+	WorkerPaused  = "paused"
 	WorkerOffline = "offline"
 )
+
+// Commands to control running kitchen worker
+const (
+	WorkerCommandPause      = "pause"       // stop consuming, orders in progress are finished
+	WorkerCommandResume     = "resume"      // continue consuming after pause
+	WorkerCommandDrain      = "drain"       // finish orders in progress and shut down
+	WorkerCommandOrderTypes = "order_types" // change order types the worker handles
+)
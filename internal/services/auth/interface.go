@@ -0,0 +1,18 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"wheres-my-pizza/internal/domain/models"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key models.APIKey, keyHash string) (models.APIKey, error)
+	Authenticate(ctx context.Context, keyHash string) (models.APIKey, error)
+	MarkUsed(ctx context.Context, id int, precision time.Duration) error
+	Revoke(ctx context.Context, id int) (models.APIKey, error)
+	List(ctx context.Context) ([]models.APIKey, error)
+	AddAudit(ctx context.Context, entry models.AuditEntry) error
+	ListAudit(ctx context.Context, keyID *int, limit int) ([]models.AuditEntry, error)
+}
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"wheres-my-pizza/internal/domain/models"
+	"wheres-my-pizza/internal/domain/types"
+	"wheres-my-pizza/pkg/logger"
+)
+
+const (
+	// keyPrefix marks keys of this service, e.g. in leaked secrets scanners.
+	keyPrefix = "wmp_"
+	// keyBytes is number of random bytes of a key.
+	keyBytes = 32
+	// shownPrefixLen is number of first key characters stored in plain to tell keys apart.
+	shownPrefixLen = 12
+	// adminKeyName is name of the admin key from config in the audit log.
+	adminKeyName = "admin"
+	// lastUsedPrecision is how stale last_used_at of a key may get, authenticating with the key
+	// writes it at most once per this time.
+	lastUsedPrecision = time.Minute
+)
+
+type Service struct {
+	repo     APIKeyRepository
+	adminKey string
+
+	log logger.Logger
+}
+
+// NewService creates API key service. adminKey is a manager key from config which is not stored
+// in the database, empty adminKey disables it.
+func NewService(repo APIKeyRepository, adminKey string, log logger.Logger) *Service {
+	return &Service{
+		repo:     repo,
+		adminKey: adminKey,
+		log:      log,
+	}
+}
+
+// Authenticate returns API key by its plain value. Returns models.ErrUnauthorized if the key
+// is unknown or revoked.
+func (s *Service) Authenticate(ctx context.Context, key string) (models.APIKey, error) {
+	if key == "" {
+		return models.APIKey{}, models.ErrUnauthorized
+	}
+
+	if s.adminKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(s.adminKey)) == 1 {
+		return models.APIKey{Name: adminKeyName, Role: types.RoleManager}, nil
+	}
+
+	apiKey, err := s.repo.Authenticate(ctx, hashKey(key))
+	if err != nil {
+		if errors.Is(err, models.ErrAPIKeyNotFound) {
+			return models.APIKey{}, models.ErrUnauthorized
+		}
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to authenticate api key", err)
+		return models.APIKey{}, fmt.Errorf("failed to authenticate api key: %w", err)
+	}
+
+	if apiKey.LastUsedAt == nil || time.Since(*apiKey.LastUsedAt) > lastUsedPrecision {
+		if err := s.repo.MarkUsed(ctx, apiKey.ID, lastUsedPrecision); err != nil {
+			s.log.Error(ctx, types.ActionDBQueryFailed, "failed to mark api key used", err, "key-id", apiKey.ID)
+		}
+	}
+
+	return apiKey, nil
+}
+
+// IssueKey issues new API key, customerID is the customer of a customer key. The plain key is
+// returned only here.
+func (s *Service) IssueKey(ctx context.Context, name, role string, customerID *int, createdBy string) (models.IssuedAPIKey, error) {
+	key, err := generateKey()
+	if err != nil {
+		return models.IssuedAPIKey{}, fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	apiKey, err := s.repo.Create(ctx, models.APIKey{
+		Name:       name,
+		Role:       role,
+		CustomerID: customerID,
+		Prefix:     key[:shownPrefixLen],
+		CreatedBy:  createdBy,
+	}, hashKey(key))
+	if err != nil {
+		if errors.Is(err, models.ErrCustomerNotFound) {
+			return models.IssuedAPIKey{}, err
+		}
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to store api key", err)
+		return models.IssuedAPIKey{}, fmt.Errorf("failed to store api key: %w", err)
+	}
+
+	s.log.Info(ctx, types.ActionAPIKeyIssued, "api key issued", "key-id", apiKey.ID, "key-name", name, "role", role, "issued-by", createdBy)
+
+	return models.IssuedAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+// RevokeKey revokes API key, requests with it are rejected right away.
+func (s *Service) RevokeKey(ctx context.Context, id int, revokedBy string) (models.APIKey, error) {
+	apiKey, err := s.repo.Revoke(ctx, id)
+	if err != nil {
+		if errors.Is(err, models.ErrAPIKeyNotFound) {
+			return models.APIKey{}, err
+		}
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to revoke api key", err, "key-id", id)
+		return models.APIKey{}, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	s.log.Info(ctx, types.ActionAPIKeyRevoked, "api key revoked", "key-id", id, "key-name", apiKey.Name, "revoked-by", revokedBy)
+
+	return apiKey, nil
+}
+
+// ListKeys returns all issued API keys, revoked included.
+func (s *Service) ListKeys(ctx context.Context) ([]models.APIKey, error) {
+	keys, err := s.repo.List(ctx)
+	if err != nil {
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to list api keys", err)
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Audit records an authenticated request.
+func (s *Service) Audit(ctx context.Context, entry models.AuditEntry) {
+	if err := s.repo.AddAudit(ctx, entry); err != nil {
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to write audit log", err, "key-name", entry.KeyName, "path", entry.Path)
+	}
+}
+
+// ListAudit returns latest audited requests, only of the key if keyID is not nil.
+func (s *Service) ListAudit(ctx context.Context, keyID *int, limit int) ([]models.AuditEntry, error) {
+	entries, err := s.repo.ListAudit(ctx, keyID, limit)
+	if err != nil {
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to list audit log", err)
+		return nil, fmt.Errorf("failed to list audit log: %w", err)
+	}
+
+	return entries, nil
+}
+
+func generateKey() (string, error) {
+	b := make([]byte, keyBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return keyPrefix + hex.EncodeToString(b), nil
+}
+
+// hashKey hashes API key to store and look it up. Keys are long random strings,
+// so plain sha256 is enough and lookup stays a single indexed query.
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,108 @@
+package kitchen
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"wheres-my-pizza/internal/domain/models"
+	"wheres-my-pizza/internal/domain/types"
+)
+
+// pollCommands takes pending commands of the worker and applies them in order they were sent.
+func (s *KitchenWorker) pollCommands(ctx context.Context) {
+	commands, err := s.workerRepo.TakeCommands(ctx, s.worker.name)
+	if err != nil {
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to take worker commands", err, "worker-name", s.worker.name)
+		return
+	}
+
+	for _, cmd := range commands {
+		s.log.Info(ctx, types.ActionWorkerCommand, "applying worker command", "worker-name", s.worker.name, "command", cmd.Command)
+		s.applyCommand(ctx, cmd)
+	}
+}
+
+func (s *KitchenWorker) applyCommand(ctx context.Context, cmd models.WorkerCommand) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Stopped or stopping, consumers must not be started again.
+	if !s.isWorking || s.workCtx.Err() != nil {
+		return
+	}
+
+	switch cmd.Command {
+	case types.WorkerCommandPause:
+		s.pause(ctx)
+	case types.WorkerCommandResume:
+		s.resume(ctx)
+	case types.WorkerCommandDrain:
+		// Service stops the worker, it is drained the same way as on shutdown.
+		select {
+		case s.errCh <- ErrWorkerDrained:
+		default:
+		}
+	case types.WorkerCommandOrderTypes:
+		s.setOrderTypes(ctx, cmd.OrderTypes)
+	default:
+		s.log.Warn(ctx, types.ActionWorkerCommand, "unknown worker command", "worker-name", s.worker.name, "command", cmd.Command)
+	}
+}
+
+// pause stops all consumers, orders in progress are finished and prefetched ones are requeued.
+func (s *KitchenWorker) pause(ctx context.Context) {
+	if s.paused {
+		return
+	}
+
+	for orderType := range s.consumers {
+		s.stopConsuming(orderType)
+	}
+	s.paused = true
+
+	if err := s.workerRepo.SetStatus(ctx, s.worker.name, types.WorkerPaused); err != nil {
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to set worker status", err, "worker-name", s.worker.name)
+	}
+}
+
+// resume starts consumers of all order types of the worker.
+func (s *KitchenWorker) resume(ctx context.Context) {
+	if !s.paused {
+		return
+	}
+
+	for _, orderType := range s.worker.orderTypes {
+		s.startConsuming(orderType)
+	}
+	s.paused = false
+
+	if err := s.workerRepo.SetStatus(ctx, s.worker.name, types.WorkerOnline); err != nil {
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to set worker status", err, "worker-name", s.worker.name)
+	}
+}
+
+// setOrderTypes stops consumers of removed order types and starts consumers of added ones.
+func (s *KitchenWorker) setOrderTypes(ctx context.Context, orderTypes []string) {
+	if len(orderTypes) == 0 {
+		s.log.Warn(ctx, types.ActionWorkerCommand, "empty order types, command ignored", "worker-name", s.worker.name)
+		return
+	}
+
+	for _, orderType := range s.worker.orderTypes {
+		if !slices.Contains(orderTypes, orderType) {
+			s.stopConsuming(orderType)
+		}
+	}
+
+	if !s.paused {
+		for _, orderType := range orderTypes {
+			s.startConsuming(orderType)
+		}
+	}
+	s.worker.orderTypes = orderTypes
+
+	if err := s.workerRepo.SetOrderTypes(ctx, s.worker.name, strings.Join(orderTypes, ",")); err != nil {
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to set worker order types", err, "worker-name", s.worker.name)
+	}
+}
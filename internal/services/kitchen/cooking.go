@@ -0,0 +1,62 @@
+package kitchen
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"wheres-my-pizza/internal/domain/models"
+)
+
+// SimulatedKitchen cooks an order for its estimated time.
+type SimulatedKitchen struct{}
+
+func NewSimulatedKitchen() *SimulatedKitchen {
+	return &SimulatedKitchen{}
+}
+
+func (k *SimulatedKitchen) Cook(ctx context.Context, _ *models.CreateOrder, estimate time.Duration) error {
+	timer := time.NewTimer(estimate)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ManualKitchen is a real kitchen: an order is cooked until a cook marks it ready via API.
+type ManualKitchen struct {
+	orderRepo    ReadyMarkRepository
+	pollInterval time.Duration
+}
+
+func NewManualKitchen(orderRepo ReadyMarkRepository, pollInterval time.Duration) *ManualKitchen {
+	return &ManualKitchen{
+		orderRepo:    orderRepo,
+		pollInterval: pollInterval,
+	}
+}
+
+// Cook waits until the order is marked ready, estimate is used only for the expected completion.
+func (k *ManualKitchen) Cook(ctx context.Context, order *models.CreateOrder, _ time.Duration) error {
+	ticker := time.NewTicker(k.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			ready, err := k.orderRepo.IsMarkedReady(ctx, order.Number)
+			if err != nil {
+				return fmt.Errorf("failed to check ready mark: %w", err)
+			}
+			if ready {
+				return nil
+			}
+		}
+	}
+}
@@ -10,25 +10,53 @@ import (
 // Repository contract
 type WorkerRepository interface {
 	// MarkOnline marks worker by inserting (or updating) a record in the
-	// workers table with its unique name and type, marking it online.
-	MarkOnline(ctx context.Context, name, orderTypes string, heartbeat time.Duration) error
+	// workers table with its unique name, type and concurrency, marking it online.
+	MarkOnline(ctx context.Context, name, orderTypes string, concurrency int, heartbeat time.Duration) error
 
 	// MarkOffline marks worker offline.
 	MarkOffline(ctx context.Context, name string) error
 
-	// UpdateLastSeen updates last seen timestamp
-	UpdateLastSeen(ctx context.Context, name string) error
+	// UpdateLastSeen updates last seen timestamp and heartbeat interval, a worker marked offline
+	// meanwhile gets status back (online or paused).
+	UpdateLastSeen(ctx context.Context, name, status string, heartbeat time.Duration) error
 
 	// Incerements number of proccessed orders for worker.
 	IncrOrdersProcessed(ctx context.Context, name string) error
+
+	// AddActiveOrders changes number of orders being cooked by the worker.
+	AddActiveOrders(ctx context.Context, name string, delta int) error
+
+	// SetStatus sets worker status (online or paused).
+	SetStatus(ctx context.Context, name, status string) error
+
+	// SetOrderTypes sets comma-separated list of order types the worker handles.
+	SetOrderTypes(ctx context.Context, name, orderTypes string) error
+
+	// TakeCommands returns pending commands of the worker and marks them processed.
+	TakeCommands(ctx context.Context, name string) ([]models.WorkerCommand, error)
 }
 
 type OrderRepository interface {
-	// SetStatus sets new status and returns old status
+	// SetStatus sets new status and returns old status. Cooking starts only for a 'received' order,
+	// otherwise models.ErrOrderNotReceived is returned. Other statuses are set only by the worker
+	// cooking the order, otherwise models.ErrOrderNotOwned is returned.
 	SetStatus(ctx context.Context, orderNumber, workerName, status string, notes string) (string, error)
 }
 
+type ReadyMarkRepository interface {
+	// IsMarkedReady reports if the order being cooked was marked ready by a cook.
+	IsMarkedReady(ctx context.Context, orderNumber string) (bool, error)
+}
+
+// Kitchen cooks orders.
+type Kitchen interface {
+	// Cook returns when the order is cooked, or ctx error if cooking was interrupted.
+	Cook(ctx context.Context, order *models.CreateOrder, estimate time.Duration) error
+}
+
 type Consumer interface {
+	// Consume calls handler for every order of orderType. Handlers may run concurrently,
+	// every message is acknowledged separately once its handler returns.
 	Consume(ctx context.Context, orderType string, handler func(ctx context.Context, req *models.CreateOrder) error) error
 }
 
@@ -15,8 +15,11 @@ import (
 )
 
 var (
-	ErrWorkerStopped = errors.New("worker stopped")
-	ErrNilOrder      = errors.New("nil order")
+	ErrWorkerStopped      = errors.New("worker stopped")
+	ErrWorkerDrained      = errors.New("worker drained by command")
+	ErrWorkerStopping     = errors.New("worker is stopping, cannot process new orders")
+	ErrCookingInterrupted = errors.New("cooking interrupted")
+	ErrNilOrder           = errors.New("nil order")
 )
 
 type (
@@ -26,21 +29,37 @@ type (
 		consumer   Consumer
 		producer   Producer
 
+		kitchen      Kitchen
+		cookingModel *models.CookingModel
+
 		isWorking bool
 		worker    *worker
 
-		mu           sync.Mutex
-		cancel       func()
-		activeOrders sync.WaitGroup // activeOrders for monitor processing orders
-		stopping     chan struct{}  // stopping channel to stop signal for proccessing orders
+		mu            sync.Mutex
+		cancel        func()          // stops consuming
+		workCtx       context.Context // context of Work, consumers are started with it
+		stopHeartbeat func()          // stops heartbeats, they go on while the worker is drained
+		heartbeatWG   sync.WaitGroup
+		errCh         chan<- error
+		consumers     map[string]context.CancelFunc // running consumers by order type
+		consumersWG   sync.WaitGroup
+		paused        bool
+		abortCooking  func()             // interrupts orders being cooked when drain deadline is reached
+		cookCtx       context.Context    // cancelled by abortCooking
+		drainTimeout  time.Duration      // time given to active orders to finish on stop
+		drainMu       sync.RWMutex       // orders are not started while stopping is being closed
+		activeOrders  sync.WaitGroup     // activeOrders for monitor processing orders
+		stopping      chan struct{}      // stopping channel to stop signal for proccessing orders
+		heartbeatCh   chan time.Duration // new heartbeat intervals, see SetHeartbeat
 
 		log logger.Logger
 	}
 
 	worker struct {
-		name       string
-		orderTypes []string // Comma-separated list of order types the worker can handle (e.g., `dine_in,takeout`). If omitted, handles all.
-		heartbeat  time.Duration
+		name        string
+		orderTypes  []string // Comma-separated list of order types the worker can handle (e.g., `dine_in,takeout`). If omitted, handles all.
+		concurrency int      // number of orders cooked in parallel
+		heartbeat   time.Duration
 	}
 )
 
@@ -50,11 +69,17 @@ func NewWorker(
 	orderRepo OrderRepository,
 	consumer Consumer,
 	producer Producer,
+	kitchen Kitchen,
+	cookingModel *models.CookingModel,
 	workerName string,
 	orderTypes []string,
+	concurrency int,
 	heartbeat time.Duration,
+	drainTimeout time.Duration,
 	log logger.Logger,
 ) *KitchenWorker {
+	cookCtx, abortCooking := context.WithCancel(context.Background())
+
 	return &KitchenWorker{
 		workerRepo: workerRepo,
 		orderRepo:  orderRepo,
@@ -62,29 +87,37 @@ func NewWorker(
 		producer:   producer,
 		mu:         sync.Mutex{},
 
+		kitchen:      kitchen,
+		cookingModel: cookingModel,
+
 		worker: &worker{
-			name:       workerName,
-			orderTypes: orderTypes,
-			heartbeat:  heartbeat,
+			name:        workerName,
+			orderTypes:  orderTypes,
+			concurrency: concurrency,
+			heartbeat:   heartbeat,
 		},
 
+		consumers:    make(map[string]context.CancelFunc),
+		cookCtx:      cookCtx,
+		abortCooking: abortCooking,
+		drainTimeout: drainTimeout,
 		activeOrders: sync.WaitGroup{},
 		stopping:     make(chan struct{}),
+		heartbeatCh:  make(chan time.Duration, 1),
 
 		log: log,
 	}
 }
 
-// Work starts consuming orders and proccesses them
+// Work starts consuming orders and proccesses them. It returns when ctx is cancelled or
+// the worker is stopped, orders in progress are drained by Stop.
 func (s *KitchenWorker) Work(ctx context.Context, errCh chan<- error) {
-	defer func() {
-		// stop the worker from consuming and updating database.
-		s.Stop(ctx)
-		errCh <- ErrWorkerStopped
-	}()
-
 	ctx, cancel := context.WithCancel(ctx)
+	s.mu.Lock()
 	s.cancel = cancel
+	s.workCtx = ctx
+	s.errCh = errCh
+	s.mu.Unlock()
 
 	// marking kitchen-worker as 'online'
 	if err := s.markOnline(ctx); err != nil {
@@ -93,79 +126,180 @@ func (s *KitchenWorker) Work(ctx context.Context, errCh chan<- error) {
 		return
 	}
 
-	wg := sync.WaitGroup{}
+	defer func() {
+		select {
+		case errCh <- ErrWorkerStopped:
+		default:
+		}
+	}()
+
+	// Heartbeats are not stopped with ctx: Stop drains the worker first and it must not be taken
+	// for dead meanwhile.
+	heartbeatCtx, stopHeartbeat := context.WithCancel(context.WithoutCancel(ctx))
+
+	s.mu.Lock()
+	if !s.isWorking {
+		// stopped already
+		s.mu.Unlock()
+		stopHeartbeat()
+		return
+	}
 	for _, ot := range s.worker.orderTypes {
-		wg.Add(1)
-		go func(ot string) {
-			defer func() {
-				wg.Done()
-				s.log.Info(ctx, "kitchen_worker_stop_consume", "stopped consuming orders", "order-type", ot)
-			}()
-
-			// Start consuming
-			err := s.consumer.Consume(ctx, ot, s.processOrderWrapper)
-			if err != nil {
-				select {
-				case errCh <- fmt.Errorf("failed to start consuming: %w", err):
-				default:
-					s.log.Error(ctx, "error_channel_full", "failed to send error to channel", err)
-				}
-			}
-		}(ot)
+		s.startConsuming(ot)
 	}
+	s.stopHeartbeat = stopHeartbeat
+	heartbeat := s.worker.heartbeat
+	s.heartbeatWG.Add(1)
+	s.mu.Unlock()
 
 	go func() {
-		s.heartbeatLoop(ctx, s.worker.heartbeat)
+		defer s.heartbeatWG.Done()
+		s.heartbeatLoop(heartbeatCtx, heartbeat)
 	}()
 
-	wg.Wait()
+	<-ctx.Done()
+	s.consumersWG.Wait()
 }
 
-// Stop worker from work
+// startConsuming starts consumer of the order type. Must be called with s.mu held.
+func (s *KitchenWorker) startConsuming(orderType string) {
+	if _, ok := s.consumers[orderType]; ok {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(s.workCtx)
+	s.consumers[orderType] = cancel
+
+	s.consumersWG.Add(1)
+	go func() {
+		defer func() {
+			s.consumersWG.Done()
+			s.log.Info(ctx, "kitchen_worker_stop_consume", "stopped consuming orders", "order-type", orderType)
+		}()
+
+		// Start consuming
+		err := s.consumer.Consume(ctx, orderType, s.processOrderWrapper)
+		if err != nil {
+			select {
+			case s.errCh <- fmt.Errorf("failed to start consuming: %w", err):
+			default:
+				s.log.Error(ctx, "error_channel_full", "failed to send error to channel", err)
+			}
+		}
+	}()
+}
+
+// stopConsuming stops consumer of the order type, its orders in progress are finished.
+// Must be called with s.mu held.
+func (s *KitchenWorker) stopConsuming(orderType string) {
+	if cancel, ok := s.consumers[orderType]; ok {
+		cancel()
+		delete(s.consumers, orderType)
+	}
+}
+
+// Stop drains the worker: it stops consuming (prefetched orders are requeued), lets orders
+// in progress finish within drain timeout and interrupts the rest, which are rolled back to
+// 'received' and requeued. Then the worker is marked offline.
+//
+// s.mu is held only to mark the worker stopped, heartbeats and reloads go on while it's drained.
 func (s *KitchenWorker) Stop(ctx context.Context) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	if !s.isWorking {
+		s.mu.Unlock()
 		return
 	}
+	s.isWorking = false
+	cancel, stopHeartbeat := s.cancel, s.stopHeartbeat
+	s.mu.Unlock()
 
 	// stop proccessing new orders
+	s.drainMu.Lock()
 	close(s.stopping)
-	s.log.Debug(ctx, types.ActionWorkerStop, "waiting for active orders to finish", "worker-name", s.worker.name)
-	// waiting for active proccesses to finish
-	s.activeOrders.Wait()
+	s.drainMu.Unlock()
 
-	// Cancel context
-	if s.cancel != nil {
-		s.cancel()
+	// stop consuming
+	if cancel != nil {
+		cancel()
 	}
 
+	s.log.Debug(ctx, types.ActionWorkerStop, "waiting for active orders to finish", "worker-name", s.worker.name, "timeout", utils.PrettyDuration(s.drainTimeout))
+	s.drain(ctx)
+
+	// A heartbeat after the worker is marked offline would bring it back online.
+	if stopHeartbeat != nil {
+		stopHeartbeat()
+	}
+	s.heartbeatWG.Wait()
+
 	// Mark worker offline
 	if err := s.workerRepo.MarkOffline(ctx, s.worker.name); err != nil {
 		s.log.Error(ctx, types.ActionWorkerStop, "failed to mark worker offline", err, "worker-name", s.worker.name)
 		return
 	}
-	s.isWorking = false
 
 	s.log.Info(ctx, "worker_stop", "stopping worker", "worker-name", s.worker.name)
 }
 
+// drain waits for active orders until drain timeout or ctx is done, then interrupts
+// cooking and waits for interrupted orders to be rolled back.
+func (s *KitchenWorker) drain(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		s.activeOrders.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(s.drainTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return
+	case <-timer.C:
+		s.log.Warn(ctx, types.ActionWorkerStop, "drain timeout reached, interrupting active orders", "worker-name", s.worker.name)
+	case <-ctx.Done():
+		s.log.Warn(ctx, types.ActionWorkerStop, "context done while draining, interrupting active orders", "worker-name", s.worker.name)
+	}
+
+	s.abortCooking()
+	<-done
+}
+
 func (s *KitchenWorker) processOrderWrapper(ctx context.Context, req *models.CreateOrder) error {
 	// Check if order proccessing stoppped.
+	s.drainMu.RLock()
 	select {
 	case <-s.stopping:
+		s.drainMu.RUnlock()
 		s.log.Info(ctx, types.ActionWorkerStop, "rejecting new order due to worker stopping", "order-number", req.Number)
-		return errors.New("worker is stopping, cannot process new orders")
+		return ErrWorkerStopping
 	default:
 	}
-
 	s.activeOrders.Add(1)
+	s.drainMu.RUnlock()
 	defer s.activeOrders.Done()
 
+	s.addActiveOrders(ctx, 1)
+	defer s.addActiveOrders(context.WithoutCancel(ctx), -1)
+
+	// Cooking is interrupted only when drain deadline is reached.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(s.cookCtx, cancel)
+	defer stop()
+
 	return s.proccessOrder(ctx, req)
 }
 
+// addActiveOrders reflects number of busy slots of the worker in the database.
+// It's informational only, so failures don't stop the order.
+func (s *KitchenWorker) addActiveOrders(ctx context.Context, delta int) {
+	if err := s.workerRepo.AddActiveOrders(ctx, s.worker.name, delta); err != nil {
+		s.log.Warn(ctx, types.ActionDBQueryFailed, "failed to update number of active orders", "worker-name", s.worker.name, "error", err)
+	}
+}
+
 // proccessOrder processes created order
 func (s *KitchenWorker) proccessOrder(ctx context.Context, req *models.CreateOrder) error {
 	if req == nil {
@@ -173,7 +307,7 @@ func (s *KitchenWorker) proccessOrder(ctx context.Context, req *models.CreateOrd
 		return ErrNilOrder
 	}
 
-	cookingTime := types.GetSimulateCookingDuration(req.Type) // Simulated time
+	cookingTime := s.cookingModel.Estimate(req)
 
 	s.log.Debug(
 		ctx,
@@ -185,6 +319,11 @@ func (s *KitchenWorker) proccessOrder(ctx context.Context, req *models.CreateOrd
 
 	// Set status cooking
 	oldStatus, err := s.orderRepo.SetStatus(ctx, req.Number, s.worker.name, types.StatusOrderCooking, "")
+	if errors.Is(err, models.ErrOrderNotReceived) {
+		// Redelivered message of an order which is cooked by someone else or ready, it's dropped.
+		s.log.Warn(ctx, types.ActionMessageProcessingFailed, "order is not waiting to be cooked, message dropped", "worker-name", s.worker.name, "order-number", req.Number)
+		return nil
+	}
 	if err != nil {
 		s.log.Error(ctx, types.ActionMessageProcessingFailed, "failed to set cooking status for order", err, "worker-name", s.worker.name)
 		return fmt.Errorf("failed to set cooking status for order : %w", err)
@@ -213,16 +352,29 @@ func (s *KitchenWorker) proccessOrder(ctx context.Context, req *models.CreateOrd
 		s.log.Warn(ctx, types.ActionMessageProcessingFailed, "order status changed to cooking, but could not increment number of proccessed order for worker in the database", "worker-name", s.worker.name)
 	}
 
-	// Simulating working process with context cancellation support
-	select {
-	case <-time.After(cookingTime):
-		// cooked
-	case <-ctx.Done():
-		s.log.Warn(ctx, types.ActionMessageProcessingFailed, "order processing interrupted but completing", "order-number", req.Number, "context-error", ctx.Err())
+	// Cooking, simulated or real, with context cancellation support
+	if err := s.kitchen.Cook(ctx, req, cookingTime); err != nil {
+		if ctx.Err() != nil {
+			s.log.Warn(ctx, types.ActionMessageProcessingFailed, "order processing interrupted, returning order to the queue", "order-number", req.Number, "context-error", ctx.Err())
+		} else {
+			s.log.Error(ctx, types.ActionMessageProcessingFailed, "failed to cook order, returning order to the queue", err, "order-number", req.Number)
+		}
+		if !s.rollbackOrder(context.WithoutCancel(ctx), req, requestID) {
+			return nil // another worker has the order, the message is dropped
+		}
+		return fmt.Errorf("%w: order %s", ErrCookingInterrupted, req.Number)
 	}
 
+	// The order is cooked, finishing it even if drain deadline is reached meanwhile.
+	ctx = context.WithoutCancel(ctx)
+
 	// Set status ready
 	oldStatus, err = s.orderRepo.SetStatus(ctx, req.Number, s.worker.name, types.StatusOrderReady, "")
+	if errors.Is(err, models.ErrOrderNotOwned) {
+		// The order was requeued while it was cooked here, the worker which took it makes it ready.
+		s.log.Warn(ctx, types.ActionMessageProcessingFailed, "order is no longer cooked by this worker, message dropped", "worker-name", s.worker.name, "order-number", req.Number)
+		return nil
+	}
 	if err != nil {
 		s.log.Error(ctx, types.ActionMessageProcessingFailed, "failed to set ready status for order", err, "worker-name", s.worker.name)
 		return fmt.Errorf("failed to set ready status for order: %w", err)
@@ -253,6 +405,33 @@ func (s *KitchenWorker) proccessOrder(ctx context.Context, req *models.CreateOrd
 	return nil
 }
 
+// rollbackOrder returns half-cooked order to 'received', so it can be cooked again by another worker.
+// Returns false if the order is no longer cooked by this worker, then it's left as is.
+func (s *KitchenWorker) rollbackOrder(ctx context.Context, req *models.CreateOrder, requestID string) bool {
+	oldStatus, err := s.orderRepo.SetStatus(ctx, req.Number, s.worker.name, types.StatusOrderReceived, "cooking interrupted, order returned to the queue")
+	if errors.Is(err, models.ErrOrderNotOwned) {
+		s.log.Warn(ctx, types.ActionMessageProcessingFailed, "order is no longer cooked by this worker, message dropped", "worker-name", s.worker.name, "order-number", req.Number)
+		return false
+	}
+	if err != nil {
+		s.log.Error(ctx, types.ActionMessageProcessingFailed, "failed to roll back order status", err, "worker-name", s.worker.name, "order-number", req.Number)
+		return true
+	}
+
+	if err := s.producer.StatusUpdate(ctx, &models.StatusUpdate{
+		OrderNumber: req.Number,
+		OldStatus:   oldStatus,
+		NewStatus:   types.StatusOrderReceived,
+		ChangedBy:   s.worker.name,
+		Timestamp:   time.Now(),
+		RequestID:   requestID,
+	}); err != nil {
+		s.log.Error(ctx, types.ActionRabbitMQPublishFailed, "failed to publish status update", err, "worker-name", s.worker.name)
+	}
+
+	return true
+}
+
 // heartbeatLoop tries to update last seen field in database each heartbeat interval.
 func (s *KitchenWorker) heartbeatLoop(ctx context.Context, interval time.Duration) {
 	ticker := time.NewTicker(interval)
@@ -262,16 +441,48 @@ func (s *KitchenWorker) heartbeatLoop(ctx context.Context, interval time.Duratio
 		case <-ctx.Done():
 			s.log.Info(ctx, "worker_hearbeat_stop", "stopped hearbeat loop")
 			return
+		case interval := <-s.heartbeatCh:
+			ticker.Reset(interval)
 		case <-ticker.C:
-			if err := s.workerRepo.UpdateLastSeen(ctx, s.worker.name); err != nil {
+			status, heartbeat := s.heartbeatState()
+			if err := s.workerRepo.UpdateLastSeen(ctx, s.worker.name, status, heartbeat); err != nil {
 				s.log.Error(ctx, types.ActionDBQueryFailed, "failed to update last seen on worker", err, "worker-name", s.worker.name)
 				continue
 			}
 			s.log.Debug(ctx, types.ActionHeartbeatSent, "heartbeat was sent", "worker-name", s.worker.name)
+
+			s.pollCommands(ctx)
 		}
 	}
 }
 
+// heartbeatState returns status and heartbeat interval the worker reports with heartbeats.
+func (s *KitchenWorker) heartbeatState() (string, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.paused {
+		return types.WorkerPaused, s.worker.heartbeat
+	}
+	return types.WorkerOnline, s.worker.heartbeat
+}
+
+// SetHeartbeat changes heartbeat interval of the running worker, the next heartbeat is sent
+// after the new interval.
+func (s *KitchenWorker) SetHeartbeat(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.worker.heartbeat = interval
+
+	// Replacing interval which wasn't applied yet.
+	select {
+	case <-s.heartbeatCh:
+	default:
+	}
+	s.heartbeatCh <- interval
+}
+
 // markOnline marks kitchen-worker as online
 func (s *KitchenWorker) markOnline(ctx context.Context) error {
 	s.mu.Lock()
@@ -286,7 +497,7 @@ func (s *KitchenWorker) markOnline(ctx context.Context) error {
 	workerOrderTypes := strings.Join(s.worker.orderTypes, ",")
 
 	// Marking worker as online
-	if err := s.workerRepo.MarkOnline(ctx, s.worker.name, workerOrderTypes, s.worker.heartbeat); err != nil {
+	if err := s.workerRepo.MarkOnline(ctx, s.worker.name, workerOrderTypes, s.worker.concurrency, s.worker.heartbeat); err != nil {
 		return err
 	}
 	s.isWorking = true
@@ -296,6 +507,7 @@ func (s *KitchenWorker) markOnline(ctx context.Context) error {
 		"worker was successfully registered",
 		"worker-name", s.worker.name,
 		"order-types", workerOrderTypes,
+		"concurrency", s.worker.concurrency,
 		"heartbeat-interval", utils.PrettyDuration(s.worker.heartbeat),
 	)
 
@@ -0,0 +1,163 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"wheres-my-pizza/internal/domain/models"
+	"wheres-my-pizza/internal/domain/types"
+)
+
+// CreateOrders creates orders of a batch and returns result of every order by its index.
+//
+// Order numbers are allocated at once, orders are stored in one transaction and published in a batch
+// with publisher confirms. Without partial a failed order fails the whole batch: nothing is stored and
+// models.ErrBatchAborted is returned with results holding the error. In partial mode failed orders are
+// reported and the others are created. An order stored but not published is reported failed as
+// CreateOrder does.
+func (s *Service) CreateOrders(ctx context.Context, reqs []*models.CreateOrder, partial bool) ([]models.BatchOrderResult, error) {
+	switch {
+	case len(reqs) == 0:
+		return nil, ErrEmptyBatch
+	case len(reqs) > s.cfg.Load().Services.Order.BatchMaxSize:
+		return nil, ErrBatchTooLarge
+	}
+
+	s.log.Debug(ctx, types.ActionOrderReceived, "creating batch of orders", "orders", len(reqs), "partial", partial)
+
+	// The batch takes one slot as a single order does.
+	if !s.sem.TryAcquire(s.cfg.Load().Services.Order.SemWait) {
+		s.log.Error(ctx, types.ActionOrderProccessingFailed, "failed to proccess batch of orders", ErrTooManyRequest)
+		return nil, ErrTooManyRequest
+	}
+	defer s.sem.Release()
+
+	results := make([]models.BatchOrderResult, len(reqs))
+
+	// Orders still in the batch with their indexes
+	pending := make([]*models.CreateOrder, 0, len(reqs))
+	indexes := make([]int, 0, len(reqs))
+	for i, req := range reqs {
+		if err := s.resolveCustomer(ctx, req); err != nil {
+			results[i].Err = err
+			if !partial {
+				return results, models.ErrBatchAborted
+			}
+			continue
+		}
+		pending = append(pending, req)
+		indexes = append(indexes, i)
+	}
+
+	if len(pending) == 0 {
+		return results, nil
+	}
+
+	today := todayDate()
+	last, err := s.orderRepo.GetAndIncrementSequence(ctx, today, len(pending))
+	if err != nil {
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to get next order sequence. generating random order_number", err)
+	}
+
+	for j, req := range pending {
+		if err != nil {
+			req.SetNumber(today, getRandomOrderNumber())
+		} else {
+			req.SetNumber(today, last-len(pending)+1+j)
+		}
+		req.CalucalteTotalAmount()
+		req.CalculatePriority()
+		req.Status = types.StatusOrderReceived
+	}
+
+	// Store orders to database
+	orders, storeErrs, err := s.orderRepo.CreateBatch(ctx, pending, servicename, "batch", partial)
+	if err != nil {
+		if errors.Is(err, models.ErrBatchAborted) {
+			for j, storeErr := range storeErrs {
+				results[indexes[j]].Err = storeErr
+			}
+			s.log.Error(ctx, types.ActionDBTransactionFailed, "batch of orders is rolled back", err)
+			return results, err
+		}
+		s.log.Error(ctx, types.ActionDBTransactionFailed, "failed to create batch of orders", err)
+		return nil, fmt.Errorf("failed to create batch of orders: %w", err)
+	}
+
+	// Orders stored to database are published
+	stored := make([]*models.CreateOrder, 0, len(pending))
+	storedIndexes := make([]int, 0, len(pending))
+	for j, storeErr := range storeErrs {
+		if storeErr != nil {
+			s.log.Error(ctx, types.ActionDBTransactionFailed, "failed to create order of the batch", storeErr, "index", indexes[j])
+			results[indexes[j]].Err = fmt.Errorf("failed to create new order: %w", storeErr)
+			continue
+		}
+		stored = append(stored, pending[j])
+		storedIndexes = append(storedIndexes, indexes[j])
+		results[indexes[j]].Info = &models.OrderCreatedInfo{
+			Number:      orders[j].Number,
+			Status:      orders[j].Status,
+			TotalAmount: orders[j].TotalAmount,
+		}
+	}
+
+	publishErrs := s.publishBatch(ctx, stored)
+	for k, publishErr := range publishErrs {
+		if publishErr != nil {
+			s.log.Error(ctx, types.ActionDBQueryFailed, "order stored to database, but not published", publishErr, "order-number", stored[k].Number)
+			results[storedIndexes[k]] = models.BatchOrderResult{Err: fmt.Errorf("failed to publish order: %w", publishErr)}
+		}
+	}
+
+	return results, nil
+}
+
+// publishBatch publishes orders in a batch, orders which were not published because of transient
+// errors are retried.
+// Returns error of every order by its index.
+func (s *Service) publishBatch(ctx context.Context, orders []*models.CreateOrder) []error {
+	errs := make([]error, len(orders))
+	if len(orders) == 0 {
+		return errs
+	}
+
+	// Indexes of orders to publish
+	left := make([]int, len(orders))
+	for i := range orders {
+		left[i] = i
+	}
+
+	conn := s.cfg.Load().RabbitMQ.Conn
+	_ = retry(conn.ReconnectAttempt, conn.ReconnectDelay, func() error {
+		batch := make([]*models.CreateOrder, len(left))
+		for k, i := range left {
+			batch[k] = orders[i]
+		}
+
+		published, err := s.writer.PublishCreateOrders(ctx, batch)
+		if err != nil {
+			for _, i := range left {
+				errs[i] = err
+			}
+			return err
+		}
+
+		failed := left[:0]
+		for k, i := range left {
+			errs[i] = published[k]
+			if models.KindOf(published[k]) == models.KindTransient {
+				failed = append(failed, i)
+			}
+		}
+		left = failed
+
+		if len(left) > 0 {
+			return errs[left[0]]
+		}
+		return nil
+	})
+
+	return errs
+}
@@ -0,0 +1,113 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"wheres-my-pizza/internal/domain/models"
+	"wheres-my-pizza/internal/domain/types"
+)
+
+// resolveCustomer fills the order from the customer account: customer name if it is not given and
+// delivery address from the saved address.
+func (s *Service) resolveCustomer(ctx context.Context, req *models.CreateOrder) error {
+	if req.CustomerID == nil {
+		return nil
+	}
+
+	customer, err := s.customerRepo.Get(ctx, *req.CustomerID)
+	if err != nil {
+		if errors.Is(err, models.ErrCustomerNotFound) {
+			return err
+		}
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to get customer", err, "customer-id", *req.CustomerID)
+		return fmt.Errorf("failed to get customer: %w", err)
+	}
+
+	if req.CustomerName == "" {
+		req.CustomerName = customer.Name
+	}
+
+	if req.AddressID != nil {
+		address, err := s.customerRepo.GetAddress(ctx, customer.ID, *req.AddressID)
+		if err != nil {
+			if errors.Is(err, models.ErrAddressNotFound) {
+				return err
+			}
+			s.log.Error(ctx, types.ActionDBQueryFailed, "failed to get customer address", err, "customer-id", customer.ID)
+			return fmt.Errorf("failed to get customer address: %w", err)
+		}
+		req.DeliveryAddress = &address.Address
+	}
+
+	return nil
+}
+
+// CreateCustomer creates customer account
+func (s *Service) CreateCustomer(ctx context.Context, customer models.Customer) (models.Customer, error) {
+	created, err := s.customerRepo.Create(ctx, customer)
+	if err != nil {
+		if errors.Is(err, models.ErrCustomerExists) {
+			return models.Customer{}, err
+		}
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to create customer", err)
+		return models.Customer{}, fmt.Errorf("failed to create customer: %w", err)
+	}
+
+	return created, nil
+}
+
+// AddCustomerAddress saves delivery address of the customer
+func (s *Service) AddCustomerAddress(ctx context.Context, address models.CustomerAddress) (models.CustomerAddress, error) {
+	added, err := s.customerRepo.AddAddress(ctx, address)
+	if err != nil {
+		if errors.Is(err, models.ErrCustomerNotFound) || errors.Is(err, models.ErrAddressExists) {
+			return models.CustomerAddress{}, err
+		}
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to add customer address", err, "customer-id", address.CustomerID)
+		return models.CustomerAddress{}, fmt.Errorf("failed to add customer address: %w", err)
+	}
+
+	return added, nil
+}
+
+// DeleteCustomerAddress deletes saved address of the customer
+func (s *Service) DeleteCustomerAddress(ctx context.Context, customerID, addressID int) error {
+	if err := s.customerRepo.DeleteAddress(ctx, customerID, addressID); err != nil {
+		if errors.Is(err, models.ErrAddressNotFound) {
+			return err
+		}
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to delete customer address", err, "customer-id", customerID)
+		return fmt.Errorf("failed to delete customer address: %w", err)
+	}
+
+	return nil
+}
+
+// AddCustomerFavorite adds menu item to favorites of the customer
+func (s *Service) AddCustomerFavorite(ctx context.Context, favorite models.FavoriteItem) (models.FavoriteItem, error) {
+	added, err := s.customerRepo.AddFavorite(ctx, favorite)
+	if err != nil {
+		if errors.Is(err, models.ErrCustomerNotFound) || errors.Is(err, models.ErrFavoriteExists) {
+			return models.FavoriteItem{}, err
+		}
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to add customer favorite", err, "customer-id", favorite.CustomerID)
+		return models.FavoriteItem{}, fmt.Errorf("failed to add customer favorite: %w", err)
+	}
+
+	return added, nil
+}
+
+// DeleteCustomerFavorite removes menu item from favorites of the customer
+func (s *Service) DeleteCustomerFavorite(ctx context.Context, customerID, favoriteID int) error {
+	if err := s.customerRepo.DeleteFavorite(ctx, customerID, favoriteID); err != nil {
+		if errors.Is(err, models.ErrFavoriteNotFound) {
+			return err
+		}
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to delete customer favorite", err, "customer-id", customerID)
+		return fmt.Errorf("failed to delete customer favorite: %w", err)
+	}
+
+	return nil
+}
@@ -9,11 +9,23 @@ import (
 
 type OrderRepository interface {
 	Create(ctx context.Context, req *models.CreateOrder, changedBy, notes string) (*models.Order, error)
-	GetAndIncrementSequence(ctx context.Context, date string) (int, error)
+	CreateBatch(ctx context.Context, reqs []*models.CreateOrder, changedBy, notes string, partial bool) ([]*models.Order, []error, error)
+	GetAndIncrementSequence(ctx context.Context, date string, n int) (int, error)
+}
+
+type CustomerRepository interface {
+	Create(ctx context.Context, customer models.Customer) (models.Customer, error)
+	Get(ctx context.Context, id int) (models.Customer, error)
+	AddAddress(ctx context.Context, address models.CustomerAddress) (models.CustomerAddress, error)
+	GetAddress(ctx context.Context, customerID, addressID int) (models.CustomerAddress, error)
+	DeleteAddress(ctx context.Context, customerID, addressID int) error
+	AddFavorite(ctx context.Context, favorite models.FavoriteItem) (models.FavoriteItem, error)
+	DeleteFavorite(ctx context.Context, customerID, favoriteID int) error
 }
 
 type MessageBroker interface {
 	PublishCreateOrder(ctx context.Context, order *models.CreateOrder) error
+	PublishCreateOrders(ctx context.Context, orders []*models.CreateOrder) ([]error, error)
 }
 
 type Semaphore interface {
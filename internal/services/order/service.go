@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"sync/atomic"
 	"time"
 
 	"wheres-my-pizza/internal/config"
@@ -13,30 +14,42 @@ import (
 	"wheres-my-pizza/pkg/logger"
 )
 
-var ErrTooManyRequest = errors.New("too many requests")
+var (
+	ErrTooManyRequest = errors.New("too many requests")
+	ErrBatchTooLarge  = errors.New("too many orders in the batch")
+	ErrEmptyBatch     = errors.New("batch must contain at least one order")
+)
 
 const servicename = "order-service"
 
 type Service struct {
-	orderRepo OrderRepository
-	writer    MessageBroker
-	sem       Semaphore
-	semWait   time.Duration
+	orderRepo    OrderRepository
+	customerRepo CustomerRepository
+	writer       MessageBroker
+	sem          Semaphore
 
-	cfg config.Config
+	cfg atomic.Pointer[config.Config] // replaced on reload, see Reload
 	log logger.Logger
 }
 
-func NewService(cfg config.Config, repo OrderRepository, writer MessageBroker, sem Semaphore, semWait time.Duration, log logger.Logger) *Service {
-	return &Service{
-		orderRepo: repo,
-		writer:    writer,
-		sem:       sem,
-		semWait:   time.Second,
+func NewService(cfg config.Config, repo OrderRepository, customerRepo CustomerRepository, writer MessageBroker, sem Semaphore, log logger.Logger) *Service {
+	s := &Service{
+		orderRepo:    repo,
+		customerRepo: customerRepo,
+		writer:       writer,
+		sem:          sem,
 
-		cfg: cfg,
 		log: log,
 	}
+	s.cfg.Store(&cfg)
+
+	return s
+}
+
+// Reload replaces config of the service: wait for a free slot and publish retries are
+// taken from it by the next orders. Semaphore is resized by its owner.
+func (s *Service) Reload(cfg config.Config) {
+	s.cfg.Store(&cfg)
 }
 
 // CreateOrder creates new order
@@ -49,15 +62,19 @@ func (s *Service) CreateOrder(ctx context.Context, req *models.CreateOrder) (*mo
 		"slots-used", s.sem.Used(),
 	)
 
-	// Trying to take slot under s.semWait seconds if not returning error.
-	if !s.sem.TryAcquire(s.semWait) {
+	// Trying to take slot under semwait if not returning error.
+	if !s.sem.TryAcquire(s.cfg.Load().Services.Order.SemWait) {
 		s.log.Error(ctx, types.ActionOrderProccessingFailed, "failed to proccess order", ErrTooManyRequest)
 		return nil, ErrTooManyRequest
 	}
 	defer s.sem.Release()
 
+	if err := s.resolveCustomer(ctx, req); err != nil {
+		return nil, err
+	}
+
 	today := todayDate()
-	number, err := s.orderRepo.GetAndIncrementSequence(ctx, today)
+	number, err := s.orderRepo.GetAndIncrementSequence(ctx, today, 1)
 	if err != nil {
 		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to get next order sequence. generating random order_number", err)
 		number = getRandomOrderNumber()
@@ -76,7 +93,8 @@ func (s *Service) CreateOrder(ctx context.Context, req *models.CreateOrder) (*mo
 	}
 
 	// Send request info about publishing order with retry
-	if err := retry(s.cfg.RabbitMQ.ReconnectAttempt, s.cfg.RabbitMQ.ReconnectDelay, func() error {
+	conn := s.cfg.Load().RabbitMQ.Conn
+	if err := retry(conn.ReconnectAttempt, conn.ReconnectDelay, func() error {
 		return s.writer.PublishCreateOrder(ctx, req)
 	}); err != nil {
 		s.log.Error(ctx, types.ActionDBQueryFailed, "order stored to database, but not published", err)
@@ -99,14 +117,16 @@ func todayDate() string {
 	return time.Now().UTC().Format("20060102") // Go's reference time format
 }
 
-// retry funciton to attempt request multiple times
+// retry funciton to attempt request multiple times. Only transient errors are retried, other
+// errors (e.g. returned or nacked publish) fail the same way on every attempt.
 func retry(attempts int, delay time.Duration, fn func() error) error {
 	var err error
 
+	attempts = max(attempts, 1)
 	for i := range attempts {
 		err = fn()
-		if err == nil {
-			return nil
+		if err == nil || models.KindOf(err) != models.KindTransient {
+			return err
 		}
 
 		if i < attempts-1 {
@@ -0,0 +1,122 @@
+package tracking
+
+import (
+	"context"
+	"time"
+
+	"wheres-my-pizza/internal/domain/models"
+	"wheres-my-pizza/internal/domain/types"
+)
+
+const (
+	// cookTimeSamples is number of latest cooked orders of a type the mean cook time is taken from.
+	cookTimeSamples = 50
+	// minCookTimeSamples is number of cooked orders below which the cooking model is trusted more than history.
+	minCookTimeSamples = 5
+)
+
+// estimateCompletion fills estimated completion of the order and its position in the kitchen queue.
+//
+// Order being cooked completes its expected cook time after cooking started. Waiting order is cooked
+// in the online slot of its type which frees up first: a busy slot frees up when the order in it is
+// expected ready, and every order ahead takes the earliest slot for the mean cook time of the type.
+// Completion stays nil while nobody online can cook the order.
+func (s *Service) estimateCompletion(ctx context.Context, status *models.OrderStatus) error {
+	progress, err := s.statusRepo.GetProgress(ctx, status.OrderNumber)
+	if err != nil {
+		return err
+	}
+
+	switch progress.Status {
+	case types.StatusOrderReady, types.StatusOrderCompleted:
+		status.Completion = progress.CompletedAt
+		return nil
+	case types.StatusOrderCancelled:
+		status.Completion = nil
+		return nil
+	}
+
+	now := time.Now()
+	order := &models.CreateOrder{Type: progress.Type, Items: progress.Items}
+
+	if progress.Status == types.StatusOrderCooking {
+		completion := later(progress.StatusChanged.Add(s.cookingModel.Expected(order)), now)
+		status.Completion = &completion
+		return nil
+	}
+
+	// received or returned to the queue
+	position := progress.Ahead
+	status.QueuePosition = &position
+	status.Completion = nil
+
+	threshold := time.Duration(s.heartbeatInt) * time.Second
+	slots, err := s.workerRepo.OnlineSlots(ctx, progress.Type, threshold)
+	if err != nil {
+		return err
+	}
+	if slots == 0 {
+		return nil
+	}
+
+	cooking, err := s.statusRepo.ListCooking(ctx, progress.Type, threshold)
+	if err != nil {
+		return err
+	}
+	cookTime, err := s.cookTime(ctx, progress.Type)
+	if err != nil {
+		return err
+	}
+
+	// when every slot frees up, orders being cooked are oldest first
+	free := make([]time.Time, slots)
+	for i := range free {
+		free[i] = now
+		if i < len(cooking) {
+			ready := cooking[i].Started.Add(s.cookingModel.Expected(&cooking[i].Order))
+			free[i] = later(ready, now) // running late, it is going to be ready any moment
+		}
+	}
+
+	for range progress.Ahead {
+		first := earliest(free)
+		free[first] = free[first].Add(cookTime)
+	}
+
+	completion := free[earliest(free)].Add(s.cookingModel.Expected(order))
+	status.Completion = &completion
+
+	return nil
+}
+
+// earliest returns index of the slot which frees up first.
+func earliest(free []time.Time) int {
+	first := 0
+	for i := range free {
+		if free[i].Before(free[first]) {
+			first = i
+		}
+	}
+	return first
+}
+
+// later returns the later of two times.
+func later(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return b
+	}
+	return a
+}
+
+// cookTime returns expected cook time of an order of the type, taken from recent history when there is enough of it.
+func (s *Service) cookTime(ctx context.Context, orderType string) (time.Duration, error) {
+	mean, samples, err := s.statusRepo.MeanCookTime(ctx, orderType, cookTimeSamples)
+	if err != nil {
+		return 0, err
+	}
+	if samples >= minCookTimeSamples && mean > 0 {
+		return mean, nil
+	}
+
+	return s.cookingModel.BaseTime(orderType), nil
+}
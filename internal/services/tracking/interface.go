@@ -2,6 +2,7 @@ package tracking
 
 import (
 	"context"
+	"time"
 
 	"wheres-my-pizza/internal/domain/models"
 )
@@ -9,8 +10,54 @@ import (
 type StatusRepo interface {
 	GetCurrent(ctx context.Context, orderNumber string) (models.OrderStatus, error)
 	ListOrderHistory(ctx context.Context, orderNumber string) ([]models.OrderHistory, error)
+	GetProgress(ctx context.Context, orderNumber string) (models.OrderProgress, error)
+	MeanCookTime(ctx context.Context, orderType string, samples int) (time.Duration, int, error)
+	// ListCooking returns orders of the type being cooked by workers online within threshold.
+	ListCooking(ctx context.Context, orderType string, threshold time.Duration) ([]models.CookingOrder, error)
 }
 
 type WorkerRepo interface {
 	List(ctx context.Context) ([]models.Worker, error)
+	CountOnline(ctx context.Context, orderType string, threshold time.Duration) (int, error)
+	OnlineSlots(ctx context.Context, orderType string, threshold time.Duration) (int, error)
+	AddCommand(ctx context.Context, cmd models.WorkerCommand) (models.WorkerCommand, error)
+	Get(ctx context.Context, name string) (models.Worker, error)
+	ListSessions(ctx context.Context, name string, limit int) ([]models.WorkerSession, error)
+	Stats(ctx context.Context, name string) (models.WorkerStats, error)
+}
+
+type OrderRepo interface {
+	// MarkReady marks order being cooked ready (manual cooking mode).
+	MarkReady(ctx context.Context, orderNumber string) error
+}
+
+type CustomerRepo interface {
+	Get(ctx context.Context, id int) (models.Customer, error)
+	ListAddresses(ctx context.Context, customerID int) ([]models.CustomerAddress, error)
+	ListFavorites(ctx context.Context, customerID int) ([]models.FavoriteItem, error)
+	ListOrders(ctx context.Context, customerID, limit, offset int) ([]models.CustomerOrder, error)
+}
+
+type QueueInspector interface {
+	// InspectOrderType returns state of the kitchen queue of the order type and its DLQ.
+	InspectOrderType(ctx context.Context, orderType string) (queue, dlq models.QueueState, err error)
+}
+
+// Reaper contracts
+
+type StaleWorkerRepo interface {
+	// MarkStaleOffline marks offline workers which missed two of their own heartbeats and returns
+	// their names.
+	MarkStaleOffline(ctx context.Context) ([]string, error)
+}
+
+type StuckOrderRepo interface {
+	// ListStuckCooking returns orders left in 'cooking' by offline workers longer than stuckAfter.
+	ListStuckCooking(ctx context.Context, stuckAfter time.Duration) ([]models.CreateOrder, error)
+	// Requeue returns order to 'received' if publish succeeds.
+	Requeue(ctx context.Context, orderNumber, changedBy, notes string, publish func() error) error
+}
+
+type OrderPublisher interface {
+	PublishCreateOrder(ctx context.Context, order *models.CreateOrder) error
 }
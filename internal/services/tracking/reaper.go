@@ -0,0 +1,110 @@
+package tracking
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"wheres-my-pizza/internal/domain/models"
+	"wheres-my-pizza/internal/domain/types"
+	"wheres-my-pizza/pkg/logger"
+)
+
+const reaperName = "reaper"
+
+// Reaper recovers orders of crashed kitchen workers. A worker which missed its heartbeats is
+// marked offline, orders it left in 'cooking' longer than the longest base cooking time are returned
+// to 'received' and published to the orders exchange again.
+//
+// If the message of such order was not acknowledged, the broker redelivers it as well and the
+// order may be cooked twice, which is preferred to an order lost forever.
+type Reaper struct {
+	workerRepo StaleWorkerRepo
+	orderRepo  StuckOrderRepo
+	publisher  OrderPublisher
+
+	interval   time.Duration
+	stuckAfter time.Duration // order is stuck if it is cooking for this long
+
+	log logger.Logger
+}
+
+// NewReaper creates reaper which runs every interval. Workers are considered dead after
+// two missed heartbeats of their own interval, the same as on worker registration. Orders of dead
+// workers are stuck after the longest base cooking time and two heartbeatInt.
+func NewReaper(
+	workerRepo StaleWorkerRepo,
+	orderRepo StuckOrderRepo,
+	publisher OrderPublisher,
+	cookingModel *models.CookingModel,
+	interval time.Duration,
+	heartbeatInt int,
+	log logger.Logger,
+) *Reaper {
+	staleThreshold := time.Duration(heartbeatInt) * time.Second * 2
+	longestCooking := cookingModel.MaxBaseTime()
+
+	return &Reaper{
+		workerRepo: workerRepo,
+		orderRepo:  orderRepo,
+		publisher:  publisher,
+		interval:   interval,
+		stuckAfter: longestCooking + staleThreshold,
+		log:        log,
+	}
+}
+
+// Run reaps every interval until ctx is done.
+func (r *Reaper) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Reap(ctx); err != nil {
+				r.log.Error(ctx, types.ActionDBQueryFailed, "failed to reap stale workers", err)
+			}
+		}
+	}
+}
+
+// Reap marks dead workers offline and requeues orders stuck in 'cooking'.
+func (r *Reaper) Reap(ctx context.Context) error {
+	const op = "Reaper.Reap"
+
+	workers, err := r.workerRepo.MarkStaleOffline(ctx)
+	if err != nil {
+		return models.Wrap(op, err)
+	}
+	for _, name := range workers {
+		r.log.Warn(ctx, types.ActionWorkerReaped, "worker missed heartbeats, marked offline", "worker-name", name)
+	}
+
+	orders, err := r.orderRepo.ListStuckCooking(ctx, r.stuckAfter)
+	if err != nil {
+		return models.Wrap(op, err)
+	}
+
+	for i := range orders {
+		order := &orders[i]
+
+		err := r.orderRepo.Requeue(ctx, order.Number, reaperName, "worker went offline while cooking", func() error {
+			order.Status = types.StatusOrderReceived
+			return r.publisher.PublishCreateOrder(ctx, order)
+		})
+		if errors.Is(err, models.ErrOrderNotFound) {
+			continue // finished meanwhile
+		}
+		if err != nil {
+			r.log.Error(ctx, types.ActionOrderProccessingFailed, "failed to requeue stuck order", err, "order-number", order.Number)
+			continue
+		}
+
+		r.log.Info(ctx, types.ActionOrderRequeued, "stuck order requeued", "order-number", order.Number)
+	}
+
+	return nil
+}
@@ -3,7 +3,6 @@ package tracking
 import (
 	"context"
 	"errors"
-	"fmt"
 	"time"
 
 	"wheres-my-pizza/internal/domain/models"
@@ -11,24 +10,47 @@ import (
 	"wheres-my-pizza/pkg/logger"
 )
 
+// workerSessionsLimit is number of latest sessions shown in worker details.
+const workerSessionsLimit = 30
+
 type Service struct {
 	statusRepo   StatusRepo
 	workerRepo   WorkerRepo
+	orderRepo    OrderRepo
+	customerRepo CustomerRepo
+	queues       QueueInspector
+	cookingModel *models.CookingModel
 	heartbeatInt int
+	drainTarget  time.Duration
 
 	log logger.Logger
 }
 
-func NewService(statusRepo StatusRepo, workerRepo WorkerRepo, heartbeatInt int, log logger.Logger) *Service {
+func NewService(
+	statusRepo StatusRepo,
+	workerRepo WorkerRepo,
+	orderRepo OrderRepo,
+	customerRepo CustomerRepo,
+	queues QueueInspector,
+	cookingModel *models.CookingModel,
+	heartbeatInt int,
+	drainTarget time.Duration,
+	log logger.Logger,
+) *Service {
 	return &Service{
 		statusRepo:   statusRepo,
 		workerRepo:   workerRepo,
+		orderRepo:    orderRepo,
+		customerRepo: customerRepo,
+		queues:       queues,
+		cookingModel: cookingModel,
 		heartbeatInt: heartbeatInt,
+		drainTarget:  drainTarget,
 		log:          log,
 	}
 }
 
-// GetOrderStatus — возвращает статус заказа по его номеру.
+// GetOrderStatus — возвращает статус заказа по его номеру и оценку времени его готовности.
 func (s *Service) GetOrderStatus(ctx context.Context, orderNumber string) (models.OrderStatus, error) {
 	const op = "Service.GetOrderStatus"
 
@@ -39,7 +61,12 @@ func (s *Service) GetOrderStatus(ctx context.Context, orderNumber string) (model
 		}
 
 		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to get current order status", err)
-		return models.OrderStatus{}, fmt.Errorf("%s: %v", op, err)
+		return models.OrderStatus{}, models.Wrap(op, err)
+	}
+
+	// status is still useful without the estimate
+	if err := s.estimateCompletion(ctx, &statusInfo); err != nil {
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to estimate order completion", err, "order-number", orderNumber)
 	}
 
 	return statusInfo, nil
@@ -57,7 +84,7 @@ func (s *Service) ListWorkers(ctx context.Context) ([]models.Worker, error) {
 		}
 
 		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to get workers list", err)
-		return nil, fmt.Errorf("%s: %v", op, err)
+		return nil, models.Wrap(op, err)
 	}
 
 	threshold := time.Duration(s.heartbeatInt) * time.Second
@@ -82,8 +109,220 @@ func (s *Service) GetTrackingHistory(ctx context.Context, orderNumber string) ([
 		}
 
 		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to get tracking history", err)
-		return nil, fmt.Errorf("%s: %v", op, err)
+		return nil, models.Wrap(op, err)
 	}
 
 	return historyList, nil
 }
+
+// GetKitchenCapacity — возвращает загрузку очередей кухни и рекомендуемое число работников по типам заказов.
+func (s *Service) GetKitchenCapacity(ctx context.Context) (models.KitchenCapacity, error) {
+	const op = "Service.GetKitchenCapacity"
+
+	threshold := time.Duration(s.heartbeatInt) * time.Second
+
+	capacity := models.KitchenCapacity{
+		Timestamp:   time.Now(),
+		DrainTarget: s.drainTarget.Seconds(),
+		OrderTypes:  make([]models.OrderTypeCapacity, 0, len(types.AllOrderTypes)),
+	}
+
+	for _, orderType := range types.AllOrderTypes {
+		queue, dlq, err := s.queues.InspectOrderType(ctx, orderType)
+		if err != nil {
+			s.log.Error(ctx, types.ActionRabbitConnectionFailed, "failed to inspect kitchen queue", err, "order-type", orderType)
+			return models.KitchenCapacity{}, models.Wrap(op, err)
+		}
+
+		online, err := s.workerRepo.CountOnline(ctx, orderType, threshold)
+		if err != nil {
+			s.log.Error(ctx, types.ActionDBQueryFailed, "failed to count online workers", err, "order-type", orderType)
+			return models.KitchenCapacity{}, models.Wrap(op, err)
+		}
+
+		slots, err := s.workerRepo.OnlineSlots(ctx, orderType, threshold)
+		if err != nil {
+			s.log.Error(ctx, types.ActionDBQueryFailed, "failed to count online worker slots", err, "order-type", orderType)
+			return models.KitchenCapacity{}, models.Wrap(op, err)
+		}
+
+		cookingTime := s.cookingModel.BaseTime(orderType)
+
+		capacity.OrderTypes = append(capacity.OrderTypes, models.OrderTypeCapacity{
+			OrderType:          orderType,
+			Queue:              queue,
+			DeadLetterQueue:    dlq,
+			OnlineWorkers:      online,
+			OnlineSlots:        slots,
+			CookingTime:        cookingTime.Seconds(),
+			RecommendedWorkers: recommendWorkers(queue, cookingTime, s.drainTarget, online, slots),
+		})
+	}
+
+	return capacity, nil
+}
+
+// recommendWorkers returns number of workers needed to cook all queued and in-progress orders
+// within drain target. A worker cooks as many orders at a time as online workers do on average
+// (slots per worker), one if no worker is online. At least one worker is recommended for every
+// order type, so new orders are not stuck in the queue.
+func recommendWorkers(queue models.QueueState, cookingTime, drainTarget time.Duration, online, slots int) int {
+	backlog := queue.Ready
+	if queue.Unacked != nil {
+		backlog += *queue.Unacked
+	}
+
+	if drainTarget <= 0 || backlog == 0 {
+		return 1
+	}
+
+	work := time.Duration(backlog) * cookingTime
+	needed := int((work + drainTarget - 1) / drainTarget) // ceil, orders cooked at the same time
+
+	perWorker := 1
+	if online > 0 {
+		perWorker = max(slots/online, 1)
+	}
+	workers := (needed + perWorker - 1) / perWorker // ceil
+
+	return max(workers, 1)
+}
+
+// SendWorkerCommand — ставит команду в очередь работника, работник применяет её на следующем heartbeat.
+func (s *Service) SendWorkerCommand(ctx context.Context, cmd models.WorkerCommand) (models.WorkerCommand, error) {
+	const op = "Service.SendWorkerCommand"
+
+	added, err := s.workerRepo.AddCommand(ctx, cmd)
+	if err != nil {
+		if errors.Is(err, models.ErrWorkerNotFound) {
+			return models.WorkerCommand{}, err
+		}
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to add worker command", err, "worker-name", cmd.WorkerName)
+		return models.WorkerCommand{}, models.Wrap(op, err)
+	}
+
+	s.log.Info(ctx, types.ActionWorkerCommand, "worker command sent", "worker-name", cmd.WorkerName, "command", cmd.Command)
+
+	return added, nil
+}
+
+// GetWorker — возвращает работника и его последние смены.
+func (s *Service) GetWorker(ctx context.Context, name string) (models.WorkerDetails, error) {
+	const op = "Service.GetWorker"
+
+	worker, err := s.workerRepo.Get(ctx, name)
+	if err != nil {
+		if errors.Is(err, models.ErrWorkerNotFound) {
+			return models.WorkerDetails{}, models.ErrWorkerNotFound
+		}
+
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to get worker", err, "worker-name", name)
+		return models.WorkerDetails{}, models.Wrap(op, err)
+	}
+
+	if time.Since(worker.LastSeen) > time.Duration(s.heartbeatInt)*time.Second {
+		worker.Status = types.WorkerOffline
+	}
+
+	sessions, err := s.workerRepo.ListSessions(ctx, name, workerSessionsLimit)
+	if err != nil {
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to list worker sessions", err, "worker-name", name)
+		return models.WorkerDetails{}, models.Wrap(op, err)
+	}
+
+	return models.WorkerDetails{
+		Worker:   worker,
+		Sessions: sessions,
+	}, nil
+}
+
+// GetWorkerStats — возвращает статистику работы работника по всем сменам.
+func (s *Service) GetWorkerStats(ctx context.Context, name string) (models.WorkerStats, error) {
+	const op = "Service.GetWorkerStats"
+
+	if _, err := s.workerRepo.Get(ctx, name); err != nil {
+		if errors.Is(err, models.ErrWorkerNotFound) {
+			return models.WorkerStats{}, models.ErrWorkerNotFound
+		}
+
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to get worker", err, "worker-name", name)
+		return models.WorkerStats{}, models.Wrap(op, err)
+	}
+
+	stats, err := s.workerRepo.Stats(ctx, name)
+	if err != nil {
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to get worker stats", err, "worker-name", name)
+		return models.WorkerStats{}, models.Wrap(op, err)
+	}
+
+	return stats, nil
+}
+
+// MarkOrderReady — отмечает готовность заказа поваром, в ручном режиме кухни работник завершает заказ.
+func (s *Service) MarkOrderReady(ctx context.Context, orderNumber string) error {
+	const op = "Service.MarkOrderReady"
+
+	if err := s.orderRepo.MarkReady(ctx, orderNumber); err != nil {
+		if errors.Is(err, models.ErrOrderNotFound) || errors.Is(err, models.ErrOrderNotCooking) {
+			return err
+		}
+
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to mark order ready", err, "order-number", orderNumber)
+		return models.Wrap(op, err)
+	}
+
+	return nil
+}
+
+// GetCustomer — возвращает клиента с сохранёнными адресами и избранным.
+func (s *Service) GetCustomer(ctx context.Context, id int) (models.CustomerDetails, error) {
+	const op = "Service.GetCustomer"
+
+	customer, err := s.customerRepo.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, models.ErrCustomerNotFound) {
+			return models.CustomerDetails{}, err
+		}
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to get customer", err, "customer-id", id)
+		return models.CustomerDetails{}, models.Wrap(op, err)
+	}
+
+	addresses, err := s.customerRepo.ListAddresses(ctx, id)
+	if err != nil {
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to list customer addresses", err, "customer-id", id)
+		return models.CustomerDetails{}, models.Wrap(op, err)
+	}
+
+	favorites, err := s.customerRepo.ListFavorites(ctx, id)
+	if err != nil {
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to list customer favorites", err, "customer-id", id)
+		return models.CustomerDetails{}, models.Wrap(op, err)
+	}
+
+	return models.CustomerDetails{
+		Customer:  customer,
+		Addresses: addresses,
+		Favorites: favorites,
+	}, nil
+}
+
+// ListCustomerOrders — возвращает историю заказов клиента, начиная с последних.
+func (s *Service) ListCustomerOrders(ctx context.Context, id, limit, offset int) ([]models.CustomerOrder, error) {
+	const op = "Service.ListCustomerOrders"
+
+	if _, err := s.customerRepo.Get(ctx, id); err != nil {
+		if errors.Is(err, models.ErrCustomerNotFound) {
+			return nil, err
+		}
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to get customer", err, "customer-id", id)
+		return nil, models.Wrap(op, err)
+	}
+
+	orders, err := s.customerRepo.ListOrders(ctx, id, limit, offset)
+	if err != nil {
+		s.log.Error(ctx, types.ActionDBQueryFailed, "failed to list customer orders", err, "customer-id", id)
+		return nil, models.Wrap(op, err)
+	}
+
+	return orders, nil
+}
@@ -0,0 +1,3 @@
+ALTER TABLE workers
+    DROP COLUMN IF EXISTS "active_orders",
+    DROP COLUMN IF EXISTS "concurrency";
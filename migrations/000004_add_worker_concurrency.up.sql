@@ -0,0 +1,3 @@
+ALTER TABLE workers
+    ADD COLUMN IF NOT EXISTS "concurrency"   integer not null default 1,
+    ADD COLUMN IF NOT EXISTS "active_orders" integer not null default 0;
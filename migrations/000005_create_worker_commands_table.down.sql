@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_worker_commands_pending;
+DROP TABLE IF EXISTS worker_commands;
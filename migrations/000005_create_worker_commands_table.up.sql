@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS worker_commands (
+    "id"            serial      primary key,
+    "created_at"    timestamptz not null    default now(),
+    "worker_name"   text        not null    references workers(name) on delete cascade,
+    "command"       text        not null    check (command in ('pause', 'resume', 'drain', 'order_types')),
+    "order_types"   text,
+    "processed_at"  timestamptz
+);
+
+-- Workers poll their pending commands on every heartbeat
+CREATE INDEX IF NOT EXISTS idx_worker_commands_pending ON worker_commands(worker_name) WHERE processed_at IS NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_order_status_log_changed_by;
+DROP INDEX IF EXISTS idx_worker_sessions_worker_started;
+DROP TABLE IF EXISTS worker_sessions;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS worker_sessions (
+    "id"                serial      primary key,
+    "worker_name"       text        not null    references workers(name) on delete cascade,
+    "started_at"        timestamptz not null    default now(),
+    "ended_at"          timestamptz,
+    "orders_processed"  integer     not null    default 0
+);
+
+-- For worker history: sessions of a worker, latest first
+CREATE INDEX IF NOT EXISTS idx_worker_sessions_worker_started ON worker_sessions(worker_name, started_at DESC);
+
+-- For worker stats: status changes made by a worker
+CREATE INDEX IF NOT EXISTS idx_order_status_log_changed_by ON order_status_log(changed_by, status);
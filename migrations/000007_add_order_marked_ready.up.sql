@@ -0,0 +1,2 @@
+-- Set by a cook in manual cooking mode, reset when cooking starts
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS "marked_ready_at" timestamptz;
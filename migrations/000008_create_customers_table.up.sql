@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS customers (
+    "id"            serial      primary key,
+    "created_at"    timestamptz not null    default now(),
+    "updated_at"    timestamptz not null    default now(),
+    "name"          text        not null,
+    "phone"         text        unique,
+    "email"         text        unique,
+    check (phone IS NOT NULL OR email IS NOT NULL)
+);
+
+-- Saved delivery addresses
+CREATE TABLE IF NOT EXISTS customer_addresses (
+    "id"            serial      primary key,
+    "created_at"    timestamptz not null    default now(),
+    "customer_id"   integer     not null    references customers(id) on delete cascade,
+    "label"         text,
+    "address"       text        not null,
+    unique (customer_id, address)
+);
+
+-- Favorite menu items
+CREATE TABLE IF NOT EXISTS customer_favorites (
+    "id"            serial          primary key,
+    "created_at"    timestamptz     not null    default now(),
+    "customer_id"   integer         not null    references customers(id) on delete cascade,
+    "name"          text            not null,
+    "price"         decimal(8,2)    not null,
+    unique (customer_id, name)
+);
+
+-- Orders placed without an account keep customer_id NULL
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS "customer_id" integer references customers(id);
+CREATE INDEX IF NOT EXISTS idx_orders_customer_id ON orders(customer_id, created_at) WHERE customer_id IS NOT NULL;
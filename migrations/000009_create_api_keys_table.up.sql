@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    "id"            serial      primary key,
+    "created_at"    timestamptz not null    default now(),
+    "name"          text        not null,
+    "role"          text        not null    check (role in ('customer', 'kitchen', 'manager', 'partner')),
+    "prefix"        text        not null,
+    "key_hash"      text        unique not null,   -- sha256 of the key
+    "created_by"    text        not null,
+    "last_used_at"  timestamptz,
+    "revoked_at"    timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS api_audit_log (
+    "id"            bigserial   primary key,
+    "created_at"    timestamptz not null    default now(),
+    "key_id"        integer     references api_keys(id),
+    "key_name"      text        not null,
+    "role"          text        not null,
+    "method"        text        not null,
+    "path"          text        not null,
+    "status"        integer     not null,
+    "request_id"    text,
+    "remote_addr"   text
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_audit_log_key_id ON api_audit_log(key_id, created_at);
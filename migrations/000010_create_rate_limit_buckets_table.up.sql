@@ -0,0 +1,7 @@
+-- Token buckets shared by order-service replicas
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_buckets (
+    "key"           text                primary key,
+    "tokens"        double precision    not null,
+    "allowed"       boolean             not null,   -- whether the last request took a token
+    "updated_at"    timestamptz         not null    default now()
+);
@@ -0,0 +1,2 @@
+-- Heartbeat interval of the worker, it is dead after two missed heartbeats
+ALTER TABLE workers ADD COLUMN IF NOT EXISTS "heartbeat_interval" interval not null default interval '30 seconds';
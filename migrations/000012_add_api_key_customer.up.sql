@@ -0,0 +1,2 @@
+-- Customer the key belongs to, customer keys only access this customer
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS "customer_id" integer references customers(id);
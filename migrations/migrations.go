@@ -0,0 +1,7 @@
+// Package migrations embeds SQL migrations of the database schema, they are applied with --mode=migrate.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,228 @@
+package configparser
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Decode decodes YAML node into v, which must be a non-nil pointer.
+//
+// Struct fields are matched by 'yaml' tags. Dots in a tag name a nested key, so a flat struct
+// can be filled from nested mappings, e.g. `yaml:"reconnect.delay"`. Untagged nested structs
+// and ones tagged `yaml:",inline"` are decoded from the mapping of their parent, other untagged
+// fields and `yaml:"-"` are skipped. Null values leave fields as is. Unknown keys and invalid
+// values are errors, decoding goes on after them and all errors are returned joined, each with
+// its line.
+//
+// Durations are written as "1m30s". Maps are decoded from mappings, or from comma-separated
+// key=value pairs for compatibility with environment variables.
+func Decode(node *Node, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("expected non-nil pointer")
+	}
+
+	return (&decoder{}).decode(node, rv.Elem(), "")
+}
+
+// UnmarshalYaml parses YAML document and decodes it into v, see Decode.
+func UnmarshalYaml(data []byte, v any) error {
+	node, err := ParseYaml(data)
+	if err != nil {
+		return err
+	}
+
+	return Decode(node, v)
+}
+
+// decoder decodes nodes into values, set is called with paths of decoded struct fields.
+type decoder struct {
+	set func(path string)
+}
+
+func (d *decoder) decode(node *Node, v reflect.Value, path string) error {
+	if node.IsNull() {
+		return nil
+	}
+
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.decode(node, v.Elem(), path)
+	}
+
+	switch {
+	case v.Kind() == reflect.Struct:
+		if node.Kind != MappingNode {
+			return errorf(node.Line, "%s: expected mapping", name(path))
+		}
+
+		fields := &fieldTree{}
+		if err := fields.add(v); err != nil {
+			return err
+		}
+		return d.decodeFields(node, fields, path)
+	case v.Kind() == reflect.Map && node.Kind == MappingNode:
+		if v.Type().Key().Kind() != reflect.String {
+			return errorf(node.Line, "%s: unsupported map key kind %s", name(path), v.Type().Key().Kind())
+		}
+
+		var errs []error
+		m := reflect.MakeMapWithSize(v.Type(), len(node.Pairs))
+		for _, pair := range node.Pairs {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := d.decode(pair.Value, elem, join(path, pair.Key)); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			m.SetMapIndex(reflect.ValueOf(pair.Key).Convert(v.Type().Key()), elem)
+		}
+		v.Set(m)
+		return errors.Join(errs...)
+	case v.Kind() == reflect.Slice:
+		if node.Kind != SequenceNode {
+			return errorf(node.Line, "%s: expected sequence", name(path))
+		}
+
+		var errs []error
+		s := reflect.MakeSlice(v.Type(), len(node.Items), len(node.Items))
+		for i, item := range node.Items {
+			if err := d.decode(item, s.Index(i), fmt.Sprintf("%s[%d]", name(path), i)); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		v.Set(s)
+		return errors.Join(errs...)
+	}
+
+	if node.Kind != ScalarNode {
+		return errorf(node.Line, "%s: expected scalar", name(path))
+	}
+	if err := setValue(v, node.Value, name(path)); err != nil {
+		return errorf(node.Line, "%v", err)
+	}
+
+	return nil
+}
+
+func (d *decoder) decodeFields(node *Node, fields *fieldTree, path string) error {
+	var errs []error
+	for _, pair := range node.Pairs {
+		keyPath := join(path, pair.Key)
+
+		child, ok := fields.children[pair.Key]
+		if !ok {
+			errs = append(errs, errorf(pair.Line, "%s: unknown key", keyPath))
+			continue
+		}
+
+		if child.children == nil {
+			if err := d.decode(pair.Value, child.field, keyPath); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if d.set != nil && !pair.Value.IsNull() {
+				d.set(keyPath)
+			}
+			continue
+		}
+
+		if pair.Value.IsNull() {
+			continue
+		}
+		if pair.Value.Kind != MappingNode {
+			errs = append(errs, errorf(pair.Value.Line, "%s: expected mapping", keyPath))
+			continue
+		}
+		if err := d.decodeFields(pair.Value, child, keyPath); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// fieldTree maps YAML keys to struct fields. Leaves hold fields, inner nodes are
+// mappings made of dotted tags.
+type fieldTree struct {
+	field    reflect.Value
+	children map[string]*fieldTree
+}
+
+func (t *fieldTree) add(v reflect.Value) error {
+	if t.children == nil {
+		t.children = make(map[string]*fieldTree)
+	}
+
+	rt := v.Type()
+	for i := range v.NumField() {
+		sf := rt.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		tag, tagged := sf.Tag.Lookup("yaml")
+		key, opts, _ := strings.Cut(tag, ",")
+		if key == "-" {
+			continue
+		}
+
+		if opts == "inline" || (!tagged && sf.Type.Kind() == reflect.Struct) {
+			if sf.Type.Kind() != reflect.Struct {
+				return fmt.Errorf("field %s: only structs can be inlined", sf.Name)
+			}
+			if err := t.add(v.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+		if key == "" {
+			continue
+		}
+
+		node := t
+		parts := strings.Split(key, ".")
+		for j, part := range parts {
+			child := node.children[part]
+
+			if j == len(parts)-1 {
+				if child != nil {
+					return fmt.Errorf("field %s: yaml key %q is already used", sf.Name, key)
+				}
+				node.children[part] = &fieldTree{field: v.Field(i)}
+				break
+			}
+
+			if child == nil {
+				child = &fieldTree{children: make(map[string]*fieldTree)}
+				node.children[part] = child
+			}
+			if child.children == nil {
+				return fmt.Errorf("field %s: yaml key %q is already used", sf.Name, strings.Join(parts[:j+1], "."))
+			}
+			node = child
+		}
+	}
+
+	return nil
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func name(path string) string {
+	if path == "" {
+		return "document"
+	}
+	return path
+}
@@ -0,0 +1,265 @@
+package configparser
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testDB struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+}
+
+type testLog struct {
+	Level string `yaml:"log_level"`
+}
+
+type testConfig struct {
+	Name    string            `yaml:"name"`
+	Port    int               `yaml:"port"`
+	Debug   bool              `yaml:"debug"`
+	Ratio   float64           `yaml:"ratio"`
+	Timeout time.Duration     `yaml:"timeout"`
+	Hosts   []string          `yaml:"hosts"`
+	Labels  map[string]string `yaml:"labels"`
+	Weights map[string]int    `yaml:"weights"`
+	Delay   time.Duration     `yaml:"reconnect.delay"`
+	Retries int               `yaml:"reconnect.retries"`
+	DB      testDB            `yaml:"db"`
+	Limit   *int              `yaml:"limit"`
+	Secret  string            `yaml:"-"`
+	Log     testLog           // untagged struct is inlined
+}
+
+func TestDecode(t *testing.T) {
+	limit := 7
+
+	tests := []struct {
+		name string
+		data string
+		want testConfig // decoded over defaults
+	}{
+		{
+			name: "scalars",
+			data: "name: pizza\nport: 8080\ndebug: true\nratio: 0.5\ntimeout: 1m30s\n",
+			want: testConfig{Name: "pizza", Port: 8080, Debug: true, Ratio: 0.5, Timeout: 90 * time.Second},
+		},
+		{
+			name: "quoted scalars",
+			data: "name: 'a: b # c'\nport: \"9090\"\n",
+			want: testConfig{Name: "a: b # c", Port: 9090},
+		},
+		{
+			name: "null values keep defaults",
+			data: "name:\nport: ~\ndb:\nhosts: null\n",
+			want: testConfig{Name: "default", Port: 1},
+		},
+		{
+			name: "empty quoted string overrides default",
+			data: "name: ''\n",
+			want: testConfig{Port: 1},
+		},
+		{
+			name: "list",
+			data: "hosts:\n  - a\n  - b:1\n  - 'c' # comment\n",
+			want: testConfig{Name: "default", Port: 1, Hosts: []string{"a", "b:1", "c"}},
+		},
+		{
+			name: "flow list",
+			data: "hosts: [a, 'b, c']\n",
+			want: testConfig{Name: "default", Port: 1, Hosts: []string{"a", "b, c"}},
+		},
+		{
+			name: "map from mapping",
+			data: "labels:\n  env: prod # comment\n  url: http://host:80\n",
+			want: testConfig{Name: "default", Port: 1, Labels: map[string]string{"env": "prod", "url": "http://host:80"}},
+		},
+		{
+			name: "map from key=value pairs",
+			data: "weights: a=1, b=2\n",
+			want: testConfig{Name: "default", Port: 1, Weights: map[string]int{"a": 1, "b": 2}},
+		},
+		{
+			name: "dotted tags and nested struct",
+			data: "reconnect:\n  delay: 2s\n  retries: 3\ndb:\n  host: localhost\n  port: 5432\n",
+			want: testConfig{Name: "default", Port: 1, Delay: 2 * time.Second, Retries: 3, DB: testDB{Host: "localhost", Port: 5432}},
+		},
+		{
+			name: "inlined struct",
+			data: "log_level: debug\n",
+			want: testConfig{Name: "default", Port: 1, Log: testLog{Level: "debug"}},
+		},
+		{
+			name: "pointer",
+			data: "limit: 7\n",
+			want: testConfig{Name: "default", Port: 1, Limit: &limit},
+		},
+		{
+			name: "anchors and merge keys",
+			data: "labels: &labels\n  env: prod\n  tier: db\nweights:\n  <<: {a: 1, b: 2}\n  b: 3\nhosts: &hosts [a]\n",
+			want: testConfig{
+				Name:    "default",
+				Port:    1,
+				Hosts:   []string{"a"},
+				Labels:  map[string]string{"env": "prod", "tier": "db"},
+				Weights: map[string]int{"a": 1, "b": 3},
+			},
+		},
+		{
+			name: "alias",
+			data: "db: &db {host: h}\nname: &name pizza\nlabels:\n  owner: *name\n",
+			want: testConfig{Name: "pizza", Port: 1, DB: testDB{Host: "h"}, Labels: map[string]string{"owner": "pizza"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := testConfig{Name: "default", Port: 1}
+			if err := UnmarshalYaml([]byte(tt.data), &got); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		lines []int    // of the joined errors, in order
+		errs  []string // substrings of the messages
+	}{
+		{
+			name:  "unknown key",
+			data:  "name: a\nextra: 1\n",
+			lines: []int{2},
+			errs:  []string{"extra: unknown key"},
+		},
+		{
+			name:  "unknown nested key",
+			data:  "db:\n  host: h\n  prot: 1\n",
+			lines: []int{3},
+			errs:  []string{"db.prot: unknown key"},
+		},
+		{
+			name:  "unknown dotted key",
+			data:  "reconnect:\n  delay: 1s\n  jitter: 2\n",
+			lines: []int{3},
+			errs:  []string{"reconnect.jitter: unknown key"},
+		},
+		{
+			name:  "skipped field is unknown",
+			data:  "secret: x\n",
+			lines: []int{1},
+			errs:  []string{"secret: unknown key"},
+		},
+		{
+			name:  "invalid values",
+			data:  "port: abc\ndebug: maybe\ntimeout: 5\n",
+			lines: []int{1, 2, 3},
+			errs:  []string{"failed to parse int for port", "failed to parse bool for debug", "failed to parse duration for timeout"},
+		},
+		{
+			name:  "decoding goes on after errors",
+			data:  "name: a\nextra: 1\nport: x\ndb:\n  host: h\n  other: 2\n",
+			lines: []int{2, 3, 6},
+			errs:  []string{"extra: unknown key", "port", "db.other: unknown key"},
+		},
+		{
+			name:  "mapping expected",
+			data:  "db: localhost\n",
+			lines: []int{1},
+			errs:  []string{"db: expected mapping"},
+		},
+		{
+			name:  "mapping expected for dotted key",
+			data:  "reconnect: 1s\n",
+			lines: []int{1},
+			errs:  []string{"reconnect: expected mapping"},
+		},
+		{
+			name:  "sequence expected",
+			data:  "hosts:\n  a: 1\n",
+			lines: []int{2},
+			errs:  []string{"hosts: expected sequence"},
+		},
+		{
+			name:  "scalar expected",
+			data:  "name: [a]\n",
+			lines: []int{1},
+			errs:  []string{"name: expected scalar"},
+		},
+		{
+			name:  "invalid map value",
+			data:  "weights:\n  a: 1\n  b: two\n",
+			lines: []int{3},
+			errs:  []string{"failed to parse int for weights.b"},
+		},
+		{
+			name:  "document is not a mapping",
+			data:  "- a\n",
+			lines: []int{1},
+			errs:  []string{"document: expected mapping"},
+		},
+		{
+			name:  "parse error",
+			data:  "name: a\nport: 1\nname: b\n",
+			lines: []int{3},
+			errs:  []string{`duplicate key "name"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg testConfig
+			err := UnmarshalYaml([]byte(tt.data), &cfg)
+			if err == nil {
+				t.Fatalf("errors %q expected", tt.errs)
+			}
+
+			errs := unjoin(err)
+			if len(errs) != len(tt.errs) {
+				t.Fatalf("got %d errors %q, want %d", len(errs), err, len(tt.errs))
+			}
+			for i := range errs {
+				checkLineError(t, errs[i], tt.lines[i], tt.errs[i])
+			}
+		})
+	}
+}
+
+func TestDecodeTarget(t *testing.T) {
+	var cfg testConfig
+	if err := Decode(&Node{Kind: MappingNode}, cfg); err == nil {
+		t.Error("error expected for non-pointer")
+	}
+
+	type duplicate struct {
+		A string `yaml:"a"`
+		B string `yaml:"a"`
+	}
+	err := UnmarshalYaml([]byte("a: x\n"), &duplicate{})
+	if err == nil || !strings.Contains(err.Error(), `yaml key "a" is already used`) {
+		t.Errorf("error %v, want duplicate yaml key", err)
+	}
+}
+
+// unjoin flattens errors joined by Decode.
+func unjoin(err error) []error {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+
+	var errs []error
+	for _, e := range joined.Unwrap() {
+		errs = slices.Concat(errs, unjoin(e))
+	}
+	return errs
+}
@@ -0,0 +1,194 @@
+package configparser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// Source is the layer a config value comes from.
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceFile    Source = "file"
+	SourceEnv     Source = "env"
+	SourceFlag    Source = "flag"
+)
+
+// Sources maps paths of fields to sources of their values, fields left zero are absent.
+type Sources map[string]Source
+
+// Layers are sources of config values, from lowest precedence to highest after defaults.
+// Zero layers are skipped.
+type Layers struct {
+	File  string                          // YAML file
+	Env   func(key string) (string, bool) // e.g. os.LookupEnv
+	Flags map[string]string               // flags given on the command line by name
+}
+
+// Field is a configurable struct field.
+type Field struct {
+	Path    string // dotted YAML path, identifies the field
+	Env     string
+	Flag    string
+	Default string
+	Value   reflect.Value
+}
+
+// Fields returns configurable fields of the struct v points to, in declaration order.
+//
+// A field is configurable if it has a 'yaml' tag, see Decode for the tag format. Its
+// environment variable, flag and default value are given by 'env', 'flag' and 'default' tags.
+func Fields(v any) ([]Field, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("expected pointer to struct")
+	}
+
+	var fields []Field
+	if err := collectFields(rv.Elem(), "", &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func collectFields(v reflect.Value, prefix string, fields *[]Field) error {
+	rt := v.Type()
+	for i := range v.NumField() {
+		sf := rt.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		tag, tagged := sf.Tag.Lookup("yaml")
+		key, opts, _ := strings.Cut(tag, ",")
+		if key == "-" {
+			continue
+		}
+
+		if opts == "inline" || (!tagged && sf.Type.Kind() == reflect.Struct) {
+			if err := collectFields(v.Field(i), prefix, fields); err != nil {
+				return err
+			}
+			continue
+		}
+		if key == "" {
+			continue
+		}
+
+		path := join(prefix, key)
+		if sf.Type.Kind() == reflect.Struct {
+			if err := collectFields(v.Field(i), path, fields); err != nil {
+				return err
+			}
+			continue
+		}
+
+		*fields = append(*fields, Field{
+			Path:    path,
+			Env:     sf.Tag.Get("env"),
+			Flag:    sf.Tag.Get("flag"),
+			Default: sf.Tag.Get("default"),
+			Value:   v.Field(i),
+		})
+	}
+
+	return nil
+}
+
+// Load fills in the struct v points to from default values, YAML file, environment variables
+// and flags, each layer overrides the previous ones. Invalid values don't stop loading, all
+// errors are returned joined together with sources of the values which were set.
+func Load(v any, layers Layers) (Sources, error) {
+	fields, err := Fields(v)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make(Sources, len(fields))
+	var errs []error
+
+	for _, f := range fields {
+		if f.Default == "" {
+			continue
+		}
+		if err := setValue(f.Value, f.Default, f.Path); err != nil {
+			errs = append(errs, fmt.Errorf("default of %w", err))
+			continue
+		}
+		sources[f.Path] = SourceDefault
+	}
+
+	if layers.File != "" {
+		if err := loadFile(v, layers.File, sources); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if layers.Env != nil {
+		for _, f := range fields {
+			if f.Env == "" {
+				continue
+			}
+			val, ok := layers.Env(f.Env)
+			if !ok || val == "" {
+				continue
+			}
+			if err := setValue(f.Value, val, f.Env); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			sources[f.Path] = SourceEnv
+		}
+	}
+
+	for _, f := range fields {
+		val, ok := layers.Flags[f.Flag]
+		if f.Flag == "" || !ok {
+			continue
+		}
+		if err := setValue(f.Value, val, "--"+f.Flag); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		sources[f.Path] = SourceFlag
+	}
+
+	return sources, errors.Join(errs...)
+}
+
+func loadFile(v any, filename string, sources Sources) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("could not open YAML file: %w", err)
+	}
+
+	node, err := ParseYaml(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+
+	d := &decoder{set: func(path string) { sources[path] = SourceFile }}
+	if err := d.decode(node, reflect.ValueOf(v).Elem(), ""); err != nil {
+		return fileErrors(filename, err)
+	}
+
+	return nil
+}
+
+// fileErrors prefixes every one of joined decoding errors with the file name.
+func fileErrors(filename string, err error) error {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+
+	var errs []error
+	for _, err := range joined.Unwrap() {
+		errs = append(errs, fileErrors(filename, err))
+	}
+	return errors.Join(errs...)
+}
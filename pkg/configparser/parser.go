@@ -5,92 +5,76 @@ import (
 	"os"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 )
 
-// Parse fills in the struct from environment variables and default values
+// Parse fills in the struct from environment variables and default values, see Load.
 func Parse(v any) error {
-	rv := reflect.ValueOf(v)
-	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
-		return fmt.Errorf("expected pointer to struct")
-	}
-	return parseStruct(rv.Elem())
+	_, err := Load(v, Layers{Env: os.LookupEnv})
+	return err
 }
 
-func parseStruct(rv reflect.Value) error {
-	rt := rv.Type()
-
-	for i := range rv.NumField() {
-		field := rv.Field(i)
-		fieldType := rt.Field(i)
-
-		envTag := fieldType.Tag.Get("env")
-		defaultTag := fieldType.Tag.Get("default")
-
-		// If it's a nested struct and doesn't have `env`, parse recursively
-		if field.Kind() == reflect.Struct && envTag == "" {
-			if err := parseStruct(field); err != nil {
-				return err
-			}
-			continue
+// setValue parses val into the field. Maps are written as comma-separated key=value pairs,
+// e.g. "dine_in=8s,takeout=10s".
+func setValue(field reflect.Value, val, envTag string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		dur, err := time.ParseDuration(val)
+		if err != nil {
+			return fmt.Errorf("failed to parse duration for %s: %w", envTag, err)
 		}
+		field.Set(reflect.ValueOf(dur))
+		return nil
+	}
 
-		if envTag == "" {
-			continue
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(val)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse int for %s: %w", envTag, err)
 		}
-
-		val, ok := os.LookupEnv(envTag)
-
-		if !ok || val == "" {
-			val = defaultTag // fallback to default if no env var
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(val, 10, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse uint for %s: %w", envTag, err)
 		}
-		if val == "" {
-			continue // still empty? Skip.
+		field.SetUint(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("failed to parse bool for %s: %w", envTag, err)
 		}
-
-		if !field.CanSet() {
-			return fmt.Errorf("cannot set field %s", fieldType.Name)
+		field.SetBool(b)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse float for %s: %w", envTag, err)
 		}
-
-		if fieldType.Type == reflect.TypeOf(time.Duration(0)) {
-			dur, err := time.ParseDuration(val)
-			if err != nil {
-				return fmt.Errorf("failed to parse duration for %s: %v", envTag, err)
-			}
-			field.Set(reflect.ValueOf(dur))
-			continue
+		field.SetFloat(f)
+	case reflect.Map:
+		if field.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map key kind %s for %s", field.Type().Key().Kind(), envTag)
 		}
 
-		switch field.Kind() {
-		case reflect.String:
-			field.SetString(val)
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			n, err := strconv.ParseInt(val, 10, 64)
-			if err != nil {
-				return fmt.Errorf("failed to parse int for %s: %v", envTag, err)
+		m := reflect.MakeMap(field.Type())
+		for pair := range strings.SplitSeq(val, ",") {
+			k, v, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("failed to parse map entry %q for %s: expected key=value", pair, envTag)
 			}
-			field.SetInt(n)
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			n, err := strconv.ParseUint(val, 10, 64)
-			if err != nil {
-				return fmt.Errorf("failed to parse uint for %s: %v", envTag, err)
-			}
-			field.SetUint(n)
-		case reflect.Bool:
-			b, err := strconv.ParseBool(val)
-			if err != nil {
-				return fmt.Errorf("failed to parse bool for %s: %v", envTag, err)
-			}
-			field.SetBool(b)
-		case reflect.Float32, reflect.Float64:
-			f, err := strconv.ParseFloat(val, 64)
-			if err != nil {
-				return fmt.Errorf("failed to parse float for %s: %v", envTag, err)
+
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if err := setValue(elem, strings.TrimSpace(v), envTag); err != nil {
+				return err
 			}
-			field.SetFloat(f)
-		default:
-			return fmt.Errorf("unsupported kind %s for field %s", field.Kind(), fieldType.Name)
+			m.SetMapIndex(reflect.ValueOf(strings.TrimSpace(k)), elem)
 		}
+		field.Set(m)
+	default:
+		return fmt.Errorf("unsupported kind %s for %s", field.Kind(), envTag)
 	}
 
 	return nil
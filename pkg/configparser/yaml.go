@@ -0,0 +1,681 @@
+package configparser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// YAML subset supported by ParseYaml:
+//   - block mappings and sequences, including '- key: value' items;
+//   - flow collections on one line: [a, b], {a: 1, b: 2};
+//   - plain, 'single' and "double" quoted scalars, '|' and '>' block scalars;
+//   - comments, anchors (&name), aliases (*name) and '<<' merge keys.
+//
+// Tags, complex keys, multi-line plain scalars and multiple documents are not supported.
+
+// Kind is kind of YAML node.
+type Kind int
+
+const (
+	ScalarNode Kind = iota + 1
+	MappingNode
+	SequenceNode
+)
+
+// Node is a node of YAML document.
+type Node struct {
+	Kind   Kind
+	Line   int
+	Value  string  // scalar value
+	Quoted bool    // quoted or block scalar, it's never null
+	Pairs  []Pair  // mapping entries in document order
+	Items  []*Node // sequence items
+}
+
+// Pair is an entry of YAML mapping.
+type Pair struct {
+	Key   string
+	Line  int
+	Value *Node
+}
+
+// IsNull reports whether node is an empty or null scalar.
+func (n *Node) IsNull() bool {
+	if n == nil {
+		return true
+	}
+	if n.Kind != ScalarNode || n.Quoted {
+		return false
+	}
+
+	switch n.Value {
+	case "", "~", "null", "Null", "NULL":
+		return true
+	}
+	return false
+}
+
+// Error is an error of YAML document at line.
+type Error struct {
+	Line int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+func errorf(line int, format string, args ...any) error {
+	return &Error{Line: line, Msg: fmt.Sprintf(format, args...)}
+}
+
+// ParseYaml parses YAML document into a tree. Empty document is a null scalar.
+func ParseYaml(data []byte) (*Node, error) {
+	text := strings.TrimPrefix(string(data), "\uFEFF")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	p := &parser{
+		lines:   strings.Split(text, "\n"),
+		anchors: make(map[string]*Node),
+	}
+
+	return p.parseDocument()
+}
+
+// line is a significant line of the document.
+type line struct {
+	num     int
+	indent  int
+	content string // without indentation and comment
+}
+
+type parser struct {
+	lines   []string
+	pos     int
+	anchors map[string]*Node
+}
+
+func (p *parser) parseDocument() (*Node, error) {
+	// directives and document start
+	for {
+		l, ok, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return &Node{Kind: ScalarNode, Line: 1}, nil
+		}
+		if !strings.HasPrefix(l.content, "%") && l.content != "---" {
+			break
+		}
+		p.pos++
+	}
+
+	l, _, _ := p.peek()
+	root, err := p.parseBlock(l.indent)
+	if err != nil {
+		return nil, err
+	}
+
+	l, ok, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if ok && l.content == "---" {
+		return nil, errorf(l.num, "multiple documents are not supported")
+	}
+	if ok && l.content != "..." {
+		return nil, errorf(l.num, "unexpected %q", l.content)
+	}
+
+	return root, nil
+}
+
+// peek returns next significant line without consuming it, blank and comment lines are skipped.
+func (p *parser) peek() (line, bool, error) {
+	for ; p.pos < len(p.lines); p.pos++ {
+		raw := p.lines[p.pos]
+		content := strings.TrimLeft(raw, " ")
+		indent := len(raw) - len(content)
+
+		content = strings.TrimSpace(stripComment(content))
+		if content == "" {
+			continue
+		}
+		if strings.HasPrefix(raw[indent:], "\t") {
+			return line{}, false, errorf(p.pos+1, "tabs are not allowed in indentation")
+		}
+
+		return line{num: p.pos + 1, indent: indent, content: content}, true, nil
+	}
+
+	return line{}, false, nil
+}
+
+// parseBlock parses node starting at the next line, which is indented by indent.
+func (p *parser) parseBlock(indent int) (*Node, error) {
+	l, _, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case isSeqItem(l.content):
+		return p.parseSequence(indent)
+	case isKeyLine(l.content):
+		return p.parseMapping(indent)
+	}
+
+	p.pos++
+	return p.parseValue(l.content, l, indent, false)
+}
+
+func (p *parser) parseMapping(indent int) (*Node, error) {
+	node := &Node{Kind: MappingNode}
+	seen := make(map[string]bool)
+	var merges []*Node
+
+	for {
+		l, ok, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if !ok || l.indent < indent || l.content == "..." || l.content == "---" {
+			break
+		}
+		if l.indent > indent {
+			return nil, errorf(l.num, "unexpected indentation")
+		}
+
+		key, rest, isKey, err := splitKey(l.content, l.num)
+		if err != nil {
+			return nil, err
+		}
+		if !isKey {
+			return nil, errorf(l.num, "expected 'key: value', found %q", l.content)
+		}
+		if node.Line == 0 {
+			node.Line = l.num
+		}
+		p.pos++
+
+		value, err := p.parseValue(rest, l, indent, true)
+		if err != nil {
+			return nil, err
+		}
+
+		if key == "<<" {
+			merges = append(merges, value)
+			continue
+		}
+		if seen[key] {
+			return nil, errorf(l.num, "duplicate key %q", key)
+		}
+		seen[key] = true
+		node.Pairs = append(node.Pairs, Pair{Key: key, Line: l.num, Value: value})
+	}
+
+	// merged keys don't override explicit ones
+	for _, merge := range merges {
+		sources := []*Node{merge}
+		if merge.Kind == SequenceNode {
+			sources = merge.Items
+		}
+
+		for _, src := range sources {
+			if src.Kind != MappingNode {
+				return nil, errorf(merge.Line, "merge key expects a mapping")
+			}
+			for _, pair := range src.Pairs {
+				if !seen[pair.Key] {
+					seen[pair.Key] = true
+					node.Pairs = append(node.Pairs, pair)
+				}
+			}
+		}
+	}
+
+	return node, nil
+}
+
+func (p *parser) parseSequence(indent int) (*Node, error) {
+	node := &Node{Kind: SequenceNode}
+
+	for {
+		l, ok, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if !ok || l.indent < indent || !isSeqItem(l.content) {
+			break
+		}
+		if l.indent > indent {
+			return nil, errorf(l.num, "unexpected indentation")
+		}
+		if node.Line == 0 {
+			node.Line = l.num
+		}
+
+		rest := strings.TrimLeft(l.content[1:], " ")
+
+		// compact nested collection: '- key: value' or '- - item', the item is
+		// parsed as a block indented by the position of its content
+		if rest != "" && (isSeqItem(rest) || isKeyLine(rest)) {
+			raw := []byte(p.lines[p.pos])
+			raw[l.indent] = ' '
+			p.lines[p.pos] = string(raw)
+
+			item, err := p.parseBlock(l.indent + len(l.content) - len(rest))
+			if err != nil {
+				return nil, err
+			}
+			node.Items = append(node.Items, item)
+			continue
+		}
+
+		p.pos++
+		item, err := p.parseValue(rest, l, indent, false)
+		if err != nil {
+			return nil, err
+		}
+		node.Items = append(node.Items, item)
+	}
+
+	return node, nil
+}
+
+// parseValue parses value given after 'key:' or '-' at line l, nested blocks are indented deeper than indent.
+// Sequence of a mapping value may be indented the same as its key.
+func (p *parser) parseValue(rest string, l line, indent int, mappingValue bool) (*Node, error) {
+	var anchor string
+	if strings.HasPrefix(rest, "&") {
+		anchor, rest = cutToken(rest[1:])
+		if anchor == "" {
+			return nil, errorf(l.num, "empty anchor name")
+		}
+	}
+
+	var (
+		node *Node
+		err  error
+	)
+	switch {
+	case rest == "":
+		node, err = p.parseNested(l, indent, mappingValue)
+	case strings.HasPrefix(rest, "*"):
+		name, after := cutToken(rest[1:])
+		if after != "" {
+			return nil, errorf(l.num, "unexpected %q after alias", after)
+		}
+		var ok bool
+		if node, ok = p.anchors[name]; !ok {
+			return nil, errorf(l.num, "unknown anchor %q", name)
+		}
+	case rest[0] == '|' || rest[0] == '>':
+		node, err = p.parseBlockScalar(rest, l, indent)
+	default:
+		node, err = parseInline(rest, l.num)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if anchor != "" {
+		p.anchors[anchor] = node
+	}
+	return node, nil
+}
+
+// parseNested parses value given on the following lines, it's null if there are none.
+func (p *parser) parseNested(l line, indent int, mappingValue bool) (*Node, error) {
+	next, ok, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case ok && next.indent > indent:
+		return p.parseBlock(next.indent)
+	case ok && mappingValue && next.indent == indent && isSeqItem(next.content):
+		return p.parseSequence(indent)
+	}
+
+	return &Node{Kind: ScalarNode, Line: l.num}, nil
+}
+
+// parseBlockScalar parses literal '|' or folded '>' scalar with optional chomping indicator.
+func (p *parser) parseBlockScalar(header string, l line, indent int) (*Node, error) {
+	style, chomp := header[0], byte(0)
+	switch header[1:] {
+	case "":
+	case "-", "+":
+		chomp = header[1]
+	default:
+		return nil, errorf(l.num, "unsupported block scalar header %q", header)
+	}
+
+	var lines []string
+	blockIndent := -1
+	for ; p.pos < len(p.lines); p.pos++ {
+		raw := strings.TrimRight(p.lines[p.pos], " ")
+		if raw == "" {
+			lines = append(lines, "")
+			continue
+		}
+
+		ind := len(raw) - len(strings.TrimLeft(raw, " "))
+		if ind <= indent || (blockIndent >= 0 && ind < blockIndent) {
+			break
+		}
+		if blockIndent < 0 {
+			blockIndent = ind
+		}
+		lines = append(lines, raw[blockIndent:])
+	}
+
+	trailing := 0
+	for trailing < len(lines) && lines[len(lines)-1-trailing] == "" {
+		trailing++
+	}
+	body := lines[:len(lines)-trailing]
+
+	var text strings.Builder
+	for i, s := range body {
+		switch {
+		case i == 0:
+		case style == '|':
+			text.WriteByte('\n')
+		case s == "":
+			text.WriteByte('\n')
+			continue
+		case body[i-1] != "":
+			text.WriteByte(' ')
+		}
+		text.WriteString(s)
+	}
+
+	switch {
+	case chomp == '+':
+		text.WriteString(strings.Repeat("\n", trailing+1))
+	case chomp == 0 && len(body) > 0:
+		text.WriteByte('\n')
+	}
+
+	return &Node{Kind: ScalarNode, Line: l.num, Value: text.String(), Quoted: true}, nil
+}
+
+// parseInline parses scalar or flow collection taking the rest of the line.
+func parseInline(s string, num int) (*Node, error) {
+	f := &flowParser{s: s, line: num}
+
+	node, err := f.value(false)
+	if err != nil {
+		return nil, err
+	}
+
+	f.skipSpaces()
+	if f.pos < len(f.s) {
+		return nil, errorf(num, "unexpected %q after value", f.s[f.pos:])
+	}
+
+	return node, nil
+}
+
+// flowParser parses a value written on one line.
+type flowParser struct {
+	s    string
+	pos  int
+	line int
+}
+
+func (f *flowParser) value(inFlow bool) (*Node, error) {
+	f.skipSpaces()
+	if f.pos == len(f.s) {
+		return &Node{Kind: ScalarNode, Line: f.line}, nil
+	}
+
+	switch f.s[f.pos] {
+	case '[':
+		return f.sequence()
+	case '{':
+		return f.mapping()
+	case '"', '\'':
+		s, err := f.quoted()
+		if err != nil {
+			return nil, err
+		}
+		return &Node{Kind: ScalarNode, Line: f.line, Value: s, Quoted: true}, nil
+	}
+
+	start := f.pos
+	if inFlow {
+		for f.pos < len(f.s) && !strings.ContainsRune(",]}", rune(f.s[f.pos])) {
+			f.pos++
+		}
+	} else {
+		f.pos = len(f.s)
+	}
+
+	return &Node{Kind: ScalarNode, Line: f.line, Value: strings.TrimSpace(f.s[start:f.pos])}, nil
+}
+
+func (f *flowParser) sequence() (*Node, error) {
+	node := &Node{Kind: SequenceNode, Line: f.line}
+	f.pos++ // [
+
+	for {
+		f.skipSpaces()
+		if f.pos == len(f.s) {
+			return nil, errorf(f.line, "unterminated flow sequence")
+		}
+		if f.s[f.pos] == ']' {
+			f.pos++
+			return node, nil
+		}
+
+		item, err := f.value(true)
+		if err != nil {
+			return nil, err
+		}
+		node.Items = append(node.Items, item)
+
+		if err := f.separator(']'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (f *flowParser) mapping() (*Node, error) {
+	node := &Node{Kind: MappingNode, Line: f.line}
+	seen := make(map[string]bool)
+	f.pos++ // {
+
+	for {
+		f.skipSpaces()
+		if f.pos == len(f.s) {
+			return nil, errorf(f.line, "unterminated flow mapping")
+		}
+		if f.s[f.pos] == '}' {
+			f.pos++
+			return node, nil
+		}
+
+		var key string
+		if c := f.s[f.pos]; c == '"' || c == '\'' {
+			var err error
+			if key, err = f.quoted(); err != nil {
+				return nil, err
+			}
+		} else {
+			start := f.pos
+			for f.pos < len(f.s) && !strings.ContainsRune(":,}", rune(f.s[f.pos])) {
+				f.pos++
+			}
+			key = strings.TrimSpace(f.s[start:f.pos])
+		}
+
+		f.skipSpaces()
+		if f.pos == len(f.s) || f.s[f.pos] != ':' {
+			return nil, errorf(f.line, "expected ':' after key %q", key)
+		}
+		f.pos++
+
+		value, err := f.value(true)
+		if err != nil {
+			return nil, err
+		}
+		if seen[key] {
+			return nil, errorf(f.line, "duplicate key %q", key)
+		}
+		seen[key] = true
+		node.Pairs = append(node.Pairs, Pair{Key: key, Line: f.line, Value: value})
+
+		if err := f.separator('}'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// separator consumes ',' between flow items, closing bracket is left for the caller.
+func (f *flowParser) separator(closing byte) error {
+	f.skipSpaces()
+	switch {
+	case f.pos < len(f.s) && f.s[f.pos] == ',':
+		f.pos++
+		return nil
+	case f.pos < len(f.s) && f.s[f.pos] == closing:
+		return nil
+	}
+
+	return errorf(f.line, "expected ',' or '%c'", closing)
+}
+
+func (f *flowParser) quoted() (string, error) {
+	s, end, err := unquote(f.s[f.pos:], f.line)
+	if err != nil {
+		return "", err
+	}
+
+	f.pos += end
+	return s, nil
+}
+
+func (f *flowParser) skipSpaces() {
+	for f.pos < len(f.s) && (f.s[f.pos] == ' ' || f.s[f.pos] == '\t') {
+		f.pos++
+	}
+}
+
+// unquote parses quoted string at the start of s and returns it with length of its source.
+func unquote(s string, num int) (string, int, error) {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote == '"' && s[i] == '\\':
+			i++
+		case quote == '\'' && s[i] == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == quote:
+			if quote == '\'' {
+				return strings.ReplaceAll(s[1:i], "''", "'"), i + 1, nil
+			}
+			v, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", 0, errorf(num, "invalid double-quoted string %s", s[:i+1])
+			}
+			return v, i + 1, nil
+		}
+	}
+
+	return "", 0, errorf(num, "unterminated quoted string")
+}
+
+// splitKey splits 'key: value' line, isKey is false if the line is not a mapping entry.
+func splitKey(content string, num int) (key, rest string, isKey bool, err error) {
+	if content == "" || strings.ContainsRune("[{&*|>", rune(content[0])) || isSeqItem(content) {
+		return "", "", false, nil
+	}
+
+	if c := content[0]; c == '"' || c == '\'' {
+		k, end, err := unquote(content, num)
+		if err != nil {
+			return "", "", false, err
+		}
+
+		after := strings.TrimLeft(content[end:], " ")
+		if after == ":" || strings.HasPrefix(after, ": ") {
+			return k, strings.TrimSpace(after[1:]), true, nil
+		}
+		return "", "", false, nil
+	}
+
+	for i := 0; i < len(content); i++ {
+		if content[i] == ':' && (i+1 == len(content) || content[i+1] == ' ') {
+			return strings.TrimSpace(content[:i]), strings.TrimSpace(content[i+1:]), true, nil
+		}
+	}
+
+	return "", "", false, nil
+}
+
+func isKeyLine(content string) bool {
+	_, _, isKey, err := splitKey(content, 0)
+	return isKey || err != nil
+}
+
+func isSeqItem(content string) bool {
+	return content == "-" || strings.HasPrefix(content, "- ")
+}
+
+// cutToken cuts anchor or alias name off s.
+func cutToken(s string) (name, rest string) {
+	name, rest, _ = strings.Cut(s, " ")
+	return name, strings.TrimSpace(rest)
+}
+
+// stripComment cuts comment off the line. '#' starts a comment at the line start or after
+// whitespace, unless it is inside a quoted scalar.
+func stripComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == '"':
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				quote = 0
+			}
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			}
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		case (c == '"' || c == '\'') && opensQuote(s, i):
+			quote = c
+		}
+	}
+
+	return s
+}
+
+// opensQuote reports whether quote at i starts a quoted scalar rather than being part of a plain one.
+func opensQuote(s string, i int) bool {
+	j := i - 1
+	for j >= 0 && s[j] == ' ' {
+		j--
+	}
+	if j < 0 {
+		return true
+	}
+
+	switch s[j] {
+	case ':', '[', '{', ',':
+		return true
+	case '-':
+		return j == 0 || s[j-1] == ' '
+	}
+	return false
+}
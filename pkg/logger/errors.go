@@ -0,0 +1,80 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"wheres-my-pizza/internal/domain/models"
+)
+
+// maxFrames limits stack of the 'error' object.
+const maxFrames = 32
+
+// errorValue returns 'error' object of a record: message, kind of the domain error, what every
+// error in the chain added to the message and stack where the error was created. Errors without
+// stack get stack of the logging call.
+func errorValue(err error) slog.Value {
+	if err == nil {
+		return slog.GroupValue(
+			slog.String("msg", "<nil>"),
+			slog.Any("stack", frames(callers())),
+		)
+	}
+
+	pcs := models.StackOf(err)
+	if pcs == nil {
+		pcs = callers()
+	}
+
+	return slog.GroupValue(
+		slog.String("msg", err.Error()),
+		slog.String("kind", models.KindOf(err).String()),
+		slog.Any("chain", chain(err)),
+		slog.Any("stack", frames(pcs)),
+	)
+}
+
+// chain returns own part of the message of every error in the chain, e.g.
+// ["Service.GetWorker", "workerRepository.Get", "worker is not found"]. Errors which add nothing,
+// like sentinel domain errors, are skipped. Joined errors end the chain.
+func chain(err error) []string {
+	var links []string
+	for err != nil {
+		msg := err.Error()
+		next := errors.Unwrap(err)
+		if next != nil {
+			msg = strings.TrimSuffix(strings.TrimSuffix(msg, next.Error()), ": ")
+		}
+		if msg != "" {
+			links = append(links, msg)
+		}
+		err = next
+	}
+	return links
+}
+
+// callers returns stack of the logging call.
+func callers() []uintptr {
+	pcs := make([]uintptr, maxFrames+8)
+	n := runtime.Callers(2, pcs)
+	return pcs[:n]
+}
+
+// frames formats stack as "function (file.go:line)", frames of the runtime and the logger are skipped.
+func frames(pcs []uintptr) []string {
+	out := make([]string, 0, len(pcs))
+	iter := runtime.CallersFrames(pcs)
+	for {
+		frame, more := iter.Next()
+		if !strings.HasPrefix(frame.Function, "runtime.") && !strings.Contains(frame.Function, "pkg/logger.") {
+			out = append(out, fmt.Sprintf("%s (%s:%d)", frame.Function, filepath.Base(frame.File), frame.Line))
+		}
+		if !more || len(out) == maxFrames {
+			return out
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// Levels are levels of the service and components which override it.
+type Levels struct {
+	Level      string            `json:"level"`
+	Components map[string]string `json:"components"`
+}
+
+// levels holds level of the service and overrides of components, safe for concurrent use.
+type levels struct {
+	level slog.LevelVar
+
+	mu         sync.RWMutex
+	components map[string]slog.Level
+}
+
+// newLevels creates levels, invalid ones fall back to INFO for the service and are ignored
+// for components.
+func newLevels(logLevel string, components map[string]string) *levels {
+	l := &levels{components: make(map[string]slog.Level, len(components))}
+
+	if err := l.set("", logLevel); err != nil {
+		l.level.Set(slog.LevelInfo)
+	}
+	for component, logLevel := range components {
+		l.set(component, logLevel)
+	}
+
+	return l
+}
+
+// get returns level of the component, service level if it's not overridden.
+func (l *levels) get(component string) slog.Level {
+	if component != "" {
+		l.mu.RLock()
+		lvl, ok := l.components[component]
+		l.mu.RUnlock()
+		if ok {
+			return lvl
+		}
+	}
+	return l.level.Level()
+}
+
+// set sets level of the service if component is empty. Empty level of a component removes
+// its override.
+func (l *levels) set(component, logLevel string) error {
+	if component != "" && logLevel == "" {
+		l.mu.Lock()
+		delete(l.components, component)
+		l.mu.Unlock()
+		return nil
+	}
+
+	lvl, err := parseLevel(logLevel)
+	if err != nil {
+		return err
+	}
+
+	if component == "" {
+		l.level.Set(lvl)
+		return nil
+	}
+
+	l.mu.Lock()
+	l.components[component] = lvl
+	l.mu.Unlock()
+	return nil
+}
+
+func (l *levels) snapshot() Levels {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	components := make(map[string]string, len(l.components))
+	for component, lvl := range l.components {
+		components[component] = lvl.String()
+	}
+
+	return Levels{Level: l.level.Level().String(), Components: components}
+}
+
+// sampler keeps every Nth record of sampled actions. Rates are fixed at creation, so counters
+// are read without locking.
+type sampler struct {
+	rates    map[string]uint64
+	counters map[string]*atomic.Uint64
+}
+
+func newSampler(rates map[string]int) *sampler {
+	s := &sampler{
+		rates:    make(map[string]uint64, len(rates)),
+		counters: make(map[string]*atomic.Uint64, len(rates)),
+	}
+	for action, n := range rates {
+		if n > 1 {
+			s.rates[action] = uint64(n)
+			s.counters[action] = new(atomic.Uint64)
+		}
+	}
+	return s
+}
+
+// keep reports whether record of the action is written: the first one and every Nth after it.
+func (s *sampler) keep(action string) bool {
+	n, ok := s.rates[action]
+	if !ok {
+		return true
+	}
+	return (s.counters[action].Add(1)-1)%n == 0
+}
+
+func parseLevel(logLevel string) (slog.Level, error) {
+	switch logLevel {
+	case LevelDebug:
+		return slog.LevelDebug, nil
+	case LevelInfo:
+		return slog.LevelInfo, nil
+	case LevelWarn:
+		return slog.LevelWarn, nil
+	case LevelError:
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("invalid log level: %s", logLevel)
+	}
+}
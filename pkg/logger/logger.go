@@ -2,10 +2,9 @@ package logger
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"os"
-	"runtime"
-	"strings"
 	"time"
 
 	"wheres-my-pizza/internal/domain/models"
@@ -23,54 +22,78 @@ type Logger interface {
 	Info(ctx context.Context, action, msg string, args ...any)
 	Warn(ctx context.Context, action, msg string, args ...any)
 	Error(ctx context.Context, action, msg string, err error, args ...any)
+
+	// Component returns logger of a part of the service, its level may differ from the
+	// service level, see SetComponentLevel. Records are tagged with 'component'.
+	Component(name string) Logger
+
+	// SetLevel changes level of the service at runtime.
+	SetLevel(logLevel string) error
+	// SetComponentLevel overrides level of the component, empty level removes the override.
+	SetComponentLevel(component, logLevel string) error
+	// Levels returns current levels.
+	Levels() Levels
+
 	GetSlogLogger() *slog.Logger
 }
 
+// Options of the logger. Only Level is required.
+type Options struct {
+	Level      string
+	Format     string            // FormatJSON (default) or FormatText
+	Components map[string]string // levels of components overriding Level
+	Sample     map[string]int    // action=N: only every Nth record of the action is written, errors are not sampled
+}
+
+const (
+	FormatJSON = "json"
+	FormatText = "text" // for local development
+)
+
 type logger struct {
 	slog     *slog.Logger
+	levels   *levels // shared by all loggers derived from one, see Component
+	sampler  *sampler
 	service  string
 	hostname string
 }
 
-// Initialize logger with service name and log level
-func InitLogger(serviceName, logLevel string) Logger {
+// Initialize logger with service name and options. Invalid levels fall back to INFO.
+func InitLogger(serviceName string, opts Options) Logger {
 	hostname, err := os.Hostname()
 	if err != nil {
 		hostname = "unknown"
 	}
 
-	level := new(slog.LevelVar)
-	switch logLevel {
-	case LevelDebug:
-		level.Set(slog.LevelDebug)
-	case LevelInfo:
-		level.Set(slog.LevelInfo)
-	case LevelWarn:
-		level.Set(slog.LevelWarn)
-	case LevelError:
-		level.Set(slog.LevelError)
-	default:
-		level.Set(slog.LevelInfo)
+	levels := newLevels(opts.Level, opts.Components)
+
+	handlerOpts := &slog.HandlerOptions{
+		// Levels are checked by contextHandler
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			// Rename 'msg' to 'message'
+			if a.Key == slog.MessageKey {
+				return slog.Attr{Key: "message", Value: a.Value}
+			}
+			// Format time as ISO 8601
+			if a.Key == slog.TimeKey {
+				if t, ok := a.Value.Any().(time.Time); ok {
+					return slog.Attr{Key: "timestamp", Value: slog.StringValue(t.Format(time.RFC3339))}
+				}
+			}
+			return a
+		},
+	}
+
+	var inner slog.Handler = slog.NewJSONHandler(os.Stdout, handlerOpts)
+	if opts.Format == FormatText {
+		inner = slog.NewTextHandler(os.Stdout, handlerOpts)
 	}
 
 	// Custom handler to add request_id and rename message field
 	handler := &contextHandler{
-		handler: slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-			Level: level,
-			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
-				// Rename 'msg' to 'message'
-				if a.Key == slog.MessageKey {
-					return slog.Attr{Key: "message", Value: a.Value}
-				}
-				// Format time as ISO 8601
-				if a.Key == slog.TimeKey {
-					if t, ok := a.Value.Any().(time.Time); ok {
-						return slog.Attr{Key: "timestamp", Value: slog.StringValue(t.Format(time.RFC3339))}
-					}
-				}
-				return a
-			},
-		}),
+		handler: inner,
+		levels:  levels,
 	}
 
 	// Create base logger with service and hostname
@@ -81,21 +104,28 @@ func InitLogger(serviceName, logLevel string) Logger {
 
 	return &logger{
 		slog:     base,
+		levels:   levels,
+		sampler:  newSampler(opts.Sample),
 		service:  serviceName,
 		hostname: hostname,
 	}
 }
 
-// Context handler to inject request_id
+// Context handler to inject request_id and component, it filters records by level of the component
 type contextHandler struct {
-	handler slog.Handler
+	handler   slog.Handler
+	levels    *levels
+	component string
 }
 
 func (h *contextHandler) Enabled(ctx context.Context, lvl slog.Level) bool {
-	return h.handler.Enabled(ctx, lvl)
+	return lvl >= h.levels.get(h.component)
 }
 
 func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if h.component != "" {
+		r.AddAttrs(slog.String("component", h.component))
+	}
 	if reqID, ok := ctx.Value(models.GetRequestIDKey()).(string); ok {
 		r.AddAttrs(slog.String("request_id", reqID))
 	}
@@ -103,38 +133,67 @@ func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
 }
 
 func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return &contextHandler{handler: h.handler.WithAttrs(attrs)}
+	return &contextHandler{handler: h.handler.WithAttrs(attrs), levels: h.levels, component: h.component}
 }
 
 func (h *contextHandler) WithGroup(name string) slog.Handler {
-	return &contextHandler{handler: h.handler.WithGroup(name)}
+	return &contextHandler{handler: h.handler.WithGroup(name), levels: h.levels, component: h.component}
 }
 
 // Logger methods
 func (l *logger) Debug(ctx context.Context, action, msg string, args ...any) {
-	l.slog.DebugContext(ctx, msg, append(args, "action", action)...)
+	l.log(ctx, slog.LevelDebug, action, msg, args)
 }
 
 func (l *logger) Info(ctx context.Context, action, msg string, args ...any) {
-	l.slog.InfoContext(ctx, msg, append(args, "action", action)...)
+	l.log(ctx, slog.LevelInfo, action, msg, args)
 }
 
 func (l *logger) Warn(ctx context.Context, action, msg string, args ...any) {
-	l.slog.WarnContext(ctx, msg, append(args, "action", action)...)
+	l.log(ctx, slog.LevelWarn, action, msg, args)
+}
+
+// log writes record if its level is enabled and the action is not sampled out.
+func (l *logger) log(ctx context.Context, lvl slog.Level, action, msg string, args []any) {
+	if !l.slog.Enabled(ctx, lvl) || !l.sampler.keep(action) {
+		return
+	}
+	l.slog.Log(ctx, lvl, msg, append(args, "action", action)...)
 }
 
 func (l *logger) Error(ctx context.Context, action, msg string, err error, args ...any) {
 	attrs := []any{
 		"action", action,
-		"error", slog.GroupValue(
-			slog.String("msg", err.Error()),
-			slog.String("stack", getStack()),
-		),
+		"error", errorValue(err),
 	}
 	attrs = append(attrs, args...)
 	l.slog.ErrorContext(ctx, msg, attrs...)
 }
 
+func (l *logger) Component(name string) Logger {
+	h := *l.slog.Handler().(*contextHandler)
+	h.component = name
+
+	c := *l
+	c.slog = slog.New(&h)
+	return &c
+}
+
+func (l *logger) SetLevel(logLevel string) error {
+	return l.levels.set("", logLevel)
+}
+
+func (l *logger) SetComponentLevel(component, logLevel string) error {
+	if component == "" {
+		return fmt.Errorf("component name is empty")
+	}
+	return l.levels.set(component, logLevel)
+}
+
+func (l *logger) Levels() Levels {
+	return l.levels.snapshot()
+}
+
 func (l *logger) GetSlogLogger() *slog.Logger {
 	return l.slog
 }
@@ -143,28 +202,3 @@ func (l *logger) GetSlogLogger() *slog.Logger {
 func WithRequestID(ctx context.Context, requestID string) context.Context {
 	return context.WithValue(ctx, models.GetRequestIDKey(), requestID)
 }
-
-// getStack return stack info
-func getStack() string {
-	var stackBuf [4096]byte
-	n := runtime.Stack(stackBuf[:], false)
-	stack := string(stackBuf[:n])
-
-	lines := strings.SplitSeq(stack, "\n")
-	for line := range lines {
-		if strings.Contains(line, "\t") &&
-			!strings.Contains(line, "runtime/") &&
-			!strings.Contains(line, "pkg/logger") {
-			// Return just the first relevant file:line
-			if idx := strings.LastIndex(line, "/"); idx > 0 {
-				line = line[idx+1:]
-			}
-			line = strings.TrimSpace(strings.TrimPrefix(line, "\t"))
-			if colon := strings.Index(line, ":"); colon > 0 {
-				return line[:colon] + ":" + strings.Split(line[colon+1:], " ")[0]
-			}
-			return line
-		}
-	}
-	return ""
-}
@@ -0,0 +1,336 @@
+// Package migrate applies versioned SQL migrations to postgres.
+//
+// Migrations are pairs of files named '<version>_<name>.up.sql' and '<version>_<name>.down.sql'.
+// Applied version is kept in 'schema_migrations' table in the format of golang-migrate, so a
+// database migrated by it is picked up as is. Every migration runs in its own transaction
+// together with the version update, and migrations are serialized between processes by an
+// advisory lock.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// lockKey is the key of the advisory lock taken while migrating.
+const lockKey int64 = 0x77_6d_70_6d_69_67 // "wmpmig"
+
+var (
+	ErrDirty          = errors.New("database is dirty, fix it manually and force the version")
+	ErrUnknownVersion = errors.New("unknown migration version")
+	ErrNoDownScript   = errors.New("migration has no down script")
+	ErrInvalidSteps   = errors.New("number of steps must be positive")
+)
+
+var fileRX = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration is a schema version with its scripts.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is the state of the database schema.
+type Status struct {
+	Version    int  // 0 - nothing applied
+	Dirty      bool // failed migration left by another tool
+	Migrations []Migration
+}
+
+// Pending returns migrations not applied yet.
+func (s Status) Pending() []Migration {
+	var pending []Migration
+	for _, m := range s.Migrations {
+		if m.Version > s.Version {
+			pending = append(pending, m)
+		}
+	}
+	return pending
+}
+
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration // by version ascending
+}
+
+// New creates migrator of the migrations found in the root of fsys.
+func New(pool *pgxpool.Pool, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		pool:       pool,
+		migrations: migrations,
+	}, nil
+}
+
+// Load reads migrations from the root of fsys, files of other names are skipped.
+func Load(fsys fs.FS) ([]Migration, error) {
+	const op = "migrate.Load"
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := fileRX.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("%s: %s: invalid version", op, entry.Name())
+		}
+
+		script, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("%s: version %d has different names %q and %q", op, version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(script)
+		} else {
+			m.Down = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("%s: version %d has no up script", op, m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return a.Version - b.Version })
+
+	return migrations, nil
+}
+
+// Up applies all pending migrations and returns them.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+
+	err := m.locked(ctx, func(conn *pgxpool.Conn) error {
+		version, err := m.current(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration.Up, migration.Version); err != nil {
+				return fmt.Errorf("up %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down reverts steps latest applied migrations and returns them.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps < 1 {
+		return nil, ErrInvalidSteps
+	}
+
+	var reverted []Migration
+
+	err := m.locked(ctx, func(conn *pgxpool.Conn) error {
+		version, err := m.current(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for ; steps > 0 && version > 0; steps-- {
+			i := m.index(version)
+			if i < 0 {
+				return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+			}
+
+			migration := m.migrations[i]
+			if migration.Down == "" {
+				return fmt.Errorf("down %d_%s: %w", migration.Version, migration.Name, ErrNoDownScript)
+			}
+
+			previous := 0
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+
+			if err := m.apply(ctx, conn, migration.Down, previous); err != nil {
+				return fmt.Errorf("down %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			reverted = append(reverted, migration)
+			version = previous
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Status returns applied version and all known migrations.
+func (m *Migrator) Status(ctx context.Context) (Status, error) {
+	status := Status{Migrations: m.migrations}
+
+	err := m.locked(ctx, func(conn *pgxpool.Conn) error {
+		var err error
+		status.Version, status.Dirty, err = m.version(ctx, conn)
+		return err
+	})
+
+	return status, err
+}
+
+// Force sets applied version without running migrations and clears dirty state.
+// Version 0 marks the database as not migrated.
+func (m *Migrator) Force(ctx context.Context, version int) error {
+	if version != 0 && m.index(version) < 0 {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	return m.locked(ctx, func(conn *pgxpool.Conn) error {
+		tx, err := conn.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback(ctx)
+
+		if err := setVersion(ctx, tx, version); err != nil {
+			return err
+		}
+
+		return tx.Commit(ctx)
+	})
+}
+
+// locked runs fn on a connection holding the migration lock, the version table is created if needed.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	const op = "migrate.locked"
+
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1);`, lockKey); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		// session settings changed by scripts must not leak into the pool
+		ctx := context.WithoutCancel(ctx)
+		conn.Exec(ctx, `RESET ALL;`)
+		conn.Exec(ctx, `SELECT pg_advisory_unlock($1);`, lockKey)
+	}()
+
+	query := `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint  NOT NULL PRIMARY KEY,
+		dirty   boolean NOT NULL
+	);`
+
+	if _, err := conn.Exec(ctx, query); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return fn(conn)
+}
+
+// current returns applied version, failing on dirty database.
+func (m *Migrator) current(ctx context.Context, conn *pgxpool.Conn) (int, error) {
+	version, dirty, err := m.version(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("%w: version %d", ErrDirty, version)
+	}
+
+	return version, nil
+}
+
+func (m *Migrator) version(ctx context.Context, conn *pgxpool.Conn) (int, bool, error) {
+	var (
+		version int
+		dirty   bool
+	)
+	err := conn.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1;`).Scan(&version, &dirty)
+	if err != nil && err != pgx.ErrNoRows {
+		return 0, false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	return version, dirty, nil
+}
+
+// apply runs script and sets version in one transaction.
+func (m *Migrator) apply(ctx context.Context, conn *pgxpool.Conn, script string, version int) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, script); err != nil {
+		return err
+	}
+
+	if err := setVersion(ctx, tx, version); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// index returns position of the version in migrations, -1 if it's unknown.
+func (m *Migrator) index(version int) int {
+	return slices.IndexFunc(m.migrations, func(migration Migration) bool {
+		return migration.Version == version
+	})
+}
+
+// setVersion stores applied version, the table is left empty for version 0.
+func setVersion(ctx context.Context, tx pgx.Tx, version int) error {
+	if _, err := tx.Exec(ctx, `TRUNCATE schema_migrations;`); err != nil {
+		return fmt.Errorf("failed to set schema version: %w", err)
+	}
+	if version == 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, false);`, version); err != nil {
+		return fmt.Errorf("failed to set schema version: %w", err)
+	}
+
+	return nil
+}
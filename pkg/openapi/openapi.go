@@ -0,0 +1,193 @@
+// Package openapi checks a hand-written OpenAPI 3.1 document against the code it describes and
+// serves parts of it.
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// methods of path items which are operations.
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// Spec is a parsed OpenAPI document.
+type Spec struct {
+	doc map[string]any
+}
+
+// Parse parses JSON OpenAPI document.
+func Parse(data []byte) (*Spec, error) {
+	const op = "openapi.Parse"
+
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if _, ok := doc["paths"].(map[string]any); !ok {
+		return nil, fmt.Errorf("%s: document has no paths", op)
+	}
+
+	return &Spec{doc: doc}, nil
+}
+
+// Routes returns operations tagged with one of tags as http.ServeMux patterns, e.g.
+// "GET /orders/{order_number}/status", sorted.
+func (s *Spec) Routes(tags ...string) []string {
+	var routes []string
+	for path, item := range s.paths() {
+		for method, operation := range operations(item) {
+			opTags, _ := operation["tags"].([]any)
+			for _, tag := range opTags {
+				if tag, ok := tag.(string); ok && slices.Contains(tags, tag) {
+					routes = append(routes, strings.ToUpper(method)+" "+path)
+					break
+				}
+			}
+		}
+	}
+	slices.Sort(routes)
+	return routes
+}
+
+// CheckRoutes returns error if routes registered by the code differ from operations tagged with
+// one of tags. Routes without method are GET routes.
+func (s *Spec) CheckRoutes(routes []string, tags ...string) error {
+	registered := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		registered[normalize(route)] = true
+	}
+	described := make(map[string]bool)
+	for _, route := range s.Routes(tags...) {
+		described[route] = true
+	}
+
+	var errs []error
+	for _, route := range slices.Sorted(maps.Keys(registered)) {
+		if !described[route] {
+			errs = append(errs, fmt.Errorf("route %s is not described", route))
+		}
+	}
+	for _, route := range slices.Sorted(maps.Keys(described)) {
+		if !registered[route] {
+			errs = append(errs, fmt.Errorf("operation %s has no route", route))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CheckSchema returns error if properties of the component schema differ from JSON fields of the
+// struct type. Fields of embedded structs are fields of the struct as encoding/json treats them.
+func (s *Spec) CheckSchema(name string, typ reflect.Type) error {
+	schemas, _ := s.components()["schemas"].(map[string]any)
+	schema, ok := schemas[name].(map[string]any)
+	if !ok {
+		return fmt.Errorf("schema %s is not described", name)
+	}
+	properties, _ := schema["properties"].(map[string]any)
+
+	fields := jsonFields(typ)
+	var errs []error
+	for _, field := range fields {
+		if _, ok := properties[field]; !ok {
+			errs = append(errs, fmt.Errorf("schema %s: field %s of %s is not described", name, field, typ))
+		}
+	}
+	for _, property := range slices.Sorted(maps.Keys(properties)) {
+		if !slices.Contains(fields, property) {
+			errs = append(errs, fmt.Errorf("schema %s: property %s is not a field of %s", name, property, typ))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Marshal returns JSON document with operations of the routes only.
+func (s *Spec) Marshal(routes []string) ([]byte, error) {
+	keep := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		keep[normalize(route)] = true
+	}
+
+	paths := make(map[string]any)
+	for path, item := range s.paths() {
+		filtered := make(map[string]any)
+		for key, value := range item {
+			if !slices.Contains(methods, key) || keep[strings.ToUpper(key)+" "+path] {
+				filtered[key] = value
+			}
+		}
+		if len(operations(filtered)) > 0 {
+			paths[path] = filtered
+		}
+	}
+
+	doc := maps.Clone(s.doc)
+	doc["paths"] = paths
+	return json.Marshal(doc)
+}
+
+func (s *Spec) paths() map[string]map[string]any {
+	paths := make(map[string]map[string]any)
+	for path, item := range s.doc["paths"].(map[string]any) {
+		if item, ok := item.(map[string]any); ok {
+			paths[path] = item
+		}
+	}
+	return paths
+}
+
+func (s *Spec) components() map[string]any {
+	components, _ := s.doc["components"].(map[string]any)
+	return components
+}
+
+// operations returns operations of the path item by lowercase method.
+func operations(item map[string]any) map[string]map[string]any {
+	ops := make(map[string]map[string]any)
+	for _, method := range methods {
+		if operation, ok := item[method].(map[string]any); ok {
+			ops[method] = operation
+		}
+	}
+	return ops
+}
+
+// normalize returns route with method, e.g. "GET /health" for "/health".
+func normalize(route string) string {
+	if !strings.Contains(route, " ") {
+		return "GET " + route
+	}
+	return route
+}
+
+// jsonFields returns names of fields of the struct type in JSON.
+func jsonFields(typ reflect.Type) []string {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	var fields []string
+	for i := range typ.NumField() {
+		f := typ.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" && f.Anonymous && f.Type.Kind() == reflect.Struct {
+			fields = append(fields, jsonFields(f.Type)...)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, name)
+	}
+	return fields
+}
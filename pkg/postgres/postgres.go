@@ -14,13 +14,13 @@ type PostgreDB struct {
 }
 
 type Config struct {
-	Host         string `env:"POSTGRES_HOST"`
-	Port         string `env:"POSTGRES_PORT"`
-	User         string `env:"POSTGRES_USER"`
-	Password     string `env:"POSTGRES_PASSWORD"`
-	DBName       string `env:"POSTGRES_DATABASE"`
-	MaxOpenConns int32  `env:"POSTGRES_MAX_OPEN_CONN" default:"25"`
-	MaxIdleTime  string `env:"POSTGRES_MAX_IDLE_TIME" default:"15m"`
+	Host         string `env:"POSTGRES_HOST" default:"localhost" yaml:"host"`
+	Port         string `env:"POSTGRES_PORT" default:"5432" yaml:"port"`
+	User         string `env:"POSTGRES_USER" yaml:"user"`
+	Password     string `env:"POSTGRES_PASSWORD" yaml:"password"`
+	DBName       string `env:"POSTGRES_DATABASE" yaml:"database"`
+	MaxOpenConns int32  `env:"POSTGRES_MAX_OPEN_CONN" default:"25" yaml:"max_open_conn"`
+	MaxIdleTime  string `env:"POSTGRES_MAX_IDLE_TIME" default:"15m" yaml:"max_idle_time"`
 }
 
 func (c Config) GetDsn() string {
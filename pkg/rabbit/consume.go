@@ -0,0 +1,200 @@
+package rabbit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"wheres-my-pizza/internal/domain/types"
+)
+
+// Subscription describes consumer which is restored after reconnect.
+type Subscription struct {
+	Queue    string
+	Prefetch int // basic.qos prefetch count, 0 - unlimited
+
+	// Qos, if set, receives new prefetch counts which are applied to the consumer channel
+	// at runtime and kept after reconnect.
+	Qos <-chan int
+
+	// Setup is called on the consumer channel before every (re)subscription, e.g. to declare
+	// a server-named queue. It returns the name of the queue to consume. Optional.
+	Setup func(ch *amqp.Channel) (string, error)
+
+	// Drained, if set, lets deliveries being handled be acknowledged after ctx is cancelled:
+	// consuming is stopped right away (basic.cancel), but the channel is kept open until
+	// Drained is closed. Without it the channel is closed at once and the broker requeues
+	// all unacknowledged deliveries.
+	Drained <-chan struct{}
+
+	// Lost, if set, receives a value when the consumer channel is lost, after all its deliveries
+	// were passed on and before resubscribing. Those deliveries can't be acknowledged anymore and
+	// the broker redelivers them, so their handling should be stopped.
+	Lost chan<- struct{}
+}
+
+// Consume starts consuming on a dedicated channel and returns deliveries channel which survives
+// reconnects: when the channel or the connection is lost, QoS and consumer are restored on a new
+// channel. Deliveries received before reconnect can't be acknowledged anymore, the broker
+// redelivers them, see Subscription.Lost. The returned channel is closed when ctx is cancelled or the client stops,
+// prefetched deliveries which were not passed on are requeued.
+func (r *RabbitMQ) Consume(ctx context.Context, sub Subscription) (<-chan amqp.Delivery, error) {
+	tag := "ctag-" + newMessageID()
+
+	ch, msgs, err := r.subscribe(ctx, sub, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan amqp.Delivery)
+	go r.forward(ctx, sub, tag, ch, msgs, out)
+
+	return out, nil
+}
+
+// subscribe opens a channel, restores QoS and starts consuming.
+func (r *RabbitMQ) subscribe(ctx context.Context, sub Subscription, tag string) (*amqp.Channel, <-chan amqp.Delivery, error) {
+	ch, err := r.Channel(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// In RabbitMQ, basic.qos is a method used to configure the quality of service for consumers,
+	// specifically by controlling how many messages a consumer can receive without acknowledging them.
+	if sub.Prefetch > 0 {
+		if err := ch.Qos(sub.Prefetch, 0, false); err != nil {
+			ch.Close()
+			return nil, nil, fmt.Errorf("failed to set QoS: %w", err)
+		}
+	}
+
+	queue := sub.Queue
+	if sub.Setup != nil {
+		if queue, err = sub.Setup(ch); err != nil {
+			ch.Close()
+			return nil, nil, err
+		}
+	}
+
+	msgs, err := ch.Consume(queue, tag, false, false, false, false, nil)
+	if err != nil {
+		ch.Close()
+		return nil, nil, fmt.Errorf("failed to consume queue %s: %w", queue, err)
+	}
+
+	return ch, msgs, nil
+}
+
+// forward passes deliveries to out and resubscribes when the consumer channel is closed.
+func (r *RabbitMQ) forward(ctx context.Context, sub Subscription, tag string, ch *amqp.Channel, msgs <-chan amqp.Delivery, out chan<- amqp.Delivery) {
+	for {
+		if !r.pass(ctx, &sub, ch, msgs, out) {
+			close(out)
+			r.stopConsuming(sub, tag, ch, msgs)
+			return
+		}
+
+		// Deliveries channel closed: the channel or the whole connection is lost.
+		ch.Close()
+		r.log.Warn(ctx, types.ActionRabbitReconnect, "consumer channel closed, resubscribing", "queue", sub.Queue)
+
+		if sub.Lost != nil {
+			select {
+			case sub.Lost <- struct{}{}:
+			case <-ctx.Done():
+				close(out)
+				return
+			case <-r.done:
+				close(out)
+				return
+			}
+		}
+
+		var err error
+		for attempt := 1; ; attempt++ {
+			ch, msgs, err = r.subscribe(ctx, sub, tag)
+			if err == nil {
+				break
+			}
+
+			if ctx.Err() != nil || r.Err() != nil || r.closed.Load() {
+				close(out)
+				return
+			}
+
+			r.log.Warn(ctx, types.ActionRabbitReconnect, "failed to resubscribe consumer", "queue", sub.Queue, "attempt", attempt, "error", err)
+
+			cfg := r.reconnectConfig()
+			select {
+			case <-time.After(backoff(attempt, cfg.ReconnectDelay, cfg.ReconnectMaxDelay)):
+			case <-ctx.Done():
+				close(out)
+				return
+			case <-r.done:
+				close(out)
+				return
+			}
+		}
+
+		r.log.Info(ctx, types.ActionRabbitReconnect, "consumer resubscribed", "queue", sub.Queue)
+	}
+}
+
+// pass forwards deliveries until msgs is closed (returns true) or consuming must stop (returns false).
+// Prefetch count received from sub.Qos is applied to ch.
+func (r *RabbitMQ) pass(ctx context.Context, sub *Subscription, ch *amqp.Channel, msgs <-chan amqp.Delivery, out chan<- amqp.Delivery) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-r.done:
+			return false
+		case prefetch := <-sub.Qos:
+			sub.Prefetch = prefetch
+			if err := ch.Qos(prefetch, 0, false); err != nil {
+				// Failed basic.qos closes the channel, the consumer is resubscribed with new prefetch.
+				r.log.Warn(ctx, types.ActionRabbitReconnect, "failed to set QoS", "queue", sub.Queue, "error", err)
+			}
+		case msg, ok := <-msgs:
+			if !ok {
+				return true
+			}
+
+			select {
+			case out <- msg:
+			case <-ctx.Done():
+				msg.Nack(false, true)
+				return false
+			case <-r.done:
+				return false
+			}
+		}
+	}
+}
+
+// stopConsuming cancels the consumer, requeues prefetched deliveries and closes the channel
+// once deliveries being handled are drained.
+func (r *RabbitMQ) stopConsuming(sub Subscription, tag string, ch *amqp.Channel, msgs <-chan amqp.Delivery) {
+	defer ch.Close()
+
+	if sub.Drained == nil || r.closed.Load() {
+		return
+	}
+
+	if err := ch.Cancel(tag, false); err != nil {
+		// The channel is already closed, the broker requeues everything by itself.
+		return
+	}
+
+	// msgs is closed after the consumer is cancelled.
+	for msg := range msgs {
+		msg.Nack(false, true)
+	}
+
+	select {
+	case <-sub.Drained:
+	case <-r.done:
+	}
+}
@@ -0,0 +1,70 @@
+package rabbit
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventDisconnected    EventType = "disconnected"
+	EventReconnected     EventType = "reconnected"
+	EventReconnectFailed EventType = "reconnect_failed"
+)
+
+// Event describes change of the connection state.
+type Event struct {
+	Type    EventType
+	Attempt int   // reconnect attempt, only for EventReconnected
+	Err     error // reason of disconnect or reconnect failure
+}
+
+type eventNotifier struct {
+	mu        sync.Mutex
+	listeners []chan Event
+}
+
+// NotifyEvents registers a listener for connection state events and returns it.
+// Events are dropped for listeners which are not ready to receive, so the channel should be buffered.
+func (r *RabbitMQ) NotifyEvents(c chan Event) chan Event {
+	r.events.mu.Lock()
+	defer r.events.mu.Unlock()
+
+	r.events.listeners = append(r.events.listeners, c)
+	return c
+}
+
+func (n *eventNotifier) notify(e Event) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, c := range n.listeners {
+		select {
+		case c <- e:
+		default:
+		}
+	}
+}
+
+// backoff returns exponential delay for the attempt capped by max with jitter in range [delay/2, delay].
+func backoff(attempt int, base, max time.Duration) time.Duration {
+	if base <= 0 {
+		base = time.Second
+	}
+	if max < base {
+		max = base
+	}
+
+	delay := base
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+
+	half := delay / 2
+	return half + rand.N(half+1)
+}
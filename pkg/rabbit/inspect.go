@@ -0,0 +1,102 @@
+package rabbit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// QueueStats is a snapshot of a queue state.
+type QueueStats struct {
+	Name      string
+	Ready     int // messages ready for delivery
+	Consumers int
+
+	// Available only through management API, nil if it is not reachable.
+	Unacked       *int       // messages delivered but not acknowledged yet
+	HeadTimestamp *time.Time // publish timestamp of the oldest ready message
+}
+
+// InspectQueue returns queue state. Ready and consumer counts are taken from passive queue.declare,
+// unacked count and head message timestamp from management API when it is available.
+func (r *RabbitMQ) InspectQueue(ctx context.Context, name string) (QueueStats, error) {
+	var q amqp.Queue
+	if err := r.WithChannel(ctx, func(ch *amqp.Channel) error {
+		var err error
+		q, err = ch.QueueDeclarePassive(name, false, false, false, false, nil)
+		return err
+	}); err != nil {
+		return QueueStats{}, fmt.Errorf("failed to inspect queue %s: %w", name, err)
+	}
+
+	stats := QueueStats{
+		Name:      q.Name,
+		Ready:     q.Messages,
+		Consumers: q.Consumers,
+	}
+
+	if r.cfg.ManagementPort == "" {
+		return stats, nil
+	}
+
+	details, err := r.managementQueue(ctx, name)
+	if err != nil {
+		r.log.Debug(ctx, "rabbitmq_management", "management API is not available", "queue", name, "error", err)
+		return stats, nil
+	}
+
+	unacked := details.Unacked
+	stats.Unacked = &unacked
+	if details.HeadTimestamp != nil && stats.Ready > 0 {
+		ts := time.Unix(*details.HeadTimestamp, 0)
+		stats.HeadTimestamp = &ts
+	}
+
+	return stats, nil
+}
+
+// managementQueueInfo is a part of management API response for a queue.
+type managementQueueInfo struct {
+	Unacked       int    `json:"messages_unacknowledged"`
+	HeadTimestamp *int64 `json:"head_message_timestamp"`
+}
+
+var managementClient = &http.Client{Timeout: 3 * time.Second}
+
+// managementQueue requests queue details from management API of the default vhost.
+func (r *RabbitMQ) managementQueue(ctx context.Context, name string) (managementQueueInfo, error) {
+	endpoint := fmt.Sprintf("http://%s:%s/api/queues/%s/%s",
+		r.cfg.Host,
+		r.cfg.ManagementPort,
+		url.PathEscape("/"),
+		url.PathEscape(name),
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return managementQueueInfo{}, err
+	}
+	req.SetBasicAuth(r.cfg.User, r.cfg.Password)
+
+	resp, err := managementClient.Do(req)
+	if err != nil {
+		return managementQueueInfo{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return managementQueueInfo{}, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var info managementQueueInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return managementQueueInfo{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return info, nil
+}
@@ -0,0 +1,125 @@
+package rabbit
+
+import (
+	"context"
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// returnBuffer is the capacity of the basic.return notification channel. Returned
+// messages are read back by the publisher, so the buffer only has to hold returns
+// of publishings that timed out before their confirmation arrived.
+const returnBuffer = 64
+
+// channelPool keeps channels of one connection for reuse. Pooled channels are in confirm
+// mode, so any of them can be used for confirmed publishing. A channel is used by a single
+// goroutine between acquire and release.
+type channelPool struct {
+	conn  *amqp.Connection
+	idle  chan *pooledChannel
+	slots chan struct{} // limits number of open channels
+}
+
+type pooledChannel struct {
+	*amqp.Channel
+	returns chan amqp.Return
+
+	pool *channelPool
+}
+
+func newChannelPool(conn *amqp.Connection, size int) *channelPool {
+	return &channelPool{
+		conn:  conn,
+		idle:  make(chan *pooledChannel, size),
+		slots: make(chan struct{}, size),
+	}
+}
+
+// acquire returns idle channel, opens a new one if the pool is not full
+// or waits until another goroutine releases a channel.
+func (p *channelPool) acquire(ctx context.Context) (*pooledChannel, error) {
+	for {
+		// Prefer already opened channels
+		select {
+		case ch := <-p.idle:
+			if ch.IsClosed() {
+				<-p.slots
+				continue
+			}
+			return ch, nil
+		default:
+		}
+
+		select {
+		case ch := <-p.idle:
+			if ch.IsClosed() {
+				<-p.slots
+				continue
+			}
+			return ch, nil
+		case p.slots <- struct{}{}:
+			ch, err := p.open()
+			if err != nil {
+				<-p.slots
+				return nil, err
+			}
+			return ch, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// open opens a new channel in confirm mode.
+func (p *channelPool) open() (*pooledChannel, error) {
+	if p.conn.IsClosed() {
+		return nil, ErrConnectionClosed
+	}
+
+	ch, err := p.conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a channel: %w", err)
+	}
+
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, fmt.Errorf("failed to put channel into confirm mode: %w", err)
+	}
+
+	return &pooledChannel{
+		Channel: ch,
+		returns: ch.NotifyReturn(make(chan amqp.Return, returnBuffer)),
+		pool:    p,
+	}, nil
+}
+
+// release gives the channel back to its pool. Channels closed by the broker
+// (e.g. after a failed declaration) are dropped.
+func (ch *pooledChannel) release() {
+	if ch.IsClosed() {
+		<-ch.pool.slots
+		return
+	}
+
+	select {
+	case ch.pool.idle <- ch:
+	default:
+		// Never happens: idle has a slot for every open channel.
+		ch.Close()
+		<-ch.pool.slots
+	}
+}
+
+// close closes all idle channels.
+func (p *channelPool) close() {
+	for {
+		select {
+		case ch := <-p.idle:
+			ch.Close()
+			<-p.slots
+		default:
+			return
+		}
+	}
+}
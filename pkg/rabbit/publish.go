@@ -0,0 +1,223 @@
+package rabbit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"wheres-my-pizza/internal/domain/types"
+)
+
+var (
+	ErrPublishNacked   = errors.New("message was nacked by the broker")
+	ErrPublishReturned = errors.New("message was returned by the broker as unroutable")
+	ErrConfirmTimeout  = errors.New("timed out waiting for publisher confirmation")
+)
+
+// PublishConfirmed publishes message on a pooled channel and waits until the broker acknowledges
+// it or timeout expires. For mandatory publishings a basic.return of the message is reported as
+// ErrPublishReturned. The channel is held until confirmation arrives, RabbitMQ always sends
+// basic.return before basic.ack of the same message, so at that point the return (if any) is
+// already buffered.
+func (r *RabbitMQ) PublishConfirmed(
+	ctx context.Context,
+	exchange, key string,
+	mandatory bool,
+	msg amqp.Publishing,
+	timeout time.Duration,
+) error {
+	ch, err := r.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer ch.release()
+
+	if msg.MessageId == "" {
+		msg.MessageId = newMessageID()
+	}
+
+	// Dropping returns left by publishings which timed out earlier.
+	r.drainReturns(ch, "")
+
+	confirmation, err := ch.PublishWithDeferredConfirmWithContext(ctx, exchange, key, mandatory, false, msg)
+	if err != nil {
+		return err
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	acked, err := confirmation.WaitContext(waitCtx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("%w: message %s", ErrConfirmTimeout, msg.MessageId)
+		}
+		return err
+	}
+
+	if !acked {
+		return fmt.Errorf("%w: message %s", ErrPublishNacked, msg.MessageId)
+	}
+
+	if mandatory {
+		if ret, ok := r.drainReturns(ch, msg.MessageId); ok {
+			return fmt.Errorf("%w: %d %s (exchange %q, routing key %q)",
+				ErrPublishReturned, ret.ReplyCode, ret.ReplyText, ret.Exchange, ret.RoutingKey)
+		}
+	}
+
+	return nil
+}
+
+// Publishing is a message of a batch with its routing key.
+type Publishing struct {
+	Key string
+	Msg amqp.Publishing
+}
+
+// PublishConfirmedBatch publishes messages on one pooled channel without waiting for confirmation of
+// each of them and then waits for all confirmations within timeout. Returns error of every message by
+// its index, nil if the message was confirmed. The error is returned if no channel is available.
+func (r *RabbitMQ) PublishConfirmedBatch(
+	ctx context.Context,
+	exchange string,
+	mandatory bool,
+	batch []Publishing,
+	timeout time.Duration,
+) ([]error, error) {
+	ch, err := r.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer ch.release()
+
+	r.drainReturns(ch, "")
+
+	// Returns are collected while confirmations are awaited, so a big batch does not
+	// fill up the returns buffer and block the connection.
+	returned := make(map[string]amqp.Return)
+	stop := make(chan struct{})
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for {
+			select {
+			case ret, open := <-ch.returns:
+				if !open {
+					return
+				}
+				returned[ret.MessageId] = ret
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	errs := make([]error, len(batch))
+	ids := make([]string, len(batch))
+	confirmations := make([]*amqp.DeferredConfirmation, len(batch))
+	for i, p := range batch {
+		msg := p.Msg
+		if msg.MessageId == "" {
+			msg.MessageId = newMessageID()
+		}
+		ids[i] = msg.MessageId
+
+		confirmations[i], errs[i] = ch.PublishWithDeferredConfirmWithContext(ctx, exchange, p.Key, mandatory, false, msg)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for i, confirmation := range confirmations {
+		if errs[i] != nil {
+			continue
+		}
+
+		acked, err := confirmation.WaitContext(waitCtx)
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			errs[i] = fmt.Errorf("%w: message %s", ErrConfirmTimeout, ids[i])
+		case err != nil:
+			errs[i] = err
+		case !acked:
+			errs[i] = fmt.Errorf("%w: message %s", ErrPublishNacked, ids[i])
+		}
+	}
+
+	close(stop)
+	<-collected
+
+	if mandatory {
+		// basic.return comes before basic.ack of the same message, so it is collected or buffered by now
+		for {
+			ret, ok := takeReturn(ch)
+			if !ok {
+				break
+			}
+			returned[ret.MessageId] = ret
+		}
+
+		for i, id := range ids {
+			if ret, ok := returned[id]; ok && errs[i] == nil {
+				errs[i] = fmt.Errorf("%w: %d %s (exchange %q, routing key %q)",
+					ErrPublishReturned, ret.ReplyCode, ret.ReplyText, ret.Exchange, ret.RoutingKey)
+			}
+		}
+	}
+
+	return errs, nil
+}
+
+// takeReturn takes one buffered return of the channel if there is one.
+func takeReturn(ch *pooledChannel) (amqp.Return, bool) {
+	select {
+	case ret, open := <-ch.returns:
+		return ret, open
+	default:
+		return amqp.Return{}, false
+	}
+}
+
+// drainReturns empties buffered returns of the channel and reports the one matching messageID.
+func (r *RabbitMQ) drainReturns(ch *pooledChannel, messageID string) (amqp.Return, bool) {
+	var (
+		found amqp.Return
+		ok    bool
+	)
+
+	for {
+		select {
+		case ret, open := <-ch.returns:
+			if !open {
+				return found, ok
+			}
+			if messageID != "" && ret.MessageId == messageID {
+				found, ok = ret, true
+				continue
+			}
+			r.log.Warn(context.Background(), types.ActionRabbitMQPublishFailed, "message was returned by broker",
+				"message-id", ret.MessageId,
+				"exchange", ret.Exchange,
+				"routing-key", ret.RoutingKey,
+				"reply", ret.ReplyText,
+			)
+		default:
+			return found, ok
+		}
+	}
+}
+
+// newMessageID returns a random hex identifier for publishing correlation.
+func newMessageID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
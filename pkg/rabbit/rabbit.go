@@ -2,7 +2,10 @@ package rabbit
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
@@ -11,20 +14,46 @@ import (
 	"wheres-my-pizza/pkg/logger"
 )
 
+var (
+	ErrConnectionClosed = errors.New("rabbitmq connection is closed")
+	ErrClientClosed     = errors.New("rabbitmq client is closed")
+)
+
+// RabbitMQ is a process wide RabbitMQ client. It holds a single connection shared by
+// all producers and consumers of the process, a pool of channels for short operations
+// (publishing, declarations) and the registered topology.
+//
+// The client recovers by itself: when the connection is lost it reconnects with jittered
+// backoff, re-declares registered topology and resubscribes consumers started with Consume.
+// Operations issued while reconnecting wait for the new connection (bounded by their context).
 type RabbitMQ struct {
-	Conn      *amqp.Connection
-	Channel   *amqp.Channel
-	closeChan chan *amqp.Error
-	isClosed  bool
+	cfg Config
+
+	mu       sync.RWMutex
+	conn     *amqp.Connection
+	pool     *channelPool
+	ready    chan struct{} // closed while the connection is open
+	topology *topology
+
+	closed atomic.Bool   // set once the client is closed, it can't be reconnected after that
+	done   chan struct{} // closed together with closed flag
+	err    error         // reason the client was closed, nil for Close
+
+	events *eventNotifier
 
 	log logger.Logger
 }
 
 type Config struct {
-	Host     string `env:"RABBITMQ_HOST" default:"localhost"`
-	Port     string `env:"RABBITMQ_PORT" default:"5672"`
-	User     string `env:"RABBITMQ_USER" default:"guest"`
-	Password string `env:"RABBITMQ_PASSWORD" default:"guest"`
+	Host              string        `env:"RABBITMQ_HOST" default:"localhost" yaml:"host"`
+	Port              string        `env:"RABBITMQ_PORT" default:"5672" yaml:"port"`
+	User              string        `env:"RABBITMQ_USER" default:"guest" yaml:"user"`
+	Password          string        `env:"RABBITMQ_PASSWORD" default:"guest" yaml:"password"`
+	ManagementPort    string        `env:"RABBITMQ_MANAGEMENT_PORT" default:"15672" yaml:"management_port"` // management API for queue details
+	ChannelPoolSize   int           `env:"RABBITMQ_CHANNEL_POOL_SIZE" default:"8" yaml:"channel_pool_size"`
+	ReconnectAttempt  int           `env:"RABBITMQ_RECONNECT_ATTEMPT" default:"5" yaml:"reconnect.attempt"` // 0 - reconnect until closed
+	ReconnectDelay    time.Duration `env:"RABBITMQ_RECONNECT_DELAY" default:"1s" yaml:"reconnect.delay"`
+	ReconnectMaxDelay time.Duration `env:"RABBITMQ_RECONNECT_MAX_DELAY" default:"30s" yaml:"reconnect.max_delay"`
 }
 
 func (c Config) GetDSN() string {
@@ -37,65 +66,276 @@ func (c Config) GetDSN() string {
 }
 
 func New(ctx context.Context, config Config, log logger.Logger) (*RabbitMQ, error) {
-	conn, err := amqp.DialConfig(config.GetDSN(), amqp.Config{
-		Heartbeat: 10 * time.Second,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	if config.ChannelPoolSize < 1 {
+		config.ChannelPoolSize = 1
 	}
 
-	// Create a channel
-	channel, err := conn.Channel()
+	r := &RabbitMQ{
+		cfg:      config,
+		ready:    make(chan struct{}),
+		topology: newTopology(),
+		done:     make(chan struct{}),
+		events:   &eventNotifier{},
+		log:      log.Component("rabbit"),
+	}
+
+	if err := r.connect(); err != nil {
+		return nil, err
+	}
+	r.markConnected()
+
+	log.Info(ctx, types.ActionRabbitMQConnected, "connected to rabbitMQ")
+
+	return r, nil
+}
+
+// connect dials new connection and replaces the channel pool.
+func (r *RabbitMQ) connect() error {
+	conn, err := amqp.DialConfig(r.cfg.GetDSN(), amqp.Config{
+		Heartbeat: 10 * time.Second,
+	})
 	if err != nil {
-		conn.Close() // Close connection if channel creation fails
-		return nil, fmt.Errorf("failed to open a channel: %w", err)
+		return fmt.Errorf("failed to connect to RabbitMQ: %w", err)
 	}
 
 	// Create close notification channel
-	closeChan := make(chan *amqp.Error, 1)
-	conn.NotifyClose(closeChan)
+	closeChan := conn.NotifyClose(make(chan *amqp.Error, 1))
 
 	// Verify the connection is alive
 	select {
 	case closeErr := <-closeChan:
 		if closeErr != nil {
-			return nil, fmt.Errorf("rabbitmq connection is closed: %v", closeErr)
+			return fmt.Errorf("%w: %v", ErrConnectionClosed, closeErr)
 		}
-		return nil, fmt.Errorf("rabbitmq connection is closed")
+		return ErrConnectionClosed
 	default:
 		// Connection is good
 	}
 
-	log.Info(ctx, types.ActionRabbitMQConnected, "connected to rabbitMQ")
+	r.mu.Lock()
+	if r.closed.Load() {
+		r.mu.Unlock()
+		conn.Close()
+		return ErrClientClosed
+	}
 
-	r := &RabbitMQ{
-		Conn:      conn,
-		Channel:   channel,
-		closeChan: closeChan,
-		isClosed:  false,
-		log:       log,
+	old, oldPool := r.conn, r.pool
+	r.conn = conn
+	r.pool = newChannelPool(conn, r.cfg.ChannelPoolSize)
+	r.mu.Unlock()
+
+	// Previous connection may be still open if re-declaring topology on it failed.
+	if oldPool != nil {
+		oldPool.close()
+	}
+	if old != nil && !old.IsClosed() {
+		old.Close()
 	}
 
 	// Start monitoring connection in background
-	go r.monitorConnection()
+	go r.monitorConnection(conn, closeChan)
 
-	return r, nil
+	return nil
 }
 
-// monitorConnection monitors the connection status
-func (r *RabbitMQ) monitorConnection() {
-	closeErr := <-r.closeChan
-	r.isClosed = true
-	if closeErr != nil {
-		r.log.Error(context.Background(), types.ActionRabbitConnectionClosed, "RabbitMQ connection closed with error", closeErr)
-	} else {
+// monitorConnection waits until the connection is closed and starts reconnecting
+// if it wasn't closed by the client itself.
+func (r *RabbitMQ) monitorConnection(conn *amqp.Connection, closeChan chan *amqp.Error) {
+	closeErr := <-closeChan
+	if closeErr == nil || r.closed.Load() {
 		r.log.Debug(context.Background(), types.ActionRabbitConnectionClosed, "RabbitMQ connection closed gracefully")
+		return
+	}
+
+	r.log.Error(context.Background(), types.ActionRabbitConnectionClosed, "RabbitMQ connection closed with error", closeErr)
+	r.markDisconnected(conn, closeErr)
+}
+
+// markDisconnected switches client into reconnecting state once per connection.
+func (r *RabbitMQ) markDisconnected(conn *amqp.Connection, reason error) {
+	r.mu.Lock()
+	if r.conn != conn || !isClosedChan(r.ready) {
+		r.mu.Unlock()
+		return
+	}
+	r.ready = make(chan struct{})
+	r.mu.Unlock()
+
+	r.events.notify(Event{Type: EventDisconnected, Err: reason})
+
+	go r.reconnectLoop()
+}
+
+// markConnected marks current connection ready for use.
+func (r *RabbitMQ) markConnected() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !isClosedChan(r.ready) {
+		close(r.ready)
+	}
+}
+
+// reconnectLoop reconnects with jittered exponential backoff until it succeeds,
+// attempts are exhausted or the client is closed.
+func (r *RabbitMQ) reconnectLoop() {
+	ctx := context.Background()
+
+	var (
+		lastErr error
+		cfg     = r.reconnectConfig()
+	)
+	for attempt := 1; cfg.ReconnectAttempt <= 0 || attempt <= cfg.ReconnectAttempt; attempt, cfg = attempt+1, r.reconnectConfig() {
+		delay := backoff(attempt, cfg.ReconnectDelay, cfg.ReconnectMaxDelay)
+		r.log.Info(ctx, types.ActionRabbitReconnect, fmt.Sprintf("attempt %d to reconnect to RabbitMQ", attempt), "delay", delay.String())
+
+		select {
+		case <-time.After(delay):
+		case <-r.done:
+			return
+		}
+
+		if err := r.reconnect(ctx); err != nil {
+			lastErr = err
+			r.log.Warn(ctx, types.ActionRabbitReconnect, "failed to reconnect to RabbitMQ", "attempt", attempt, "error", err)
+			continue
+		}
+
+		r.log.Info(ctx, types.ActionRabbitMQConnected, "reconnected to rabbitMQ", "attempt", attempt)
+		r.events.notify(Event{Type: EventReconnected, Attempt: attempt})
+		return
+	}
+
+	err := fmt.Errorf("failed to reconnect to RabbitMQ after %d attempts: %w", cfg.ReconnectAttempt, lastErr)
+	r.log.Error(ctx, types.ActionRabbitConnectionFailed, "giving up reconnecting to RabbitMQ", err)
+	r.events.notify(Event{Type: EventReconnectFailed, Err: err})
+	r.shutdown(err)
+}
+
+// SetReconnect applies reconnect attempts and backoff delays of cfg at runtime, other fields
+// are ignored. Reconnecting in progress uses them from the next attempt.
+func (r *RabbitMQ) SetReconnect(cfg Config) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cfg.ReconnectAttempt = cfg.ReconnectAttempt
+	r.cfg.ReconnectDelay = cfg.ReconnectDelay
+	r.cfg.ReconnectMaxDelay = cfg.ReconnectMaxDelay
+}
+
+// reconnectConfig returns config with current reconnect settings, see SetReconnect.
+func (r *RabbitMQ) reconnectConfig() Config {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cfg
+}
+
+// reconnect dials a new connection and re-declares registered topology.
+func (r *RabbitMQ) reconnect(ctx context.Context) error {
+	if err := r.connect(); err != nil {
+		return err
+	}
+
+	r.mu.RLock()
+	pool := r.pool
+	r.mu.RUnlock()
+
+	if err := r.topology.declare(ctx, pool); err != nil {
+		// Leaving the connection, next attempt will replace it.
+		return fmt.Errorf("failed to re-declare topology: %w", err)
 	}
+
+	r.markConnected()
+
+	return nil
 }
 
 // IsConnectionClosed checks if the connection is closed
 func (r *RabbitMQ) IsConnectionClosed() bool {
-	return r.isClosed || r.Conn.IsClosed()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.closed.Load() || r.conn == nil || r.conn.IsClosed()
+}
+
+// Err returns the reason the client stopped working: nil while it is working or
+// after Close, an error if reconnecting failed.
+func (r *RabbitMQ) Err() error {
+	select {
+	case <-r.done:
+		return r.err
+	default:
+		return nil
+	}
+}
+
+// Done returns a channel which is closed when the client stops working.
+func (r *RabbitMQ) Done() <-chan struct{} {
+	return r.done
+}
+
+// connection waits until the connection is open and returns it with its channel pool.
+func (r *RabbitMQ) connection(ctx context.Context) (*amqp.Connection, *channelPool, error) {
+	for {
+		r.mu.RLock()
+		conn, pool, ready := r.conn, r.pool, r.ready
+		r.mu.RUnlock()
+
+		select {
+		case <-r.done:
+			if r.err != nil {
+				return nil, nil, r.err
+			}
+			return nil, nil, ErrClientClosed
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-ready:
+			if !conn.IsClosed() {
+				return conn, pool, nil
+			}
+			// Closed, but close notification is not handled yet.
+			r.markDisconnected(conn, ErrConnectionClosed)
+		}
+	}
+}
+
+// Channel opens a dedicated channel on the shared connection, waiting for reconnect if needed.
+// amqp channels must not be shared between goroutines, so long living operations use their own one.
+// The caller owns the channel and must close it.
+func (r *RabbitMQ) Channel(ctx context.Context) (*amqp.Channel, error) {
+	conn, _, err := r.connection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a channel: %w", err)
+	}
+
+	return ch, nil
+}
+
+// WithChannel runs fn with a channel borrowed from the pool.
+func (r *RabbitMQ) WithChannel(ctx context.Context, fn func(ch *amqp.Channel) error) error {
+	ch, err := r.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer ch.release()
+
+	return fn(ch.Channel)
+}
+
+// acquire borrows a channel from the pool of the current connection.
+func (r *RabbitMQ) acquire(ctx context.Context) (*pooledChannel, error) {
+	_, pool, err := r.connection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return pool.acquire(ctx)
 }
 
 // Close closes rabbit connection
@@ -107,40 +347,37 @@ func (r *RabbitMQ) Close(ctx context.Context) error {
 func (r *RabbitMQ) CloseWithContext(ctx context.Context) error {
 	op := "rabbitMQ:CloseWithContext"
 
-	r.log.Debug(ctx, types.ActionRabbitConnectionClosing, "closing channel", "op", op)
+	if !r.shutdown(nil) {
+		return nil
+	}
 
-	// If connection is already closed, don't try to close channel/connection
-	if r.IsConnectionClosed() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// If connection is already closed, don't try to close channels/connection
+	if r.conn == nil || r.conn.IsClosed() {
 		return nil
 	}
 
-	// Close channel with context
-	done := make(chan error, 1)
+	r.log.Debug(ctx, types.ActionRabbitConnectionClosing, "closing channels", "op", op)
+
+	poolDone := make(chan struct{})
 	go func() {
-		if r.Channel != nil {
-			done <- r.Channel.Close()
-		} else {
-			done <- nil
-		}
+		r.pool.close()
+		close(poolDone)
 	}()
 
 	select {
-	case err := <-done:
-		if err != nil {
-			r.log.Error(ctx, types.ActionRabbitConnectionClosing, "error closing channel", err, "op", op)
-		}
+	case <-poolDone:
 	case <-ctx.Done():
-		r.log.Debug(ctx, types.ActionRabbitConnectionClosing, "context cancelled, forcing channel close", "op", op)
+		r.log.Debug(ctx, types.ActionRabbitConnectionClosing, "context cancelled, forcing channels close", "op", op)
 	}
 
 	r.log.Debug(ctx, types.ActionRabbitConnectionClosing, "closing RabbitMQ connection", "op", op)
 
+	done := make(chan error, 1)
 	go func() {
-		if r.Conn != nil {
-			done <- r.Conn.Close()
-		} else {
-			done <- nil
-		}
+		done <- r.conn.Close()
 	}()
 
 	select {
@@ -154,3 +391,24 @@ func (r *RabbitMQ) CloseWithContext(ctx context.Context) error {
 
 	return nil
 }
+
+// shutdown marks client closed with the given reason. Returns false if it was already closed.
+func (r *RabbitMQ) shutdown(reason error) bool {
+	if r.closed.Swap(true) {
+		return false
+	}
+
+	r.err = reason
+	close(r.done)
+
+	return true
+}
+
+func isClosedChan(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,187 @@
+package rabbit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Exchange describes exchange declaration.
+type Exchange struct {
+	Name       string
+	Kind       string
+	Durable    bool
+	AutoDelete bool
+	Internal   bool
+	Args       amqp.Table
+}
+
+// Queue describes queue declaration.
+type Queue struct {
+	Name       string
+	Durable    bool
+	AutoDelete bool
+	Exclusive  bool
+	Args       amqp.Table
+}
+
+// Binding describes binding of queue to exchange.
+type Binding struct {
+	Queue    string
+	Key      string
+	Exchange string
+	Args     amqp.Table
+}
+
+// topology keeps declared exchanges, queues and bindings to declare them again after reconnect.
+// Server-named queues (empty name) are not registered: their name changes with every
+// declaration, so owners of such queues have to declare them again by themselves.
+type topology struct {
+	mu        sync.Mutex
+	exchanges []Exchange
+	queues    []Queue
+	bindings  []Binding
+}
+
+func newTopology() *topology {
+	return &topology{}
+}
+
+// DeclareExchange declares exchange and registers it in the topology.
+func (r *RabbitMQ) DeclareExchange(ctx context.Context, e Exchange) error {
+	if err := r.WithChannel(ctx, func(ch *amqp.Channel) error {
+		return declareExchange(ch, e)
+	}); err != nil {
+		return err
+	}
+
+	r.topology.addExchange(e)
+	return nil
+}
+
+// DeclareQueue declares queue and registers it in the topology if it's named.
+func (r *RabbitMQ) DeclareQueue(ctx context.Context, q Queue) (amqp.Queue, error) {
+	var declared amqp.Queue
+	if err := r.WithChannel(ctx, func(ch *amqp.Channel) error {
+		var err error
+		declared, err = declareQueue(ch, q)
+		return err
+	}); err != nil {
+		return amqp.Queue{}, err
+	}
+
+	if q.Name != "" {
+		r.topology.addQueue(q)
+	}
+	return declared, nil
+}
+
+// BindQueue binds queue to exchange and registers binding in the topology if the queue is registered.
+func (r *RabbitMQ) BindQueue(ctx context.Context, b Binding) error {
+	if err := r.WithChannel(ctx, func(ch *amqp.Channel) error {
+		return ch.QueueBind(b.Queue, b.Key, b.Exchange, false, b.Args)
+	}); err != nil {
+		return fmt.Errorf("failed to bind queue %s: %w", b.Queue, err)
+	}
+
+	r.topology.addBinding(b)
+	return nil
+}
+
+func (t *topology) addExchange(e Exchange) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i := range t.exchanges {
+		if t.exchanges[i].Name == e.Name {
+			t.exchanges[i] = e
+			return
+		}
+	}
+	t.exchanges = append(t.exchanges, e)
+}
+
+func (t *topology) addQueue(q Queue) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i := range t.queues {
+		if t.queues[i].Name == q.Name {
+			t.queues[i] = q
+			return
+		}
+	}
+	t.queues = append(t.queues, q)
+}
+
+func (t *topology) addBinding(b Binding) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	registered := false
+	for _, q := range t.queues {
+		if q.Name == b.Queue {
+			registered = true
+			break
+		}
+	}
+	if !registered {
+		return
+	}
+
+	for _, existing := range t.bindings {
+		if existing.Queue == b.Queue && existing.Key == b.Key && existing.Exchange == b.Exchange {
+			return
+		}
+	}
+	t.bindings = append(t.bindings, b)
+}
+
+// declare declares all registered exchanges, queues and bindings.
+func (t *topology) declare(ctx context.Context, pool *channelPool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ch, err := pool.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer ch.release()
+
+	for _, e := range t.exchanges {
+		if err := declareExchange(ch.Channel, e); err != nil {
+			return err
+		}
+	}
+
+	for _, q := range t.queues {
+		if _, err := declareQueue(ch.Channel, q); err != nil {
+			return err
+		}
+	}
+
+	for _, b := range t.bindings {
+		if err := ch.QueueBind(b.Queue, b.Key, b.Exchange, false, b.Args); err != nil {
+			return fmt.Errorf("failed to bind queue %s: %w", b.Queue, err)
+		}
+	}
+
+	return nil
+}
+
+func declareExchange(ch *amqp.Channel, e Exchange) error {
+	if err := ch.ExchangeDeclare(e.Name, e.Kind, e.Durable, e.AutoDelete, e.Internal, false, e.Args); err != nil {
+		return fmt.Errorf("failed to declare exchange %s: %w", e.Name, err)
+	}
+	return nil
+}
+
+func declareQueue(ch *amqp.Channel, q Queue) (amqp.Queue, error) {
+	declared, err := ch.QueueDeclare(q.Name, q.Durable, q.AutoDelete, q.Exclusive, false, q.Args)
+	if err != nil {
+		return amqp.Queue{}, fmt.Errorf("failed to declare queue %s: %w", q.Name, err)
+	}
+	return declared, nil
+}
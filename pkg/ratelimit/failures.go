@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Failures limits failed attempts of clients, e.g. failed authentications. Only failures take
+// tokens, a client is blocked while its bucket is empty. Buckets are kept in memory of the process.
+type Failures struct {
+	store *MemoryStore
+	limit Limit
+}
+
+func NewFailures(limit Limit) *Failures {
+	return &Failures{
+		store: NewMemoryStore(),
+		limit: limit,
+	}
+}
+
+// Blocked reports whether the client has no attempts left and time until it has one.
+func (f *Failures) Blocked(client string) (bool, time.Duration) {
+	tokens := f.store.peek(client, f.limit)
+	if tokens >= 1 {
+		return false, 0
+	}
+	return true, seconds((1 - tokens) / f.limit.Rate)
+}
+
+// Fail takes an attempt of the client.
+func (f *Failures) Fail(client string) {
+	f.store.Take(context.Background(), client, f.limit)
+}
+
+// Cleanup removes buckets of clients which did not fail for long enough for them to be full.
+func (f *Failures) Cleanup() {
+	f.store.Cleanup(context.Background(), f.MaxIdle())
+}
+
+// MaxIdle returns time an empty bucket takes to get full.
+func (f *Failures) MaxIdle() time.Duration {
+	return seconds(float64(f.limit.Burst) / f.limit.Rate)
+}
@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// MemoryStore keeps buckets in memory of the process.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (float64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := s.refill(key, limit)
+	if b.tokens < 1 {
+		return b.tokens, false, nil
+	}
+
+	b.tokens--
+	return b.tokens, true, nil
+}
+
+// peek returns tokens of the bucket without taking one.
+func (s *MemoryStore) peek(key string, limit Limit) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.buckets[key]; !ok {
+		return float64(limit.Burst) // not creating buckets of clients which only pass by
+	}
+	return s.refill(key, limit).tokens
+}
+
+// refill returns bucket of key with tokens added since it was updated, s.mu must be held.
+func (s *MemoryStore) refill(key string, limit Limit) *bucket {
+	now := s.now()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = min(float64(limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*limit.Rate)
+	b.updated = now
+
+	return b
+}
+
+func (s *MemoryStore) Cleanup(_ context.Context, idle time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for key, b := range s.buckets {
+		if now.Sub(b.updated) > idle {
+			delete(s.buckets, key)
+		}
+	}
+
+	return nil
+}
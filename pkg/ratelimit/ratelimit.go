@@ -0,0 +1,118 @@
+// Package ratelimit implements token bucket rate limiting.
+//
+// Every client has a bucket of Burst tokens refilled with Rate tokens per second,
+// a request takes one token and is rejected when the bucket is empty.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidLimit = errors.New("invalid rate limit")
+
+// Limit is a token bucket size and refill rate.
+type Limit struct {
+	Rate  float64 // tokens per second
+	Burst int     // bucket size
+}
+
+// ParseLimit parses limit in 'rate:burst' format, e.g. '5:10' is 5 requests per second with bursts of 10.
+func ParseLimit(s string) (Limit, error) {
+	rate, burst, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return Limit{}, fmt.Errorf("%w %q: must be 'rate:burst'", ErrInvalidLimit, s)
+	}
+
+	r, err := strconv.ParseFloat(rate, 64)
+	if err != nil || r <= 0 {
+		return Limit{}, fmt.Errorf("%w %q: rate must be a positive number", ErrInvalidLimit, s)
+	}
+
+	b, err := strconv.Atoi(burst)
+	if err != nil || b < 1 {
+		return Limit{}, fmt.Errorf("%w %q: burst must be a positive integer", ErrInvalidLimit, s)
+	}
+
+	return Limit{Rate: r, Burst: b}, nil
+}
+
+// Result is a decision about a request.
+type Result struct {
+	Allowed    bool
+	Limit      int           // bucket size
+	Remaining  int           // tokens left after the request
+	Reset      time.Duration // time until the bucket is full again
+	RetryAfter time.Duration // time until a token is available, only if not allowed
+}
+
+// Store keeps buckets. Take refills bucket of key and takes a token from it if there is one.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (tokens float64, allowed bool, err error)
+	// Cleanup removes buckets not used longer than idle, they are full anyway.
+	Cleanup(ctx context.Context, idle time.Duration) error
+}
+
+// Limiter limits requests of clients to routes.
+type Limiter struct {
+	store        Store
+	defaultLimit Limit
+	routes       map[string]Limit
+}
+
+// New creates limiter. Routes without their own limit use defaultLimit.
+func New(store Store, defaultLimit Limit, routes map[string]Limit) *Limiter {
+	return &Limiter{
+		store:        store,
+		defaultLimit: defaultLimit,
+		routes:       routes,
+	}
+}
+
+// Allow takes a token from the bucket of the client for the route.
+func (l *Limiter) Allow(ctx context.Context, route, client string) (Result, error) {
+	limit, ok := l.routes[route]
+	if !ok {
+		limit = l.defaultLimit
+	}
+
+	tokens, allowed, err := l.store.Take(ctx, route+"|"+client, limit)
+	if err != nil {
+		return Result{}, err
+	}
+
+	res := Result{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: max(int(math.Floor(tokens)), 0),
+		Reset:     seconds((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+	if !allowed {
+		res.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+
+	return res, nil
+}
+
+// Cleanup removes idle buckets.
+func (l *Limiter) Cleanup(ctx context.Context, idle time.Duration) error {
+	return l.store.Cleanup(ctx, idle)
+}
+
+// MaxIdle returns time an empty bucket takes to get full, buckets idle for longer may be removed.
+func (l *Limiter) MaxIdle() time.Duration {
+	idle := seconds(float64(l.defaultLimit.Burst) / l.defaultLimit.Rate)
+	for _, limit := range l.routes {
+		idle = max(idle, seconds(float64(limit.Burst)/limit.Rate))
+	}
+	return idle
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(max(s, 0) * float64(time.Second))
+}
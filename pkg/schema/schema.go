@@ -0,0 +1,249 @@
+// Package schema decodes versioned JSON messages. Every version of a message is a Go type, messages
+// of older versions are decoded strictly into their own type and upcast to the current one.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"wheres-my-pizza/pkg/validator"
+)
+
+// Field is the name of the body field and of the message header with the schema version.
+const Field = "schema_version"
+
+// Unversioned is the version of messages without schema version: they were published before
+// messages were versioned.
+const Unversioned = 1
+
+type version[T any] struct {
+	decode  func(data []byte) (T, error)
+	example []byte
+}
+
+// Registry decodes all supported versions of a message into its current version T.
+type Registry[T any] struct {
+	name     string
+	current  int
+	versions map[int]version[T]
+}
+
+// NewRegistry creates registry of the message whose current version is T. example is a message of
+// the current version, see Check.
+func NewRegistry[T any](name string, current int, example []byte) *Registry[T] {
+	return &Registry[T]{
+		name:    name,
+		current: current,
+		versions: map[int]version[T]{
+			current: {decode: Strict[T], example: example},
+		},
+	}
+}
+
+// Register adds older version V of the message, upcast converts it to the current version.
+// example is the example message of the current version in version V.
+func Register[V, T any](r *Registry[T], v int, upcast func(V) T, example []byte) {
+	r.versions[v] = version[T]{
+		decode: func(data []byte) (T, error) {
+			old, err := Strict[V](data)
+			if err != nil {
+				var zero T
+				return zero, err
+			}
+			return upcast(old), nil
+		},
+		example: example,
+	}
+}
+
+// Version returns the current version of the message.
+func (r *Registry[T]) Version() int {
+	return r.current
+}
+
+// Decode decodes message of version v into the current version. Version 0 means the transport
+// didn't tell the version, then it is read from the body, messages without it are Unversioned.
+func (r *Registry[T]) Decode(v int, data []byte) (T, error) {
+	var zero T
+
+	inBody, err := bodyVersion(data)
+	if err != nil {
+		return zero, fmt.Errorf("%s: %w", r.name, err)
+	}
+	switch {
+	case v == 0 && inBody == 0:
+		v = Unversioned
+	case v == 0:
+		v = inBody
+	case inBody != 0 && inBody != v:
+		return zero, fmt.Errorf("%s: version %d of the body differs from version %d of the message", r.name, inBody, v)
+	}
+
+	ver, ok := r.versions[v]
+	if !ok {
+		return zero, fmt.Errorf("%s: unsupported version %d, current is %d", r.name, v, r.current)
+	}
+
+	msg, err := ver.decode(data)
+	if err != nil {
+		return zero, fmt.Errorf("%s v%d: %w", r.name, v, err)
+	}
+	return msg, nil
+}
+
+// Check returns error if examples of the versions break backward compatibility: the example of
+// every version must decode and upcast to the example of the current version, and the current
+// example must survive encoding and strict decoding.
+func (r *Registry[T]) Check() error {
+	want, err := r.Decode(r.current, r.versions[r.current].example)
+	if err != nil {
+		return fmt.Errorf("example: %w", err)
+	}
+
+	data, err := json.Marshal(want)
+	if err != nil {
+		return fmt.Errorf("%s: %w", r.name, err)
+	}
+	got, err := r.Decode(r.current, data)
+	if err != nil {
+		return fmt.Errorf("encoded example: %w", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		return fmt.Errorf("%s v%d: encoded example decodes to %+v, want %+v", r.name, r.current, got, want)
+	}
+
+	var errs []error
+	for _, v := range slices.Sorted(maps.Keys(r.versions)) {
+		if v == r.current {
+			continue
+		}
+		got, err := r.Decode(v, r.versions[v].example)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("example: %w", err))
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			errs = append(errs, fmt.Errorf("%s v%d: example upcasts to %+v, want %+v", r.name, v, got, want))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Strict decodes JSON object of type T. Unknown fields, data after the object and missing required
+// fields are errors, fields are required unless they are tagged omitempty or omitzero. null is the
+// same as a missing field.
+func Strict[T any](data []byte) (T, error) {
+	var msg, zero T
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&msg); err != nil {
+		return zero, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return zero, errors.New("unexpected data after the message")
+	}
+
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return zero, err
+	}
+	if err := required(reflect.TypeFor[T](), raw, nil); err != nil {
+		return zero, err
+	}
+
+	return msg, nil
+}
+
+// bodyVersion returns version from the body field, 0 if there is no such field.
+func bodyVersion(data []byte) (int, error) {
+	var body struct {
+		Version *int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return 0, err
+	}
+	if body.Version == nil {
+		return 0, nil
+	}
+	if *body.Version < 1 {
+		return 0, fmt.Errorf("invalid version %d", *body.Version)
+	}
+	return *body.Version, nil
+}
+
+// required returns errors of missing required fields of value of type typ, path is the validator
+// path of the value.
+func required(typ reflect.Type, value any, path []any) error {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	var errs []error
+	switch typ.Kind() {
+	case reflect.Struct:
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return nil // types with their own encoding, e.g. time.Time
+		}
+		for _, f := range fields(typ) {
+			at := append(slices.Clip(path), f.name)
+			v, ok := obj[f.name]
+			if !ok || v == nil {
+				if f.required {
+					errs = append(errs, fmt.Errorf("field %s is required", validator.Path(at...)))
+				}
+				continue
+			}
+			errs = append(errs, required(f.typ, v, at))
+		}
+	case reflect.Slice, reflect.Array:
+		arr, _ := value.([]any)
+		for i, v := range arr {
+			errs = append(errs, required(typ.Elem(), v, append(slices.Clip(path), i)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type field struct {
+	name     string
+	typ      reflect.Type
+	required bool
+}
+
+// fields returns JSON fields of the struct type, fields of embedded structs are fields of the struct
+// as encoding/json treats them.
+func fields(typ reflect.Type) []field {
+	var out []field
+	for i := range typ.NumField() {
+		f := typ.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		// Fields of embedded structs are promoted even if the struct type is unexported.
+		if name == "" && f.Anonymous && f.Type.Kind() == reflect.Struct {
+			out = append(out, fields(f.Type)...)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		optional := slices.ContainsFunc(strings.Split(opts, ","), func(opt string) bool {
+			return opt == "omitempty" || opt == "omitzero"
+		})
+		out = append(out, field{name: name, typ: f.Type, required: !optional})
+	}
+	return out
+}
@@ -0,0 +1,204 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type item struct {
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity"`
+}
+
+// messageV1 is a message before versioning.
+type messageV1 struct {
+	ID    string `json:"id"`
+	Items []item `json:"items"`
+	Note  string `json:"note,omitempty"`
+}
+
+// message is the current version: it adds version and priority, 1 for v1 messages.
+type message struct {
+	SchemaVersion int    `json:"schema_version"`
+	ID            string `json:"id"`
+	Items         []item `json:"items"`
+	Note          string `json:"note,omitempty"`
+	Priority      int    `json:"priority"`
+}
+
+func upcastMessageV1(m messageV1) message {
+	return message{SchemaVersion: 2, ID: m.ID, Items: m.Items, Note: m.Note, Priority: 1}
+}
+
+func newTestRegistry() *Registry[message] {
+	r := NewRegistry[message]("message", 2, []byte(`{"schema_version": 2, "id": "a", "items": [{"name": "x", "quantity": 1}], "priority": 1}`))
+	Register(r, 1, upcastMessageV1, []byte(`{"id": "a", "items": [{"name": "x", "quantity": 1}]}`))
+	return r
+}
+
+func TestStrict(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		err  string // substring of the error, empty if valid
+	}{
+		{name: "valid", data: `{"id": "a", "items": [{"name": "x", "quantity": 1}]}`},
+		{name: "optional field", data: `{"id": "a", "items": [], "note": "n"}`},
+		{name: "unknown field", data: `{"id": "a", "items": [], "extra": 1}`, err: `unknown field "extra"`},
+		{name: "missing required field", data: `{"items": []}`, err: "field id is required"},
+		{name: "missing nested required field", data: `{"id": "a", "items": [{"name": "x"}]}`, err: "field items/0/quantity is required"},
+		{name: "null is missing", data: `{"id": "a", "items": null}`, err: "field items is required"},
+		{name: "null optional field", data: `{"id": "a", "items": [], "note": null}`},
+		{name: "trailing data", data: `{"id": "a", "items": []} {}`, err: "unexpected data after the message"},
+		{name: "not an object", data: `[]`, err: "cannot unmarshal array"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Strict[messageV1]([]byte(tt.data))
+			checkError(t, err, tt.err)
+		})
+	}
+}
+
+func TestStrictEmbedded(t *testing.T) {
+	type wrapped struct {
+		SchemaVersion int `json:"schema_version"`
+		messageV1
+	}
+
+	got, err := Strict[wrapped]([]byte(`{"schema_version": 2, "id": "a", "items": []}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != "a" {
+		t.Errorf("id = %q, want %q", got.ID, "a")
+	}
+
+	_, err = Strict[wrapped]([]byte(`{"schema_version": 2, "items": []}`))
+	checkError(t, err, "field id is required")
+}
+
+func TestDecode(t *testing.T) {
+	current := message{SchemaVersion: 2, ID: "a", Items: []item{{Name: "x", Quantity: 1}}, Priority: 5}
+	upcast := message{SchemaVersion: 2, ID: "a", Items: []item{{Name: "x", Quantity: 1}}, Priority: 1}
+
+	tests := []struct {
+		name    string
+		version int // of the header
+		data    string
+		want    message
+		err     string
+	}{
+		{
+			name: "unversioned is v1",
+			data: `{"id": "a", "items": [{"name": "x", "quantity": 1}]}`,
+			want: upcast,
+		},
+		{
+			name:    "v1 header",
+			version: 1,
+			data:    `{"id": "a", "items": [{"name": "x", "quantity": 1}]}`,
+			want:    upcast,
+		},
+		{
+			name: "version of the body",
+			data: `{"schema_version": 2, "id": "a", "items": [{"name": "x", "quantity": 1}], "priority": 5}`,
+			want: current,
+		},
+		{
+			name:    "version of the header and the body",
+			version: 2,
+			data:    `{"schema_version": 2, "id": "a", "items": [{"name": "x", "quantity": 1}], "priority": 5}`,
+			want:    current,
+		},
+		{
+			name:    "header and body mismatch",
+			version: 1,
+			data:    `{"schema_version": 2, "id": "a", "items": [], "priority": 5}`,
+			err:     "version 2 of the body differs from version 1 of the message",
+		},
+		{
+			name:    "unsupported version of the header",
+			version: 3,
+			data:    `{"id": "a", "items": []}`,
+			err:     "unsupported version 3, current is 2",
+		},
+		{
+			name: "unsupported version of the body",
+			data: `{"schema_version": 3, "id": "a", "items": []}`,
+			err:  "unsupported version 3, current is 2",
+		},
+		{
+			name: "invalid version of the body",
+			data: `{"schema_version": 0, "id": "a", "items": []}`,
+			err:  "invalid version 0",
+		},
+		{
+			name:    "current field in v1 message",
+			version: 1,
+			data:    `{"id": "a", "items": [], "priority": 5}`,
+			err:     `message v1: json: unknown field "priority"`,
+		},
+		{
+			name:    "v1 message as v2",
+			version: 2,
+			data:    `{"id": "a", "items": []}`,
+			err:     "message v2: field schema_version is required",
+		},
+	}
+
+	r := newTestRegistry()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.Decode(tt.version, []byte(tt.data))
+			checkError(t, err, tt.err)
+			if tt.err == "" && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decode() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	if err := newTestRegistry().Check(); err != nil {
+		t.Errorf("compatible versions: %v", err)
+	}
+
+	t.Run("upcaster loses data", func(t *testing.T) {
+		r := newTestRegistry()
+		Register(r, 1, func(m messageV1) message {
+			msg := upcastMessageV1(m)
+			msg.Items = nil
+			return msg
+		}, []byte(`{"id": "a", "items": [{"name": "x", "quantity": 1}]}`))
+
+		checkError(t, r.Check(), "message v1: example upcasts to")
+	})
+
+	t.Run("invalid example", func(t *testing.T) {
+		r := newTestRegistry()
+		Register(r, 1, upcastMessageV1, []byte(`{"id": "a"}`))
+
+		checkError(t, r.Check(), "field items is required")
+	})
+
+	t.Run("current version is not decodable", func(t *testing.T) {
+		r := NewRegistry[message]("message", 2, []byte(`{"schema_version": 2, "id": "a", "items": []}`))
+
+		checkError(t, r.Check(), "field priority is required")
+	})
+}
+
+func checkError(t *testing.T, err error, want string) {
+	t.Helper()
+	switch {
+	case want == "" && err != nil:
+		t.Fatalf("unexpected error: %v", err)
+	case want != "" && err == nil:
+		t.Fatalf("error %q expected", want)
+	case want != "" && !strings.Contains(err.Error(), want):
+		t.Fatalf("error %q, want %q", err, want)
+	}
+}
@@ -1,51 +1,113 @@
 package semaphore
 
-import "time"
+import (
+	"context"
+	"sync"
+	"time"
+)
 
 // Semaphore implements a classic counting semaphore pattern for limiting concurrency.
+// Its limit can be changed while permits are held, see Resize.
 type Semaphore struct {
-	sem chan struct{}
+	mu   sync.Mutex
+	max  int
+	used int
+	free chan struct{} // closed and replaced when a permit may have become available
 }
 
 // NewSemaphore creates a new Semaphore with the specified maximum concurrency limit.
 // The max parameter determines how many concurrent Acquire operations can succeed.
 func NewSemaphore(max int) *Semaphore {
 	return &Semaphore{
-		sem: make(chan struct{}, max),
+		max:  max,
+		free: make(chan struct{}),
 	}
 }
 
 // Acquire blocks until a semaphore permit is available.
 // If the semaphore is at max capacity, it will wait until Release is called.
 func (s *Semaphore) Acquire() {
-	s.sem <- struct{}{} // Send to channel (takes a slot)
+	s.acquire(nil)
 }
 
 // Release frees a semaphore permit, allowing another Acquire to succeed.
 // It must be called after Acquire to prevent deadlocks.
 func (s *Semaphore) Release() {
-	<-s.sem // Receive from channel (frees a slot)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.used--
+	s.notify()
+}
+
+// AcquireContext blocks until a semaphore permit is available or ctx is done.
+// Returns ctx error if the permit was not acquired.
+func (s *Semaphore) AcquireContext(ctx context.Context) error {
+	if !s.acquire(ctx.Done()) {
+		return ctx.Err()
+	}
+	return nil
 }
 
 // TryAcquire attempts to acquire a permit within the specified timeout.
 // Returns true if the permit was acquired, false if the timeout elapsed.
 func (s *Semaphore) TryAcquire(timeout time.Duration) bool {
-	select {
-	case s.sem <- struct{}{}:
-		return true
-	case <-time.After(timeout):
-		return false
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return s.acquire(ctx.Done())
+}
+
+// Resize changes the maximum number of permits. Lowering it doesn't take permits back,
+// new ones are given only after enough of held permits are released.
+func (s *Semaphore) Resize(max int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.max = max
+	s.notify()
 }
 
 // Available returns the number of permits currently available (not in use).
 // This is calculated as (total capacity) - (currently used permits).
 func (s *Semaphore) Available() int {
-	return cap(s.sem) - len(s.sem)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return max(s.max-s.used, 0)
 }
 
 // Used returns the number of permits currently in use.
 // This is equivalent to the number of active Acquire operations not yet Released.
 func (s *Semaphore) Used() int {
-	return len(s.sem)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.used
+}
+
+// acquire waits for a permit until done is closed, reports whether the permit was acquired.
+func (s *Semaphore) acquire(done <-chan struct{}) bool {
+	for {
+		s.mu.Lock()
+		if s.used < s.max {
+			s.used++
+			s.mu.Unlock()
+			return true
+		}
+		free := s.free
+		s.mu.Unlock()
+
+		select {
+		case <-free:
+		case <-done:
+			return false
+		}
+	}
+}
+
+// notify wakes up waiters. Must be called with s.mu held.
+func (s *Semaphore) notify() {
+	close(s.free)
+	s.free = make(chan struct{})
 }
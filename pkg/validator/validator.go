@@ -1,6 +1,8 @@
 package validator
 
 import (
+	"fmt"
+	"maps"
 	"regexp"
 	"slices"
 	"strings"
@@ -45,6 +47,18 @@ func (v *Validator) Check(ok bool, key, message string) {
 	}
 }
 
+// Path returns key of a nested field, e.g. Path("items", 3, "price") is "items/3/price".
+// Keys are JSON pointers (RFC 6901) without the leading slash, so '~' and '/' in segments are escaped.
+func Path(segments ...any) string {
+	parts := make([]string, len(segments))
+	for i, s := range segments {
+		parts[i] = pointerEscaper.Replace(fmt.Sprint(s))
+	}
+	return strings.Join(parts, "/")
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
 // Generic function which returns true if a specific value is in a list.
 func PermittedValue[T comparable](value T, permittedValues ...T) bool {
 	return slices.Contains(permittedValues, value)
@@ -73,15 +87,13 @@ func (v *Validator) Error() string {
 	var sb strings.Builder
 	sb.WriteString("validation failed: ")
 
-	i := 0
-	for field, err := range v.Errors {
+	for i, field := range slices.Sorted(maps.Keys(v.Errors)) {
 		if i > 0 {
 			sb.WriteString("; ")
 		}
 		sb.WriteString(field)
 		sb.WriteString(": ")
-		sb.WriteString(err)
-		i++
+		sb.WriteString(v.Errors[field])
 	}
 
 	return sb.String()